@@ -3,7 +3,10 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/uznog/yamlist/internal/nvim"
@@ -18,9 +21,16 @@ var (
 func main() {
 	// Command line flags
 	noIcons := flag.Bool("no-icons", false, "Use ASCII characters instead of Nerd Font icons")
+	noComments := flag.Bool("no-comments", false, "Hide YAML comments next to rows (toggle with c)")
 	maxPreviewLines := flag.Int("max-preview-lines", 200, "Maximum lines to show in preview pane")
+	preview := flag.String("preview", "off", "Preview pane at startup: off, right, bottom (toggle with p)")
 	theme := flag.String("theme", "auto", "Color theme: auto, dark, mono")
+	formatName := flag.String("format", "auto", "Input format: auto, yaml, json, toml")
+	noWatch := flag.Bool("no-watch", false, "Do not reload when the file changes")
+	strict := flag.Bool("strict", false, "Exit on syntax errors instead of showing the parseable part")
 	nvimSocket := flag.String("nvim-socket", "", "Unix socket path for Neovim cursor sync")
+	nvimServer := flag.String("nvim-server", "", "Neovim server address for msgpack-RPC sync (default: $NVIM)")
+	labelKeys := flag.String("label-keys", "name,id,key,host,path", "Comma-separated keys that label list items (empty to turn off)")
 	showVersion := flag.Bool("version", false, "Show version and exit")
 	flag.Parse()
 
@@ -36,28 +46,120 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Get file path
+	// Validate preview position
+	validPreviews := map[string]bool{"off": true, "right": true, "bottom": true}
+	if !validPreviews[*preview] {
+		fmt.Fprintf(os.Stderr, "Error: invalid preview %q (use: off, right, bottom)\n", *preview)
+		os.Exit(1)
+	}
+
+	// Validate format
+	format, err := yamlparse.ParseFormat(*formatName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid format %q (use: auto, yaml, json, toml)\n", *formatName)
+		os.Exit(1)
+	}
+
+	// Get file path ("-" or piped stdin without a file reads from stdin)
 	args := flag.Args()
-	if len(args) < 1 {
-		fmt.Fprintln(os.Stderr, "Usage: yamlist [options] <file.yaml>")
+	readStdin := (len(args) == 0 && stdinIsPiped()) || (len(args) > 0 && args[0] == "-")
+
+	// Neovim's own server: explicit, or $NVIM when run from a :terminal
+	// (the plugin's --nvim-socket takes precedence)
+	serverAddress := *nvimServer
+	if serverAddress == "" && *nvimSocket == "" && !readStdin {
+		serverAddress = os.Getenv("NVIM")
+	}
+
+	// Without a file, a :terminal shows the buffer it was opened from
+	readBuffer := len(args) == 0 && !readStdin && serverAddress != ""
+	if len(args) < 1 && !readStdin && !readBuffer {
+		fmt.Fprintln(os.Stderr, "Usage: yamlist [options] <file.yaml|file.json|file.toml>")
+		fmt.Fprintln(os.Stderr, "       <command> | yamlist [options] [-]")
+		fmt.Fprintln(os.Stderr, "       yamlist [options]   (in a Neovim :terminal: the current buffer)")
 		fmt.Fprintln(os.Stderr, "")
 		fmt.Fprintln(os.Stderr, "Options:")
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
-	filePath := args[0]
 
-	// Check file exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "Error: file not found: %s\n", filePath)
-		os.Exit(1)
+	// Connect to Neovim's server first: the buffer may be the input
+	var nvimClient *nvim.Client
+	if serverAddress != "" {
+		filePath := ""
+		if len(args) > 0 {
+			filePath, _ = filepath.Abs(args[0])
+		}
+		client, err := nvim.NewRPCClient(serverAddress, filePath)
+		if err != nil {
+			if readBuffer {
+				fmt.Fprintf(os.Stderr, "Error connecting to Neovim: %v\n", err)
+				os.Exit(1)
+			}
+			// $NVIM is set in every :terminal, so only warn when asked for
+			if *nvimServer != "" {
+				fmt.Fprintf(os.Stderr, "Warning: could not connect to Neovim server: %v\n", err)
+			}
+		} else {
+			nvimClient = client
+			defer nvimClient.Close()
+		}
 	}
 
-	// Parse YAML file
-	doc, err := yamlparse.ParseFile(filePath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing YAML: %v\n", err)
-		os.Exit(1)
+	var doc *yamlparse.Document
+	if readBuffer {
+		// Parse the Neovim buffer, including unsaved edits
+		text, name, err := nvimClient.BufferText()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading Neovim buffer: %v\n", err)
+			os.Exit(1)
+		}
+		if *strict {
+			doc, err = yamlparse.ParseBytesAs([]byte(text), name, format)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error parsing buffer: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			doc = yamlparse.ParseBytesTolerant([]byte(text), name, format)
+		}
+	} else if readStdin {
+		// Parse YAML, JSON or TOML from stdin
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+			os.Exit(1)
+		}
+		if *strict {
+			doc, err = yamlparse.ParseBytesAs(data, yamlparse.StdinPath, format)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error parsing stdin: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			// Tolerant parse: syntax errors are shown in the TUI
+			doc = yamlparse.ParseBytesTolerant(data, yamlparse.StdinPath, format)
+		}
+	} else {
+		filePath := args[0]
+
+		// Check file exists
+		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Error: file not found: %s\n", filePath)
+			os.Exit(1)
+		}
+
+		// Parse YAML, JSON or TOML file
+		// Unless strict, syntax errors are shown in the TUI
+		if *strict {
+			doc, err = yamlparse.ParseFileAs(filePath, format)
+		} else {
+			doc, err = yamlparse.ParseFileTolerant(filePath, format)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", filePath, err)
+			os.Exit(1)
+		}
 	}
 
 	// Create config
@@ -65,10 +167,13 @@ func main() {
 		UseIcons:        !*noIcons,
 		MaxPreviewLines: *maxPreviewLines,
 		Theme:           *theme,
+		ShowComments:    !*noComments,
+		Watch:           !*noWatch,
+		Preview:         *preview,
+		LabelKeys:       splitList(*labelKeys),
 	}
 
 	// Create Neovim client if socket path provided
-	var nvimClient *nvim.Client
 	if *nvimSocket != "" {
 		client, err := nvim.NewClient(*nvimSocket)
 		if err != nil {
@@ -82,10 +187,35 @@ func main() {
 
 	// Create and run TUI
 	model := tui.NewModel(doc, config, nvimClient)
-	p := tea.NewProgram(model, tea.WithAltScreen())
+	opts := []tea.ProgramOption{tea.WithAltScreen()}
+	if readStdin {
+		// Stdin holds the document, so read keys from the terminal
+		opts = append(opts, tea.WithInputTTY())
+	}
+	p := tea.NewProgram(model, opts...)
 
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error running TUI: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// stdinIsPiped returns true if stdin is a pipe or file rather than a terminal
+func stdinIsPiped() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice == 0
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
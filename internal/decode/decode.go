@@ -0,0 +1,304 @@
+package decode
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kind is an encoding found in a value
+type Kind int
+
+const (
+	Base64 Kind = iota
+	JSON
+	YAML
+	PEM
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Base64:
+		return "base64"
+	case JSON:
+		return "json"
+	case YAML:
+		return "yaml"
+	case PEM:
+		return "pem"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	// minBase64Length is the shortest value taken for base64; shorter
+	// ones are too often plain words
+	minBase64Length = 8
+
+	// maxLayers is how many encodings inside each other are decoded
+	maxLayers = 4
+)
+
+// Decoded is a value with its encodings undone
+type Decoded struct {
+	// Kinds are the encodings found, outermost first (e.g. base64, pem)
+	Kinds []Kind
+
+	// Text is the innermost decoded text
+	Text string
+
+	// Blocks are the PEM blocks when the innermost encoding is PEM
+	Blocks []Block
+}
+
+// Block is a summary of a PEM block
+type Block struct {
+	Type        string
+	Size        int          // Length of the decoded bytes
+	Certificate *Certificate // nil unless the block is a parseable certificate
+}
+
+// Certificate is a summary of an X.509 certificate
+type Certificate struct {
+	Subject   string
+	Issuer    string
+	NotBefore time.Time
+	NotAfter  time.Time
+	DNSNames  []string
+	IsCA      bool
+}
+
+// Decode detects the encodings of value and undoes them
+// Returns false if value is not base64, a JSON or YAML map or list, or PEM.
+func Decode(value string) (*Decoded, bool) {
+	d := &Decoded{Text: value}
+	for len(d.Kinds) < maxLayers {
+		kind, text, ok := decodeLayer(d.Text, len(d.Kinds) > 0)
+		if !ok {
+			break
+		}
+		d.Kinds = append(d.Kinds, kind)
+		d.Text = text
+		if kind != Base64 {
+			break
+		}
+	}
+	if len(d.Kinds) == 0 {
+		return nil, false
+	}
+	if d.Last() == PEM {
+		d.Blocks = parsePEM(d.Text)
+	}
+	return d, true
+}
+
+// Last returns the innermost encoding
+func (d *Decoded) Last() Kind {
+	return d.Kinds[len(d.Kinds)-1]
+}
+
+// IsTree returns true if the decoded text is a JSON or YAML document
+func (d *Decoded) IsTree() bool {
+	return d.Last() == JSON || d.Last() == YAML
+}
+
+// Label describes the encodings, e.g. "base64 → pem"
+func (d *Decoded) Label() string {
+	names := make([]string, len(d.Kinds))
+	for i, kind := range d.Kinds {
+		names[i] = kind.String()
+	}
+	return strings.Join(names, " → ")
+}
+
+// Lines returns the decoded value for display: the text, JSON indented,
+// or a summary of each PEM block
+func (d *Decoded) Lines() []string {
+	switch d.Last() {
+	case PEM:
+		var lines []string
+		for i, block := range d.Blocks {
+			if i > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, block.Summary()...)
+		}
+		return lines
+	case JSON:
+		var buf bytes.Buffer
+		if json.Indent(&buf, []byte(strings.TrimSpace(d.Text)), "", "  ") == nil {
+			return strings.Split(buf.String(), "\n")
+		}
+	}
+	return strings.Split(strings.TrimSuffix(d.Text, "\n"), "\n")
+}
+
+// Summary returns a few lines describing the block
+func (b Block) Summary() []string {
+	c := b.Certificate
+	if c == nil {
+		return []string{b.Type + " (" + formatSize(b.Size) + ")"}
+	}
+
+	title := b.Type
+	if c.IsCA {
+		title += " (CA)"
+	}
+	lines := []string{
+		title,
+		"  subject:  " + c.Subject,
+		"  issuer:   " + c.Issuer,
+		"  valid:    " + c.NotBefore.UTC().Format(time.DateOnly) + " to " + c.NotAfter.UTC().Format(time.DateOnly),
+	}
+	if c.Expired(time.Now()) {
+		lines[len(lines)-1] += " (expired)"
+	}
+	if len(c.DNSNames) > 0 {
+		lines = append(lines, "  dns:      "+strings.Join(c.DNSNames, ", "))
+	}
+	return lines
+}
+
+// Expired returns true if the certificate is not valid at t
+func (c *Certificate) Expired(t time.Time) bool {
+	return t.After(c.NotAfter)
+}
+
+// decodeLayer undoes one encoding of text
+// Plain YAML is only taken when inner is set, or when the text spans
+// several lines, so that ordinary strings are not mistaken for documents.
+func decodeLayer(text string, inner bool) (Kind, string, bool) {
+	trimmed := strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(trimmed, "-----BEGIN "):
+		if block, _ := pem.Decode([]byte(trimmed)); block != nil {
+			return PEM, trimmed, true
+		}
+	case strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "["):
+		if json.Valid([]byte(trimmed)) {
+			return JSON, trimmed, true
+		}
+	}
+
+	if isYAMLTree(text, inner) {
+		return YAML, text, true
+	}
+	if decoded, ok := decodeBase64(trimmed); ok {
+		return Base64, decoded, true
+	}
+	return 0, "", false
+}
+
+// isYAMLTree returns true if text is a YAML map or list
+func isYAMLTree(text string, inner bool) bool {
+	if !inner && !strings.Contains(strings.TrimSpace(text), "\n") {
+		return false
+	}
+	var value interface{}
+	if yaml.Unmarshal([]byte(text), &value) != nil {
+		return false
+	}
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	}
+	return false
+}
+
+// decodeBase64 decodes standard or URL-safe base64, padded or not, that
+// holds printable text
+// Line breaks inside the value are ignored. Values that are a single
+// case of letters are left alone: they are words far more often.
+func decodeBase64(text string) (string, bool) {
+	text = strings.Join(strings.Fields(text), "")
+	if len(text) < minBase64Length || isSingleCaseWord(text) {
+		return "", false
+	}
+
+	for _, enc := range []*base64.Encoding{
+		base64.StdEncoding, base64.RawStdEncoding,
+		base64.URLEncoding, base64.RawURLEncoding,
+	} {
+		data, err := enc.DecodeString(text)
+		if err == nil && isText(data) {
+			return string(data), true
+		}
+	}
+	return "", false
+}
+
+// isSingleCaseWord returns true if s is all lower or all upper case letters
+func isSingleCaseWord(s string) bool {
+	lower, upper := false, false
+	for _, r := range s {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		default:
+			return false
+		}
+	}
+	return lower != upper
+}
+
+// isText returns true if data is non-empty UTF-8 without control characters
+// other than whitespace
+func isText(data []byte) bool {
+	if len(data) == 0 || !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// parsePEM summarizes the PEM blocks in text
+func parsePEM(text string) []Block {
+	var blocks []Block
+	rest := []byte(text)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return blocks
+		}
+
+		b := Block{Type: block.Type, Size: len(block.Bytes)}
+		if block.Type == "CERTIFICATE" {
+			if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+				b.Certificate = &Certificate{
+					Subject:   cert.Subject.String(),
+					Issuer:    cert.Issuer.String(),
+					NotBefore: cert.NotBefore,
+					NotAfter:  cert.NotAfter,
+					DNSNames:  cert.DNSNames,
+					IsCA:      cert.IsCA,
+				}
+			}
+		}
+		blocks = append(blocks, b)
+	}
+}
+
+// formatSize formats a byte count like "1190 bytes"
+func formatSize(n int) string {
+	if n == 1 {
+		return "1 byte"
+	}
+	return strconv.Itoa(n) + " bytes"
+}
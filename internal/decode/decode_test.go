@@ -0,0 +1,106 @@
+package decode
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	b64 := base64.StdEncoding.EncodeToString
+
+	tests := []struct {
+		value string
+		label string // "" if the value is not decoded
+		text  string
+	}{
+		{b64([]byte("s3cr3t-password")), "base64", "s3cr3t-password"},
+		{"cGFzc3dvcmQ", "base64", "password"},
+		{b64([]byte(`{"auths": {}}`)), "base64 → json", `{"auths": {}}`},
+		{b64([]byte("a: 1\nb: [2]\n")), "base64 → yaml", "a: 1\nb: [2]\n"},
+		{`{"a": 1}`, "json", `{"a": 1}`},
+		{"[1, 2]", "json", "[1, 2]"},
+		{"server:\n  port: 80\n", "yaml", "server:\n  port: 80\n"},
+		{"production", "", ""},
+		{"Hello123", "", ""},
+		{"short", "", ""},
+		{"just some\nprose", "", ""},
+		{"a: 1", "", ""},
+		{b64([]byte{0, 1, 2, 0xff, 0xfe, 3, 4, 5}), "", ""},
+	}
+
+	for _, tt := range tests {
+		d, ok := Decode(tt.value)
+		if tt.label == "" {
+			if ok {
+				t.Errorf("Decode(%q) = %s, want no decoding", tt.value, d.Label())
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("Decode(%q) failed, want %s", tt.value, tt.label)
+			continue
+		}
+		if d.Label() != tt.label || d.Text != tt.text {
+			t.Errorf("Decode(%q) = %s %q, want %s %q", tt.value, d.Label(), d.Text, tt.label, tt.text)
+		}
+	}
+}
+
+func TestDecode_PEM(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		NotBefore:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC),
+		DNSNames:     []string{"example.com", "www.example.com"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	other := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("key")}))
+
+	// Kubernetes TLS secrets hold base64 of the PEM text
+	d, ok := Decode(base64.StdEncoding.EncodeToString([]byte(cert + other)))
+	if !ok || d.Label() != "base64 → pem" {
+		t.Fatalf("Decode() = %v, %v, want base64 → pem", d, ok)
+	}
+	if len(d.Blocks) != 2 || d.Blocks[0].Certificate == nil || d.Blocks[1].Certificate != nil {
+		t.Fatalf("Blocks = %+v, want a certificate and a key", d.Blocks)
+	}
+
+	want := []string{
+		"CERTIFICATE",
+		"  subject:  CN=example.com",
+		"  issuer:   CN=example.com",
+		"  valid:    2024-01-01 to 2099-01-01",
+		"  dns:      example.com, www.example.com",
+		"",
+		"PRIVATE KEY (3 bytes)",
+	}
+	if got := d.Lines(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Lines() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDecoded_Lines(t *testing.T) {
+	d, _ := Decode(`{"a":[1,2]}`)
+	want := "{\n  \"a\": [\n    1,\n    2\n  ]\n}"
+	if got := strings.Join(d.Lines(), "\n"); got != want {
+		t.Errorf("Lines() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,123 @@
+package history
+
+import "time"
+
+// DefaultMaxEntries is the number of entries kept before the oldest are
+// dropped
+const DefaultMaxEntries = 100
+
+// Entry is one change of the document
+type Entry struct {
+	// Action describes the change, e.g. "rename" or "reload"
+	Action string
+
+	// Path is the path of the changed node ("" for whole-document changes)
+	Path string
+
+	// Before and After are the source text around the change
+	Before []byte
+	After  []byte
+
+	// Time is when the change was made
+	Time time.Time
+}
+
+// History is a linear undo/redo stack
+// Entries before the position are applied; the ones after it were undone
+// and are dropped by the next Push.
+type History struct {
+	entries    []Entry
+	pos        int
+	maxEntries int
+}
+
+// New creates an empty history keeping at most maxEntries entries
+func New(maxEntries int) *History {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &History{maxEntries: maxEntries}
+}
+
+// Push records a change, discarding the undone entries
+// Changes that leave the text as it was are ignored.
+func (h *History) Push(entry Entry) {
+	if string(entry.Before) == string(entry.After) {
+		return
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	h.entries = append(h.entries[:h.pos], entry)
+	if len(h.entries) > h.maxEntries {
+		h.entries = h.entries[len(h.entries)-h.maxEntries:]
+	}
+	h.pos = len(h.entries)
+}
+
+// Undo steps back over the last applied entry
+// Returns the entry; its Before is the text to restore.
+func (h *History) Undo() (Entry, bool) {
+	if !h.CanUndo() {
+		return Entry{}, false
+	}
+	h.pos--
+	return h.entries[h.pos], true
+}
+
+// Redo re-applies the first undone entry
+// Returns the entry; its After is the text to restore.
+func (h *History) Redo() (Entry, bool) {
+	if !h.CanRedo() {
+		return Entry{}, false
+	}
+	h.pos++
+	return h.entries[h.pos-1], true
+}
+
+// Goto moves to the state after the first pos entries
+// Returns the text of that state.
+func (h *History) Goto(pos int) ([]byte, bool) {
+	text, ok := h.State(pos)
+	if ok {
+		h.pos = pos
+	}
+	return text, ok
+}
+
+// State returns the text of the state after the first pos entries
+func (h *History) State(pos int) ([]byte, bool) {
+	if pos < 0 || pos > len(h.entries) || len(h.entries) == 0 {
+		return nil, false
+	}
+	if pos == 0 {
+		return h.entries[0].Before, true
+	}
+	return h.entries[pos-1].After, true
+}
+
+// CanUndo returns true if there is an entry to undo
+func (h *History) CanUndo() bool {
+	return h.pos > 0
+}
+
+// CanRedo returns true if there is an entry to redo
+func (h *History) CanRedo() bool {
+	return h.pos < len(h.entries)
+}
+
+// Entries returns all entries, oldest first
+func (h *History) Entries() []Entry {
+	return h.entries
+}
+
+// Position returns the number of applied entries
+func (h *History) Position() int {
+	return h.pos
+}
+
+// Len returns the number of entries
+func (h *History) Len() int {
+	return len(h.entries)
+}
@@ -0,0 +1,107 @@
+package history
+
+import "testing"
+
+// push records a change from before to after
+func push(h *History, before, after string) {
+	h.Push(Entry{Action: "edit", Before: []byte(before), After: []byte(after)})
+}
+
+func TestUndoRedo(t *testing.T) {
+	h := New(0)
+	push(h, "a", "b")
+	push(h, "b", "c")
+
+	entry, ok := h.Undo()
+	if !ok || string(entry.Before) != "b" {
+		t.Fatalf("Undo() = %q, %v, want before \"b\"", entry.Before, ok)
+	}
+	entry, ok = h.Undo()
+	if !ok || string(entry.Before) != "a" {
+		t.Fatalf("Undo() = %q, %v, want before \"a\"", entry.Before, ok)
+	}
+	if _, ok := h.Undo(); ok {
+		t.Error("Undo() past the first entry succeeded")
+	}
+
+	entry, ok = h.Redo()
+	if !ok || string(entry.After) != "b" {
+		t.Fatalf("Redo() = %q, %v, want after \"b\"", entry.After, ok)
+	}
+
+	// A new change drops the undone entry
+	push(h, "b", "d")
+	if h.CanRedo() {
+		t.Error("CanRedo() = true after a new change")
+	}
+	if h.Len() != 2 || h.Position() != 2 {
+		t.Errorf("Len() = %d, Position() = %d, want 2, 2", h.Len(), h.Position())
+	}
+}
+
+func TestPush_IgnoresNoOps(t *testing.T) {
+	h := New(0)
+	push(h, "a", "a")
+	if h.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", h.Len())
+	}
+}
+
+func TestPush_DropsOldest(t *testing.T) {
+	h := New(2)
+	push(h, "a", "b")
+	push(h, "b", "c")
+	push(h, "c", "d")
+
+	entries := h.Entries()
+	if len(entries) != 2 || string(entries[0].Before) != "b" {
+		t.Fatalf("Entries() = %d entries starting at %q, want 2 starting at \"b\"", len(entries), entries[0].Before)
+	}
+	if h.Position() != 2 {
+		t.Errorf("Position() = %d, want 2", h.Position())
+	}
+}
+
+func TestGoto(t *testing.T) {
+	h := New(0)
+	push(h, "a", "b")
+	push(h, "b", "c")
+
+	tests := []struct {
+		pos  int
+		want string
+	}{
+		{0, "a"},
+		{2, "c"},
+		{1, "b"},
+	}
+	for _, tt := range tests {
+		text, ok := h.Goto(tt.pos)
+		if !ok || string(text) != tt.want {
+			t.Errorf("Goto(%d) = %q, %v, want %q", tt.pos, text, ok, tt.want)
+		}
+		if h.Position() != tt.pos {
+			t.Errorf("Position() = %d after Goto(%d)", h.Position(), tt.pos)
+		}
+	}
+	if _, ok := h.Goto(3); ok {
+		t.Error("Goto(3) succeeded with 2 entries")
+	}
+}
+
+func TestState(t *testing.T) {
+	h := New(0)
+	push(h, "a", "b")
+	push(h, "b", "c")
+	h.Undo()
+
+	if text, ok := h.State(h.Position()); !ok || string(text) != "b" {
+		t.Errorf("State(current) = %q, %v, want \"b\"", text, ok)
+	}
+	if h.Position() != 1 {
+		t.Errorf("Position() = %d after State, want 1", h.Position())
+	}
+	if _, ok := h.State(3); ok {
+		t.Error("State(3) succeeded with 2 entries")
+	}
+}
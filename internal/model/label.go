@@ -0,0 +1,46 @@
+package model
+
+import "strings"
+
+// DefaultLabelKeys are the keys whose value labels a list item, in order
+var DefaultLabelKeys = []string{"name", "id", "key", "host", "path"}
+
+// LabelItems sets the label of every list item under node that is a map
+// The label is the value of the first of keys the item has, or else of its
+// first scalar child. With no keys, labels are cleared.
+func LabelItems(node *Node, keys []string) {
+	if node == nil {
+		return
+	}
+	node.Label = ""
+	if len(keys) > 0 && node.Kind == KindMap && node.Parent != nil &&
+		node.Parent.Kind == KindList && !node.IsDocument {
+		node.Label = itemLabel(node, keys)
+	}
+	for _, child := range node.Children {
+		LabelItems(child, keys)
+	}
+}
+
+// itemLabel returns the label of a list item
+func itemLabel(item *Node, keys []string) string {
+	for _, key := range keys {
+		for _, child := range item.Children {
+			if child.Key == key && isLabelValue(child) {
+				return child.ScalarValue
+			}
+		}
+	}
+	for _, child := range item.Children {
+		if isLabelValue(child) {
+			return child.ScalarValue
+		}
+	}
+	return ""
+}
+
+// isLabelValue returns true if node is a scalar that fits on a row
+func isLabelValue(node *Node) bool {
+	return node.Kind == KindScalar && node.ScalarType != ScalarNull &&
+		node.ScalarValue != "" && !strings.Contains(node.ScalarValue, "\n")
+}
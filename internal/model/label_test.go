@@ -0,0 +1,41 @@
+package model
+
+import "testing"
+
+func TestDisplayKey_Index(t *testing.T) {
+	for index, want := range map[int]string{0: "[0]", 9: "[9]", 12: "[12]", 105: "[105]"} {
+		if got := (&Node{Index: index}).DisplayKey(); got != want {
+			t.Errorf("DisplayKey() of item %d = %q, want %q", index, got, want)
+		}
+	}
+}
+
+func TestLabelItems(t *testing.T) {
+	list := buildList("image=nginx name=web", "port=80 host=db", "a= b=first", "id=7 name=api")
+	nested := buildList("key=inner")
+	nested.Parent = list.Children[0]
+	list.Children[0].Children = append(list.Children[0].Children, nested)
+
+	LabelItems(list, DefaultLabelKeys)
+	for i, want := range []string{"web", "db", "first", "api"} {
+		if got := list.Children[i].Label; got != want {
+			t.Errorf("Label of item %d = %q, want %q", i, got, want)
+		}
+	}
+	if got := nested.Children[0].Label; got != "inner" {
+		t.Errorf("Label of nested item = %q, want inner", got)
+	}
+	if list.Label != "" || list.Children[0].Children[0].Label != "" {
+		t.Error("nodes that are not list items have a label")
+	}
+
+	LabelItems(list, []string{"id"})
+	if got := list.Children[3].Label; got != "7" {
+		t.Errorf("Label with keys [id] = %q, want 7", got)
+	}
+
+	LabelItems(list, nil)
+	if got := list.Children[0].Label; got != "" {
+		t.Errorf("Label with no keys = %q, want none", got)
+	}
+}
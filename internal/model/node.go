@@ -1,5 +1,10 @@
 package model
 
+import (
+	"strconv"
+	"strings"
+)
+
 // NodeKind represents the type of a YAML node
 type NodeKind int
 
@@ -83,7 +88,53 @@ type Node struct {
 	Parent *Node
 
 	// LineNumber is the source line in the YAML file
+	// This is the line of the key for map entries, otherwise of the value
 	LineNumber int
+
+	// KeyPos is the source position of the key (invalid for list items and roots)
+	KeyPos Position
+
+	// ValuePos is the source position of the value
+	ValuePos Position
+
+	// EndPos is the source position just past the end of the value
+	EndPos Position
+
+	// IsDocument is true for the root node of a document in a
+	// multi-document stream (Index holds the document index)
+	IsDocument bool
+
+	// Anchor is the anchor name defined on this node (&name)
+	Anchor string
+
+	// Alias is the anchor name this node was expanded from (*name)
+	Alias string
+
+	// AliasTarget points to the node defining the anchor referenced by Alias
+	AliasTarget *Node
+
+	// Inherited is true for map keys that come from a merge key (<<)
+	Inherited bool
+
+	// MergedFrom points to the anchored node an inherited key came from
+	MergedFrom *Node
+
+	// HeadComment is the comment block above the node
+	HeadComment string
+
+	// LineComment is the comment at the end of the node's line
+	LineComment string
+
+	// FootComment is the comment block below the node
+	FootComment string
+
+	// IsError is true for the pseudo-node marking a parse error
+	// (ScalarValue holds the message, LineNumber the error line)
+	IsError bool
+
+	// Label identifies a list item that is a map, e.g. the value of its
+	// name key (empty if none; see LabelItems)
+	Label string
 }
 
 // IsExpandable returns true if the node can have children
@@ -101,13 +152,93 @@ func (n *Node) ChildCount() int {
 	return len(n.Children)
 }
 
+// IsAlias returns true if the node was expanded from an alias
+func (n *Node) IsAlias() bool {
+	return n.Alias != ""
+}
+
+// AnchorDefinition returns the node defining the anchor this node refers to,
+// either through an alias or a merge key. Returns nil if there is none.
+func (n *Node) AnchorDefinition() *Node {
+	if n.AliasTarget != nil {
+		return n.AliasTarget
+	}
+	return n.MergedFrom
+}
+
+// StartPos returns the position where the node starts in the source:
+// the key for map entries, otherwise the value
+func (n *Node) StartPos() Position {
+	if n.KeyPos.IsValid() {
+		return n.KeyPos
+	}
+	return n.ValuePos
+}
+
+// ContainsLine returns true if the node's source span includes the given line
+func (n *Node) ContainsLine(line int) bool {
+	start := n.StartPos()
+	if !start.IsValid() {
+		return false
+	}
+	end := n.EndPos
+	if !end.IsValid() {
+		end = start
+	}
+	return start.Line <= line && line <= end.Line
+}
+
+// HasComments returns true if the node has any comment attached
+func (n *Node) HasComments() bool {
+	return n.HeadComment != "" || n.LineComment != "" || n.FootComment != ""
+}
+
+// CommentText returns all comments of the node without '#' markers,
+// head comment first, one comment line per line
+func (n *Node) CommentText() string {
+	var lines []string
+	for _, comment := range []string{n.HeadComment, n.LineComment, n.FootComment} {
+		lines = append(lines, commentLines(comment)...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// InlineComment returns a single-line comment to show next to the node:
+// the line comment, or the first line of the head comment
+func (n *Node) InlineComment() string {
+	if lines := commentLines(n.LineComment); len(lines) > 0 {
+		return lines[0]
+	}
+	if lines := commentLines(n.HeadComment); len(lines) > 0 {
+		return lines[0]
+	}
+	return ""
+}
+
+// commentLines splits a raw comment into lines, stripping '#' markers
+// and blank lines
+func commentLines(comment string) []string {
+	var lines []string
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSpace(strings.TrimLeft(line, "#"))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
 // DisplayKey returns the display name for this node
 func (n *Node) DisplayKey() string {
 	if n.Key != "" {
 		return n.Key
 	}
+	if n.IsDocument {
+		return "--- #" + strconv.Itoa(n.Index)
+	}
 	if n.Index >= 0 {
-		return "[" + string(rune('0'+n.Index%10)) + "]"
+		return "[" + strconv.Itoa(n.Index) + "]"
 	}
 	return "(root)"
 }
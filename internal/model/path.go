@@ -1,8 +1,10 @@
 package model
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 // PathSegment represents a single segment in a path
@@ -10,17 +12,30 @@ type PathSegment struct {
 	// Key is the map key (empty for list indices)
 	Key string
 
-	// Index is the list index (-1 for map keys)
+	// Index is the list index (-1 for map keys), or the document index
+	// for document segments
 	Index int
+
+	// Document is true if this segment selects a document in a
+	// multi-document stream
+	Document bool
 }
 
 // IsIndex returns true if this segment represents a list index
 func (s PathSegment) IsIndex() bool {
-	return s.Index >= 0
+	return s.Index >= 0 && !s.Document
+}
+
+// IsDocument returns true if this segment represents a document index
+func (s PathSegment) IsDocument() bool {
+	return s.Document
 }
 
 // String returns the string representation of the segment
 func (s PathSegment) String() string {
+	if s.IsDocument() {
+		return "#" + strconv.Itoa(s.Index)
+	}
 	if s.IsIndex() {
 		return "[" + strconv.Itoa(s.Index) + "]"
 	}
@@ -59,31 +74,122 @@ func (p *Path) AppendIndex(index int) *Path {
 	return p.Append(PathSegment{Index: index})
 }
 
-// String returns the dot-notation string representation
-// Example: "metadata.labels[0].name"
+// AppendDocument creates a new path with the given document index appended
+func (p *Path) AppendDocument(index int) *Path {
+	return p.Append(PathSegment{Index: index, Document: true})
+}
+
+// RootPathString is the string representation of the empty (root) path
+const RootPathString = "(root)"
+
+// String returns the canonical dot-notation string representation
+// Example: "metadata.labels[0].name", or "#1.metadata.name" for the
+// second document of a multi-document stream. Keys that would read
+// differently are double-quoted: metadata.annotations."kubernetes.io/name".
+// ParsePath reads the result back.
 func (p *Path) String() string {
 	if len(p.Segments) == 0 {
-		return "(root)"
+		return RootPathString
 	}
 
 	var b strings.Builder
 	for i, seg := range p.Segments {
-		if seg.IsIndex() {
-			b.WriteString("[")
-			b.WriteString(strconv.Itoa(seg.Index))
-			b.WriteString("]")
-		} else {
-			if i > 0 && !p.Segments[i-1].IsIndex() {
-				b.WriteString(".")
-			} else if i > 0 {
-				b.WriteString(".")
-			}
-			b.WriteString(seg.Key)
+		if seg.IsDocument() || seg.IsIndex() {
+			b.WriteString(seg.String())
+			continue
+		}
+		if i > 0 {
+			b.WriteString(".")
 		}
+		b.WriteString(quoteKey(seg.Key))
 	}
 	return b.String()
 }
 
+// quoteKey returns key as written in a path string: as is, or
+// double-quoted with Go escapes if it contains path syntax, spaces or
+// control characters, or could be mistaken for the root or a document
+func quoteKey(key string) string {
+	if key == "" || key == RootPathString || strings.HasPrefix(key, "#") {
+		return strconv.Quote(key)
+	}
+	for _, r := range key {
+		if strings.ContainsRune(`.[]"\`, r) || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return strconv.Quote(key)
+		}
+	}
+	return key
+}
+
+// ParsePath parses a path string as returned by Path.String
+func ParsePath(s string) (*Path, error) {
+	path := NewPath()
+	if s == RootPathString {
+		return path, nil
+	}
+
+	rest := s
+	if strings.HasPrefix(rest, "#") {
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+		index, err := strconv.Atoi(rest[1:end])
+		if err != nil || index < 0 {
+			return nil, fmt.Errorf("invalid document index in path %q", s)
+		}
+		path = path.AppendDocument(index)
+		rest = strings.TrimPrefix(rest[end:], ".")
+	}
+
+	for rest != "" {
+		switch {
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in path %q", s)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid index %q in path %q", rest[1:end], s)
+			}
+			path = path.AppendIndex(index)
+			rest = rest[end+1:]
+
+		case rest[0] == '"':
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted key in path %q", s)
+			}
+			key, _ := strconv.Unquote(quoted)
+			path = path.AppendKey(key)
+			rest = rest[len(quoted):]
+
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key in path %q", s)
+			}
+			path = path.AppendKey(rest[:end])
+			rest = rest[end:]
+		}
+
+		// Segments are separated by dots, except before an index
+		if strings.HasPrefix(rest, ".") {
+			rest = rest[1:]
+			if rest == "" || rest[0] == '[' {
+				return nil, fmt.Errorf("empty key in path %q", s)
+			}
+		} else if rest != "" && rest[0] != '[' {
+			return nil, fmt.Errorf("unexpected %q in path %q", rest[:1], s)
+		}
+	}
+	return path, nil
+}
+
 // DisplayString returns a human-readable display string
 // This is used for search matching
 func (p *Path) DisplayString() string {
@@ -117,7 +223,7 @@ func (p *Path) Equal(other *Path) bool {
 	}
 	for i, seg := range p.Segments {
 		otherSeg := other.Segments[i]
-		if seg.Key != otherSeg.Key || seg.Index != otherSeg.Index {
+		if seg != otherSeg {
 			return false
 		}
 	}
@@ -131,7 +237,7 @@ func (p *Path) IsAncestorOf(other *Path) bool {
 	}
 	for i, seg := range p.Segments {
 		otherSeg := other.Segments[i]
-		if seg.Key != otherSeg.Key || seg.Index != otherSeg.Index {
+		if seg != otherSeg {
 			return false
 		}
 	}
@@ -0,0 +1,79 @@
+package model
+
+import "testing"
+
+func TestPathString(t *testing.T) {
+	tests := []struct {
+		path *Path
+		want string
+	}{
+		{NewPath(), "(root)"},
+		{NewPath().AppendKey("spec").AppendIndex(0).AppendKey("name"), "spec[0].name"},
+		{NewPath().AppendDocument(1).AppendKey("kind"), "#1.kind"},
+		{NewPath().AppendDocument(0).AppendIndex(2), "#0[2]"},
+		{NewPath().AppendKey("labels").AppendKey("app.kubernetes.io/name"), `labels."app.kubernetes.io/name"`},
+		{NewPath().AppendKey("a b").AppendKey(`say "hi"`), `"a b"."say \"hi\""`},
+		{NewPath().AppendKey("[0]"), `"[0]"`},
+		{NewPath().AppendKey("#1"), `"#1"`},
+		{NewPath().AppendKey(""), `""`},
+		{NewPath().AppendKey("(root)"), `"(root)"`},
+	}
+
+	for _, tt := range tests {
+		got := tt.path.String()
+		if got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+
+		parsed, err := ParsePath(got)
+		if err != nil {
+			t.Errorf("ParsePath(%q) failed: %v", got, err)
+			continue
+		}
+		if !parsed.Equal(tt.path) {
+			t.Errorf("ParsePath(%q) = %v, want %v", got, parsed.Segments, tt.path.Segments)
+		}
+	}
+}
+
+func TestParsePath_Errors(t *testing.T) {
+	for _, s := range []string{
+		"a..b",
+		"a.",
+		"a[x]",
+		"a[1",
+		`"unclosed`,
+		`"a"b`,
+		"#x.a",
+		"a.[0]",
+	} {
+		if _, err := ParsePath(s); err == nil {
+			t.Errorf("ParsePath(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestTreeState_SetRoot(t *testing.T) {
+	build := func() *Node {
+		root := &Node{Kind: KindMap, Path: NewPath()}
+		for _, key := range []string{"a.b", "a"} {
+			child := &Node{Kind: KindMap, Key: key, Path: root.Path.AppendKey(key), Parent: root}
+			child.Children = []*Node{{Key: "b", Path: child.Path.AppendKey("b"), Parent: child}}
+			root.Children = append(root.Children, child)
+		}
+		return root
+	}
+
+	old := build()
+	ts := NewTreeState(old)
+	ts.SetExpanded(old.Children[0], true) // "a.b", not a.b
+
+	root := build()
+	ts.SetRoot(root)
+	if !ts.IsExpanded(root.Children[0]) {
+		t.Error(`"a.b" is not expanded after SetRoot`)
+	}
+	if ts.IsExpanded(root.Children[1]) || ts.IsExpanded(root.Children[1].Children[0]) {
+		t.Error("a or a.b is expanded after SetRoot")
+	}
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+)
+
+// isIdentifier returns true if key can be written unquoted in JSONPath and
+// yq paths: a letter or underscore followed by letters, digits or _
+func isIdentifier(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, r := range key {
+		switch {
+		case r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+		case i > 0 && r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// JSONPath returns the path in JSONPath syntax
+// Example: $.metadata.labels['app.kubernetes.io/name']; document segments
+// have no JSONPath equivalent and are left out.
+func (p *Path) JSONPath() string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, seg := range p.Segments {
+		switch {
+		case seg.IsDocument():
+		case seg.IsIndex():
+			b.WriteString("[" + strconv.Itoa(seg.Index) + "]")
+		case isIdentifier(seg.Key):
+			b.WriteString("." + seg.Key)
+		default:
+			key := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(seg.Key)
+			b.WriteString("['" + key + "']")
+		}
+	}
+	return b.String()
+}
+
+// YQ returns the path as a yq (and jq) expression
+// Example: .metadata.labels["app.kubernetes.io/name"]; in a multi-document
+// stream the document is selected with documentIndex.
+func (p *Path) YQ() string {
+	var b strings.Builder
+	expr := "" // Path expression after the document selection
+	for _, seg := range p.Segments {
+		switch {
+		case seg.IsDocument():
+			b.WriteString("select(documentIndex == " + strconv.Itoa(seg.Index) + ") | ")
+		case seg.IsIndex():
+			expr += "[" + strconv.Itoa(seg.Index) + "]"
+		case isIdentifier(seg.Key):
+			expr += "." + seg.Key
+		default:
+			expr += "[" + strconv.Quote(seg.Key) + "]"
+		}
+	}
+	if !strings.HasPrefix(expr, ".") {
+		expr = "." + expr
+	}
+	return b.String() + expr
+}
+
+// HelmSet returns the path as a key for helm --set
+// Example: ingress.annotations.kubernetes\.io/ingress\.class; characters
+// with a meaning in --set keys are escaped with a backslash.
+func (p *Path) HelmSet() string {
+	escaper := strings.NewReplacer(`\`, `\\`, `.`, `\.`, `[`, `\[`, `]`, `\]`, `,`, `\,`, `=`, `\=`)
+
+	var b strings.Builder
+	for _, seg := range p.Segments {
+		switch {
+		case seg.IsDocument():
+		case seg.IsIndex():
+			b.WriteString("[" + strconv.Itoa(seg.Index) + "]")
+		default:
+			if b.Len() > 0 {
+				b.WriteString(".")
+			}
+			b.WriteString(escaper.Replace(seg.Key))
+		}
+	}
+	return b.String()
+}
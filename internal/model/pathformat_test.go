@@ -0,0 +1,55 @@
+package model
+
+import "testing"
+
+func TestPathFormats(t *testing.T) {
+	tests := []struct {
+		path     *Path
+		jsonPath string
+		yq       string
+		helm     string
+	}{
+		{
+			path:     NewPath(),
+			jsonPath: "$",
+			yq:       ".",
+			helm:     "",
+		},
+		{
+			path:     NewPath().AppendKey("spec").AppendKey("containers").AppendIndex(0).AppendKey("image"),
+			jsonPath: "$.spec.containers[0].image",
+			yq:       ".spec.containers[0].image",
+			helm:     "spec.containers[0].image",
+		},
+		{
+			path:     NewPath().AppendKey("labels").AppendKey("app.kubernetes.io/name"),
+			jsonPath: "$.labels['app.kubernetes.io/name']",
+			yq:       `.labels["app.kubernetes.io/name"]`,
+			helm:     `labels.app\.kubernetes\.io/name`,
+		},
+		{
+			path:     NewPath().AppendIndex(1).AppendKey("it's"),
+			jsonPath: `$[1]['it\'s']`,
+			yq:       `.[1]["it's"]`,
+			helm:     "[1].it's",
+		},
+		{
+			path:     NewPath().AppendDocument(1).AppendKey("kind"),
+			jsonPath: "$.kind",
+			yq:       "select(documentIndex == 1) | .kind",
+			helm:     "kind",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.path.JSONPath(); got != tt.jsonPath {
+			t.Errorf("JSONPath() = %q, want %q", got, tt.jsonPath)
+		}
+		if got := tt.path.YQ(); got != tt.yq {
+			t.Errorf("YQ() = %q, want %q", got, tt.yq)
+		}
+		if got := tt.path.HelmSet(); got != tt.helm {
+			t.Errorf("HelmSet() = %q, want %q", got, tt.helm)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package model
+
+// Position is a location in the source file
+// Line and Column are 1-based; a zero Line means the position is unknown
+type Position struct {
+	Line   int
+	Column int
+}
+
+// IsValid returns true if the position is known
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// Before returns true if p comes before other in the source
+func (p Position) Before(other Position) bool {
+	if p.Line != other.Line {
+		return p.Line < other.Line
+	}
+	return p.Column < other.Column
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Table is a list of maps seen as rows with the union of their keys as
+// columns
+type Table struct {
+	// List is the list node the table shows
+	List *Node
+
+	// Columns are the keys of the items, in order of first appearance
+	Columns []string
+
+	// Rows are the items of the list, in display order
+	Rows []*Node
+}
+
+// NewTable creates a table for a list of maps
+// Returns false if node is not a non-empty list whose items are all maps.
+func NewTable(node *Node) (*Table, bool) {
+	if node == nil || node.Kind != KindList || len(node.Children) == 0 {
+		return nil, false
+	}
+
+	t := &Table{List: node, Rows: append([]*Node(nil), node.Children...)}
+	seen := make(map[string]bool)
+	for _, item := range node.Children {
+		if item.Kind != KindMap {
+			return nil, false
+		}
+		for _, child := range item.Children {
+			if !seen[child.Key] {
+				seen[child.Key] = true
+				t.Columns = append(t.Columns, child.Key)
+			}
+		}
+	}
+	return t, true
+}
+
+// Cell returns the value of column col in row, or nil if the item has no
+// such key
+func (t *Table) Cell(row *Node, col int) *Node {
+	key := t.Columns[col]
+	for _, child := range row.Children {
+		if child.Key == key {
+			return child
+		}
+	}
+	return nil
+}
+
+// SortBy orders the rows by column col, descending if desc is set
+// Numbers compare as numbers and everything else as text; rows without
+// the key come last. A negative col restores the list order.
+func (t *Table) SortBy(col int, desc bool) {
+	if col < 0 {
+		t.Rows = append(t.Rows[:0], t.List.Children...)
+		return
+	}
+
+	sort.SliceStable(t.Rows, func(i, j int) bool {
+		a, b := t.Cell(t.Rows[i], col), t.Cell(t.Rows[j], col)
+		if a == nil || b == nil {
+			return a != nil
+		}
+		if desc {
+			return compareCells(b, a) < 0
+		}
+		return compareCells(a, b) < 0
+	})
+}
+
+// compareCells orders two cells: numbers before other scalars, scalars
+// before maps and lists
+func compareCells(a, b *Node) int {
+	if a.Kind != KindScalar || b.Kind != KindScalar {
+		return cellRank(a) - cellRank(b)
+	}
+
+	x, errX := strconv.ParseFloat(a.ScalarValue, 64)
+	y, errY := strconv.ParseFloat(b.ScalarValue, 64)
+	switch {
+	case errX == nil && errY == nil:
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+		return 0
+	case errX == nil:
+		return -1
+	case errY == nil:
+		return 1
+	}
+	return strings.Compare(a.ScalarValue, b.ScalarValue)
+}
+
+// cellRank orders cells of different kinds
+func cellRank(n *Node) int {
+	if n.Kind == KindScalar {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+// buildList builds a list of maps from "key=value" items, one string per
+// item with entries separated by spaces
+func buildList(items ...string) *Node {
+	list := &Node{Kind: KindList, Path: NewPath()}
+	for i, item := range items {
+		m := &Node{Kind: KindMap, Index: i, Parent: list, Path: list.Path.AppendIndex(i)}
+		for _, entry := range strings.Fields(item) {
+			key, value, _ := strings.Cut(entry, "=")
+			m.Children = append(m.Children, &Node{Key: key, ScalarValue: value, Index: -1, Parent: m, Path: m.Path.AppendKey(key)})
+		}
+		list.Children = append(list.Children, m)
+	}
+	return list
+}
+
+func TestNewTable(t *testing.T) {
+	table, ok := NewTable(buildList("name=a port=80", "name=b host=x", "port=9"))
+	if !ok {
+		t.Fatal("NewTable failed")
+	}
+	if got := strings.Join(table.Columns, ","); got != "name,port,host" {
+		t.Errorf("Columns = %s, want name,port,host", got)
+	}
+	if cell := table.Cell(table.Rows[1], 1); cell != nil {
+		t.Errorf("Cell(1, port) = %v, want nil", cell.ScalarValue)
+	}
+
+	scalars := &Node{Kind: KindList, Children: []*Node{{Kind: KindScalar}}}
+	for _, node := range []*Node{nil, scalars, {Kind: KindList}, {Kind: KindMap}} {
+		if _, ok := NewTable(node); ok {
+			t.Errorf("NewTable(%v) succeeded, want false", node)
+		}
+	}
+}
+
+func TestTable_SortBy(t *testing.T) {
+	table, _ := NewTable(buildList("n=b p=10", "n=a p=9", "p=100", "n=c p=x"))
+
+	order := func() string {
+		var names []string
+		for _, row := range table.Rows {
+			names = append(names, table.Cell(row, 1).ScalarValue)
+		}
+		return strings.Join(names, ",")
+	}
+
+	tests := []struct {
+		col  int
+		desc bool
+		want string
+	}{
+		{1, false, "9,10,100,x"},
+		{1, true, "x,100,10,9"},
+		{0, false, "9,10,x,100"}, // Missing names last
+		{0, true, "x,10,9,100"},
+		{-1, false, "10,9,100,x"},
+	}
+	for _, tt := range tests {
+		table.SortBy(tt.col, tt.desc)
+		if got := order(); got != tt.want {
+			t.Errorf("SortBy(%d, %v) = %s, want %s", tt.col, tt.desc, got, tt.want)
+		}
+	}
+}
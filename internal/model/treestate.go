@@ -2,9 +2,10 @@ package model
 
 // TreeState holds the current state of the tree view
 type TreeState struct {
-	// Expanded tracks which paths are expanded
-	// Key is the path string representation
-	Expanded map[string]bool
+	// Expanded tracks which nodes are expanded
+	// Nodes are keyed by identity, so nodes with similar paths (or the
+	// same subtree expanded through several aliases) never collide
+	Expanded map[*Node]bool
 
 	// SelectedIndex is the index of the currently selected row in visible rows
 	SelectedIndex int
@@ -25,7 +26,7 @@ type TreeState struct {
 // NewTreeState creates a new tree state with the given root
 func NewTreeState(root *Node) *TreeState {
 	return &TreeState{
-		Expanded:      make(map[string]bool),
+		Expanded:      make(map[*Node]bool),
 		SelectedIndex: 0,
 		SelectedNode:  root,
 		VisibleRows:   make([]*VisibleRow, 0),
@@ -34,41 +35,62 @@ func NewTreeState(root *Node) *TreeState {
 	}
 }
 
-// IsExpanded returns true if the node at the given path is expanded
-func (ts *TreeState) IsExpanded(path *Path) bool {
-	if path == nil {
+// IsExpanded returns true if the node is expanded
+func (ts *TreeState) IsExpanded(node *Node) bool {
+	if node == nil {
 		return true // root is always expanded
 	}
-	return ts.Expanded[path.String()]
+	return ts.Expanded[node]
 }
 
-// SetExpanded sets the expanded state for a path
-func (ts *TreeState) SetExpanded(path *Path, expanded bool) {
-	if path == nil {
+// SetExpanded sets the expanded state for a node
+func (ts *TreeState) SetExpanded(node *Node, expanded bool) {
+	if node == nil {
 		return // can't collapse root
 	}
-	pathStr := path.String()
 	if expanded {
-		ts.Expanded[pathStr] = true
+		ts.Expanded[node] = true
 	} else {
-		delete(ts.Expanded, pathStr)
+		delete(ts.Expanded, node)
 	}
 }
 
-// ToggleExpanded toggles the expanded state for a path
-func (ts *TreeState) ToggleExpanded(path *Path) bool {
-	if path == nil {
+// ToggleExpanded toggles the expanded state for a node
+func (ts *TreeState) ToggleExpanded(node *Node) bool {
+	if node == nil {
 		return true
 	}
-	pathStr := path.String()
-	if ts.Expanded[pathStr] {
-		delete(ts.Expanded, pathStr)
+	if ts.Expanded[node] {
+		delete(ts.Expanded, node)
 		return false
 	}
-	ts.Expanded[pathStr] = true
+	ts.Expanded[node] = true
 	return true
 }
 
+// SetRoot replaces the tree, carrying the expanded state over to the nodes
+// of the new tree with the same path
+func (ts *TreeState) SetRoot(root *Node) {
+	paths := make(map[string]bool, len(ts.Expanded))
+	for node := range ts.Expanded {
+		paths[node.Path.String()] = true
+	}
+
+	ts.Root = root
+	ts.Expanded = make(map[*Node]bool, len(paths))
+	ts.restoreExpanded(root, paths)
+}
+
+// restoreExpanded expands the nodes of the subtree whose path is in paths
+func (ts *TreeState) restoreExpanded(node *Node, paths map[string]bool) {
+	if paths[node.Path.String()] {
+		ts.Expanded[node] = true
+	}
+	for _, child := range node.Children {
+		ts.restoreExpanded(child, paths)
+	}
+}
+
 // ExpandAll expands all expandable nodes
 func (ts *TreeState) ExpandAll() {
 	ts.expandAllRecursive(ts.Root)
@@ -79,7 +101,7 @@ func (ts *TreeState) expandAllRecursive(node *Node) {
 		return
 	}
 	if node.IsExpandable() && node.HasChildren() {
-		ts.SetExpanded(node.Path, true)
+		ts.SetExpanded(node, true)
 	}
 	for _, child := range node.Children {
 		ts.expandAllRecursive(child)
@@ -88,7 +110,7 @@ func (ts *TreeState) expandAllRecursive(node *Node) {
 
 // CollapseAll collapses all nodes
 func (ts *TreeState) CollapseAll() {
-	ts.Expanded = make(map[string]bool)
+	ts.Expanded = make(map[*Node]bool)
 }
 
 // ExpandToNode expands all ancestors of the given node
@@ -98,7 +120,7 @@ func (ts *TreeState) ExpandToNode(node *Node) {
 	}
 	current := node.Parent
 	for current != nil {
-		ts.SetExpanded(current.Path, true)
+		ts.SetExpanded(current, true)
 		current = current.Parent
 	}
 }
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ValueKind is the type of a value in a comparison
+type ValueKind int
+
+const (
+	ValueNull ValueKind = iota
+	ValueBool
+	ValueNumber
+	ValueString
+)
+
+// Value is a scalar in a comparison
+type Value struct {
+	Kind   ValueKind
+	Bool   bool
+	Number float64
+	String string
+}
+
+// ValueOf returns the comparable value of a scalar node
+// The value follows the node's ScalarType, so a quoted "50" is a string.
+func ValueOf(node *Node) Value {
+	switch node.ScalarType {
+	case ScalarNull:
+		return Value{Kind: ValueNull}
+	case ScalarBool:
+		return Value{Kind: ValueBool, Bool: strings.EqualFold(node.ScalarValue, "true")}
+	case ScalarInt, ScalarFloat:
+		if n, err := strconv.ParseFloat(node.ScalarValue, 64); err == nil {
+			return Value{Kind: ValueNumber, Number: n}
+		}
+		if n, err := strconv.ParseInt(node.ScalarValue, 0, 64); err == nil {
+			return Value{Kind: ValueNumber, Number: float64(n)}
+		}
+	}
+	return Value{Kind: ValueString, String: node.ScalarValue}
+}
+
+// ParseValue returns the value of an unquoted literal
+// true, false, null and numbers have their own kind; anything else is a
+// string.
+func ParseValue(text string) Value {
+	switch text {
+	case "true", "false":
+		return Value{Kind: ValueBool, Bool: text == "true"}
+	case "null":
+		return Value{Kind: ValueNull}
+	}
+	if n, err := strconv.ParseFloat(text, 64); err == nil {
+		return Value{Kind: ValueNumber, Number: n}
+	}
+	return Value{Kind: ValueString, String: text}
+}
+
+// Compare applies a comparison operator (=, ==, !=, <, <=, >, >=) to a and b
+// Values of different kinds are never equal, and only numbers and strings
+// are ordered.
+func Compare(a Value, op string, b Value) bool {
+	if a.Kind != b.Kind {
+		return op == "!="
+	}
+
+	var c int
+	switch a.Kind {
+	case ValueBool:
+		if a.Bool != b.Bool {
+			c = 1
+		}
+	case ValueNumber:
+		switch {
+		case a.Number < b.Number:
+			c = -1
+		case a.Number > b.Number:
+			c = 1
+		}
+	case ValueString:
+		c = strings.Compare(a.String, b.String)
+	}
+
+	ordered := a.Kind == ValueNumber || a.Kind == ValueString
+	switch op {
+	case "=", "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return ordered && c < 0
+	case "<=":
+		return ordered && c <= 0
+	case ">":
+		return ordered && c > 0
+	case ">=":
+		return ordered && c >= 0
+	}
+	return false
+}
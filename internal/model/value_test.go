@@ -0,0 +1,49 @@
+package model
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	number := &Node{Kind: KindScalar, ScalarValue: "50", ScalarType: ScalarInt}
+	quoted := &Node{Kind: KindScalar, ScalarValue: "50", ScalarType: ScalarString}
+	hex := &Node{Kind: KindScalar, ScalarValue: "0x40", ScalarType: ScalarInt}
+
+	tests := []struct {
+		node    *Node
+		op      string
+		operand string
+		want    bool
+	}{
+		{number, ">", "30", true},
+		{number, "==", "50", true},
+		{number, "=", "50.0", true},
+		{quoted, ">", "30", false},
+		{quoted, "=", "50", false},
+		{quoted, "!=", "50", true},
+		{quoted, ">", "3", false},
+		{hex, ">", "63", true},
+	}
+
+	for _, tt := range tests {
+		if got := Compare(ValueOf(tt.node), tt.op, ParseValue(tt.operand)); got != tt.want {
+			t.Errorf("%q (%v) %s %s = %v, want %v", tt.node.ScalarValue, tt.node.ScalarType, tt.op, tt.operand, got, tt.want)
+		}
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		text string
+		want Value
+	}{
+		{"true", Value{Kind: ValueBool, Bool: true}},
+		{"null", Value{Kind: ValueNull}},
+		{"-1.5", Value{Kind: ValueNumber, Number: -1.5}},
+		{"web", Value{Kind: ValueString, String: "web"}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseValue(tt.text); got != tt.want {
+			t.Errorf("ParseValue(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
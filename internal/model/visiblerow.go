@@ -32,6 +32,18 @@ type VisibleRow struct {
 
 	// IsDimmed indicates if this row should be displayed dimmed (non-match during active search)
 	IsDimmed bool
+
+	// MatchPositions are the rune indices of the search match in the
+	// displayed key (tree view) or path (flat view)
+	MatchPositions []int
+
+	// ValueMatchPositions are the rune indices of the search match in the
+	// scalar value
+	ValueMatchPositions []int
+
+	// LabelMatchPositions are the rune indices of the search match in the
+	// label of a list item
+	LabelMatchPositions []int
 }
 
 // NewVisibleRow creates a visible row from a node
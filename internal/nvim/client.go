@@ -1,6 +1,7 @@
 package nvim
 
 import (
+	"bufio"
 	"encoding/json"
 	"net"
 	"sync"
@@ -10,19 +11,44 @@ import (
 // MinSendInterval is the minimum time between cursor updates (throttling)
 const MinSendInterval = 50 * time.Millisecond
 
-// Message represents a JSONL message sent to Neovim
+// MaxMessageSize is the largest message accepted from Neovim
+const MaxMessageSize = 64 << 20
+
+// Message represents a JSONL message exchanged with Neovim
 type Message struct {
 	Op   string `json:"op"`
 	Line int    `json:"line,omitempty"`
+	Col  int    `json:"col,omitempty"` // 1-based byte column, as Neovim counts
+
+	// Message is the text of a diagnostic
+	Message string `json:"message,omitempty"`
+
+	// Text is the full buffer contents of a "buffer" or "replace" message,
+	// or the copied text of a "yank" message
+	Text string `json:"text,omitempty"`
 }
 
-// Client handles IPC communication with Neovim via Unix socket
+// Client handles IPC communication with Neovim, either as JSONL over the
+// plugin's Unix socket or as msgpack-RPC over Neovim's own server socket
 type Client struct {
 	conn       net.Conn
 	socketPath string
-	mu         sync.Mutex
-	lastSent   time.Time
-	closed     bool
+
+	// rpc is set when connected to Neovim's server socket (see NewRPCClient)
+	rpc    *rpcConn
+	target rpcTarget
+
+	mu       sync.Mutex
+	lastSent time.Time
+	closed   bool
+
+	// attached is true once Neovim reports that it streams the source
+	// buffer (an "attach" message), so edits can go to the buffer
+	attached bool
+
+	// events receives the messages sent by Neovim
+	events chan Message
+	done   chan struct{}
 }
 
 // NewClient creates a new Neovim IPC client
@@ -33,16 +59,54 @@ func NewClient(socketPath string) (*Client, error) {
 		return nil, err
 	}
 
-	return &Client{
+	c := &Client{
 		conn:       conn,
 		socketPath: socketPath,
 		lastSent:   time.Time{},
-	}, nil
+		events:     make(chan Message, 16),
+		done:       make(chan struct{}),
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+// Events returns the channel of messages sent by Neovim
+// The channel is closed when the connection ends.
+func (c *Client) Events() <-chan Message {
+	return c.events
+}
+
+// readLoop reads JSONL messages from Neovim until the connection ends
+// Malformed lines are ignored.
+func (c *Client) readLoop() {
+	defer close(c.events)
+
+	scanner := bufio.NewScanner(c.conn)
+	scanner.Buffer(make([]byte, 64*1024), MaxMessageSize)
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		if msg.Op == "attach" {
+			c.mu.Lock()
+			c.attached = true
+			c.mu.Unlock()
+			continue
+		}
+		select {
+		case c.events <- msg:
+		case <-c.done:
+			return
+		}
+	}
 }
 
 // SendCursor sends a cursor position update to Neovim
+// Line and col are 1-based and col counts bytes; a col of 0 leaves the
+// column to Neovim
 // Throttled to prevent overwhelming the socket with rapid updates
-func (c *Client) SendCursor(line int) error {
+func (c *Client) SendCursor(line, col int) error {
 	if c == nil || c.closed {
 		return nil
 	}
@@ -59,23 +123,96 @@ func (c *Client) SendCursor(line int) error {
 	msg := Message{
 		Op:   "cursor",
 		Line: line,
+		Col:  col,
 	}
 
-	data, err := json.Marshal(msg)
-	if err != nil {
+	if err := c.write(msg); err != nil {
 		return err
 	}
 
-	// Send as JSONL (JSON + newline)
-	data = append(data, '\n')
+	c.lastSent = now
+	return nil
+}
 
-	_, err = c.conn.Write(data)
+// SendDiagnostic reports a parse error location to Neovim
+// Line and col are 1-based and col counts bytes; a line of 0 clears the
+// diagnostic
+// Diagnostics are never throttled
+func (c *Client) SendDiagnostic(line, col int, message string) error {
+	if c == nil || c.closed {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.write(Message{
+		Op:      "diagnostic",
+		Line:    line,
+		Col:     col,
+		Message: message,
+	})
+}
+
+// HasBuffer returns true if the document is a Neovim buffer, in which case
+// edits should replace the buffer contents rather than write the file
+func (c *Client) HasBuffer() bool {
+	if c == nil || c.closed {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.attached
+}
+
+// ReplaceBuffer replaces the contents of the source buffer with text
+// The buffer is left modified for the user to save.
+func (c *Client) ReplaceBuffer(text string) error {
+	if c == nil || c.closed {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.write(Message{
+		Op:   "replace",
+		Text: text,
+	})
+}
+
+// SendYank puts text copied in yamlist into Neovim's registers
+func (c *Client) SendYank(text string) error {
+	if c == nil || c.closed {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.write(Message{
+		Op:   "yank",
+		Text: text,
+	})
+}
+
+// write sends a message as JSONL (JSON + newline), or as the matching
+// API calls over msgpack-RPC
+// The caller must hold c.mu
+func (c *Client) write(msg Message) error {
+	if c.rpc != nil {
+		return c.writeRPC(msg)
+	}
+
+	data, err := json.Marshal(msg)
 	if err != nil {
 		return err
 	}
+	data = append(data, '\n')
 
-	c.lastSent = now
-	return nil
+	_, err = c.conn.Write(data)
+	return err
 }
 
 // Close closes the connection to Neovim
@@ -88,6 +225,11 @@ func (c *Client) Close() error {
 	defer c.mu.Unlock()
 
 	c.closed = true
+	close(c.done)
+	if c.rpc != nil {
+		c.closeRPC()
+		return c.rpc.close()
+	}
 	return c.conn.Close()
 }
 
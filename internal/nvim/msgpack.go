@@ -0,0 +1,285 @@
+package nvim
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Ext is a msgpack extension value
+// Neovim encodes Buffer, Window and Tabpage handles as extensions.
+type Ext struct {
+	Type int8
+	Data []byte
+}
+
+// appendValue appends the msgpack encoding of v to b
+// Supported values: nil, bool, integers, float64, string, []byte,
+// []interface{}, []string, map[string]interface{} and Ext.
+func appendValue(b []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if v {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case int:
+		return appendInt(b, int64(v)), nil
+	case int64:
+		return appendInt(b, v), nil
+	case uint32:
+		return appendUint(b, uint64(v)), nil
+	case uint64:
+		return appendUint(b, v), nil
+	case float64:
+		b = append(b, 0xcb)
+		return binary.BigEndian.AppendUint64(b, math.Float64bits(v)), nil
+	case string:
+		b = appendHeader(b, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		return append(b, v...), nil
+	case []byte:
+		b = appendHeader(b, len(v), 0, 0, 0xc4, 0xc5, 0xc6)
+		return append(b, v...), nil
+	case []string:
+		b = appendHeader(b, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, s := range v {
+			b, _ = appendValue(b, s)
+		}
+		return b, nil
+	case []interface{}:
+		b = appendHeader(b, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, item := range v {
+			var err error
+			if b, err = appendValue(b, item); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[string]interface{}:
+		b = appendHeader(b, len(v), 0x80, 16, 0, 0xde, 0xdf)
+		for key, item := range v {
+			b, _ = appendValue(b, key)
+			var err error
+			if b, err = appendValue(b, item); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case Ext:
+		b = appendHeader(b, len(v.Data), 0, 0, 0xc7, 0xc8, 0xc9)
+		b = append(b, byte(v.Type))
+		return append(b, v.Data...), nil
+	default:
+		return nil, fmt.Errorf("msgpack: unsupported type %T", v)
+	}
+}
+
+// appendInt appends a signed integer in its shortest encoding
+func appendInt(b []byte, n int64) []byte {
+	switch {
+	case n >= 0:
+		return appendUint(b, uint64(n))
+	case n >= -32:
+		return append(b, byte(n))
+	case n >= math.MinInt8:
+		return append(b, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(n))
+	}
+}
+
+// appendUint appends an unsigned integer in its shortest encoding
+func appendUint(b []byte, n uint64) []byte {
+	switch {
+	case n <= math.MaxInt8:
+		return append(b, byte(n))
+	case n <= math.MaxUint8:
+		return append(b, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), n)
+	}
+}
+
+// appendHeader appends a length header: the fix format (fix|n) when n is
+// below fixMax, otherwise the 8, 16 or 32-bit format (a zero code means
+// the format does not exist for this type)
+func appendHeader(b []byte, n int, fix byte, fixMax int, code8, code16, code32 byte) []byte {
+	switch {
+	case n < fixMax:
+		return append(b, fix|byte(n))
+	case code8 != 0 && n <= math.MaxUint8:
+		return append(b, code8, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, code16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, code32), uint32(n))
+	}
+}
+
+// decoder reads msgpack values from a stream
+// Integers decode to int64 (uint64 above math.MaxInt64), strings and
+// binaries to string, arrays to []interface{}, maps to
+// map[string]interface{} and extensions to Ext.
+type decoder struct {
+	r *bufio.Reader
+}
+
+// newDecoder creates a decoder reading from r
+func newDecoder(r io.Reader) *decoder {
+	return &decoder{r: bufio.NewReader(r)}
+}
+
+// decode reads the next value
+func (d *decoder) decode() (interface{}, error) {
+	c, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return d.decodeMap(int(c & 0x0f))
+	case c&0xf0 == 0x90:
+		return d.decodeArray(int(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		return d.decodeString(int(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6: // bin 8, 16, 32
+		return d.decodeSized(1<<(c-0xc4), d.decodeString)
+	case 0xd9, 0xda, 0xdb: // str 8, 16, 32
+		return d.decodeSized(1<<(c-0xd9), d.decodeString)
+	case 0xc7, 0xc8, 0xc9: // ext 8, 16, 32
+		return d.decodeSized(1<<(c-0xc7), d.decodeExt)
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8: // fixext 1, 2, 4, 8, 16
+		return d.decodeExt(1 << (c - 0xd4))
+	case 0xdc, 0xdd: // array 16, 32
+		return d.decodeSized(2<<(c-0xdc), d.decodeArray)
+	case 0xde, 0xdf: // map 16, 32
+		return d.decodeSized(2<<(c-0xde), d.decodeMap)
+	case 0xca:
+		n, err := d.readUint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := d.readUint(8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce, 0xcf: // uint 8, 16, 32, 64
+		n, err := d.readUint(1 << (c - 0xcc))
+		if n > math.MaxInt64 {
+			return n, err
+		}
+		return int64(n), err
+	case 0xd0, 0xd1, 0xd2, 0xd3: // int 8, 16, 32, 64
+		size := 1 << (c - 0xd0)
+		n, err := d.readUint(size)
+		// Sign-extend to 64 bits
+		shift := 64 - 8*size
+		return int64(n<<shift) >> shift, err
+	}
+	return nil, fmt.Errorf("msgpack: invalid code 0x%02x", c)
+}
+
+// readUint reads a big-endian unsigned integer of size bytes
+func (d *decoder) readUint(size int) (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(d.r, buf[:size]); err != nil {
+		return 0, err
+	}
+	var n uint64
+	for _, b := range buf[:size] {
+		n = n<<8 | uint64(b)
+	}
+	return n, nil
+}
+
+// decodeSized reads a length of size bytes, then decodes the value with it
+func (d *decoder) decodeSized(size int, decodeFn func(n int) (interface{}, error)) (interface{}, error) {
+	n, err := d.readUint(size)
+	if err != nil {
+		return nil, err
+	}
+	if n > MaxMessageSize {
+		return nil, fmt.Errorf("msgpack: length %d exceeds the size limit", n)
+	}
+	return decodeFn(int(n))
+}
+
+// readBytes reads exactly n bytes
+func (d *decoder) readBytes(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// decodeString reads a string or binary of n bytes
+func (d *decoder) decodeString(n int) (interface{}, error) {
+	buf, err := d.readBytes(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(buf), nil
+}
+
+// decodeExt reads the type and n data bytes of an extension
+func (d *decoder) decodeExt(n int) (interface{}, error) {
+	buf, err := d.readBytes(n + 1)
+	if err != nil {
+		return nil, err
+	}
+	return Ext{Type: int8(buf[0]), Data: buf[1:]}, nil
+}
+
+// decodeArray reads an array of n values
+func (d *decoder) decodeArray(n int) (interface{}, error) {
+	items := make([]interface{}, 0, min(n, 1024))
+	for i := 0; i < n; i++ {
+		item, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// decodeMap reads a map of n entries; non-string keys are formatted
+func (d *decoder) decodeMap(n int) (interface{}, error) {
+	entries := make(map[string]interface{}, min(n, 1024))
+	for i := 0; i < n; i++ {
+		key, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		entries[fmt.Sprint(key)] = value
+	}
+	return entries, nil
+}
@@ -0,0 +1,84 @@
+package nvim
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMsgpack_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  interface{}
+	}{
+		{"nil", nil, nil},
+		{"true", true, true},
+		{"false", false, false},
+		{"positive fixint", 7, int64(7)},
+		{"negative fixint", -5, int64(-5)},
+		{"uint8", 200, int64(200)},
+		{"uint16", 60000, int64(60000)},
+		{"uint32", 1 << 31, int64(1 << 31)},
+		{"int8", -100, int64(-100)},
+		{"int16", -30000, int64(-30000)},
+		{"int32", -1 << 30, int64(-1 << 30)},
+		{"int64", int64(math.MinInt64), int64(math.MinInt64)},
+		{"uint64", uint64(math.MaxUint64), uint64(math.MaxUint64)},
+		{"float", 1.5, 1.5},
+		{"fixstr", "name", "name"},
+		{"str8", string(bytes.Repeat([]byte("a"), 100)), string(bytes.Repeat([]byte("a"), 100))},
+		{"str16", string(bytes.Repeat([]byte("b"), 300)), string(bytes.Repeat([]byte("b"), 300))},
+		{"bin", []byte{1, 2, 3}, string([]byte{1, 2, 3})},
+		{"array", []interface{}{1, "two", nil}, []interface{}{int64(1), "two", nil}},
+		{"strings", []string{"a", "b"}, []interface{}{"a", "b"}},
+		{"map", map[string]interface{}{"line": 3}, map[string]interface{}{"line": int64(3)}},
+		{"ext", Ext{Type: 1, Data: []byte{5}}, Ext{Type: 1, Data: []byte{5}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := appendValue(nil, tt.value)
+			if err != nil {
+				t.Fatalf("appendValue failed: %v", err)
+			}
+			got, err := newDecoder(bytes.NewReader(data)).decode()
+			if err != nil {
+				t.Fatalf("decode failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decode = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMsgpack_DecodeNeovimHandle(t *testing.T) {
+	// A Window handle (ext type 1) as sent by Neovim: fixext1 with value 3
+	got, err := newDecoder(bytes.NewReader([]byte{0xd4, 0x01, 0x03})).decode()
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	want := Ext{Type: 1, Data: []byte{3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decode = %#v, want %#v", got, want)
+	}
+}
+
+func TestMsgpack_DecodeTruncated(t *testing.T) {
+	data, _ := appendValue(nil, []interface{}{"cursor", 12})
+	if _, err := newDecoder(bytes.NewReader(data[:len(data)-1])).decode(); err == nil {
+		t.Error("decode of truncated data succeeded, want an error")
+	}
+}
+
+func TestRPCError(t *testing.T) {
+	if err := rpcError(nil); err != nil {
+		t.Errorf("rpcError(nil) = %v, want nil", err)
+	}
+	err := rpcError([]interface{}{int64(0), "Cursor position outside buffer"})
+	if err == nil || err.Error() != "nvim: Cursor position outside buffer" {
+		t.Errorf("rpcError = %v, want the Neovim message", err)
+	}
+}
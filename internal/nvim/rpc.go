@@ -0,0 +1,187 @@
+package nvim
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// RPCTimeout is how long a msgpack-RPC call waits for Neovim's response
+const RPCTimeout = 5 * time.Second
+
+// msgpack-RPC message types
+const (
+	rpcRequest      = 0
+	rpcResponse     = 1
+	rpcNotification = 2
+)
+
+// errRPCClosed is returned by calls on a closed connection
+var errRPCClosed = errors.New("nvim: rpc connection closed")
+
+// rpcResult is the outcome of a msgpack-RPC call
+type rpcResult struct {
+	value interface{}
+	err   error
+}
+
+// rpcConn is a msgpack-RPC connection to Neovim's server socket
+type rpcConn struct {
+	conn net.Conn
+
+	// writeMu serializes writes to conn
+	writeMu sync.Mutex
+
+	// mu guards nextID, pending and closed
+	mu      sync.Mutex
+	nextID  uint32
+	pending map[uint32]chan rpcResult
+	closed  bool
+
+	// onNotify is called from the read loop for each notification
+	onNotify func(method string, args []interface{})
+}
+
+// dialRPC connects to a Neovim server address: a Unix socket path or a
+// host:port TCP address, as accepted by `nvim --listen`
+func dialRPC(address string, onNotify func(method string, args []interface{})) (*rpcConn, error) {
+	network := "unix"
+	if host, port, err := net.SplitHostPort(address); err == nil && host != "" && port != "" {
+		network = "tcp"
+	}
+
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rpcConn{
+		conn:     conn,
+		pending:  make(map[uint32]chan rpcResult),
+		onNotify: onNotify,
+	}, nil
+}
+
+// call invokes a Neovim API method and waits for its result
+func (r *rpcConn) call(method string, args ...interface{}) (interface{}, error) {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil, errRPCClosed
+	}
+	r.nextID++
+	id := r.nextID
+	done := make(chan rpcResult, 1)
+	r.pending[id] = done
+	r.mu.Unlock()
+
+	if err := r.write([]interface{}{rpcRequest, id, method, args}); err != nil {
+		r.finish(id, rpcResult{err: err})
+	}
+
+	select {
+	case result := <-done:
+		return result.value, result.err
+	case <-time.After(RPCTimeout):
+		r.finish(id, rpcResult{})
+		return nil, fmt.Errorf("nvim: %s timed out", method)
+	}
+}
+
+// notify invokes a Neovim API method without waiting for a result
+// Neovim reports failures of notifications through nvim_error_event.
+func (r *rpcConn) notify(method string, args ...interface{}) error {
+	return r.write([]interface{}{rpcNotification, method, args})
+}
+
+// write encodes and sends one message
+func (r *rpcConn) write(msg []interface{}) error {
+	data, err := appendValue(nil, msg)
+	if err != nil {
+		return err
+	}
+
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	_, err = r.conn.Write(data)
+	return err
+}
+
+// finish delivers the result of a pending call
+func (r *rpcConn) finish(id uint32, result rpcResult) {
+	r.mu.Lock()
+	done, ok := r.pending[id]
+	delete(r.pending, id)
+	r.mu.Unlock()
+
+	if ok {
+		done <- result
+	}
+}
+
+// readLoop dispatches responses and notifications until the connection ends
+// Pending calls then fail with errRPCClosed.
+func (r *rpcConn) readLoop() {
+	dec := newDecoder(r.conn)
+	for {
+		value, err := dec.decode()
+		if err != nil {
+			break
+		}
+		msg, ok := value.([]interface{})
+		if !ok || len(msg) == 0 {
+			continue
+		}
+
+		kind, _ := msg[0].(int64)
+		switch {
+		case kind == rpcResponse && len(msg) == 4:
+			id, _ := msg[1].(int64)
+			r.finish(uint32(id), rpcResult{value: msg[3], err: rpcError(msg[2])})
+		case kind == rpcNotification && len(msg) == 3:
+			method, _ := msg[1].(string)
+			args, _ := msg[2].([]interface{})
+			if r.onNotify != nil {
+				r.onNotify(method, args)
+			}
+		case kind == rpcRequest && len(msg) == 4:
+			// yamlist exposes no methods
+			id, _ := msg[1].(int64)
+			r.write([]interface{}{rpcResponse, id, "yamlist: no such method", nil})
+		}
+	}
+
+	r.mu.Lock()
+	r.closed = true
+	pending := r.pending
+	r.pending = make(map[uint32]chan rpcResult)
+	r.mu.Unlock()
+	for _, done := range pending {
+		done <- rpcResult{err: errRPCClosed}
+	}
+}
+
+// close closes the connection
+func (r *rpcConn) close() error {
+	return r.conn.Close()
+}
+
+// rpcError converts the error field of a response
+// Neovim sends [type, message] arrays; nil means success.
+func rpcError(value interface{}) error {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		if len(value) == 2 {
+			if message, ok := value[1].(string); ok {
+				return fmt.Errorf("nvim: %s", message)
+			}
+		}
+	case string:
+		return fmt.Errorf("nvim: %s", value)
+	}
+	return fmt.Errorf("nvim: %v", value)
+}
@@ -0,0 +1,265 @@
+package nvim
+
+import (
+	"fmt"
+	"strings"
+)
+
+// rpcTarget is the editor window and buffer yamlist syncs with over RPC
+type rpcTarget struct {
+	channel int64
+	win     int64
+	buf     int64
+}
+
+// replaceLua replaces the lines of a buffer with the given text
+const replaceLua = `
+local buf, text = ...
+if not vim.api.nvim_buf_is_valid(buf) then
+  return
+end
+local lines = vim.split(text, "\n", { plain = true })
+if lines[#lines] == "" then
+  table.remove(lines)
+end
+vim.api.nvim_buf_set_lines(buf, 0, -1, false, lines)
+`
+
+// setupLua picks the window to sync with and subscribes to its events
+// The window showing the given file wins, else the previous window (the
+// one yamlist's :terminal was opened from). Cursor moves and (debounced)
+// text changes are forwarded with rpcnotify; the autocmds remove
+// themselves once the channel is gone.
+const setupLua = `
+local chan, path, delay = ...
+local win
+for _, w in ipairs(vim.api.nvim_list_wins()) do
+  if path ~= "" and vim.api.nvim_buf_get_name(vim.api.nvim_win_get_buf(w)) == path then
+    win = w
+    break
+  end
+end
+if not win then
+  win = vim.fn.win_getid(vim.fn.winnr("#"))
+  if win == 0 then
+    win = vim.api.nvim_get_current_win()
+  end
+end
+local buf = vim.api.nvim_win_get_buf(win)
+local attached = path == "" or vim.api.nvim_buf_get_name(buf) == path
+
+local group = vim.api.nvim_create_augroup("yamlist_rpc_" .. chan, { clear = true })
+local timer = vim.loop.new_timer()
+local function notify(...)
+  if not pcall(vim.rpcnotify, chan, ...) then
+    pcall(vim.api.nvim_del_augroup_by_id, group)
+    if not timer:is_closing() then
+      timer:close()
+    end
+  end
+end
+
+vim.api.nvim_create_autocmd({ "CursorMoved", "CursorMovedI" }, {
+  group = group,
+  buffer = buf,
+  callback = function()
+    local pos = vim.api.nvim_win_get_cursor(0)
+    notify("yamlist_cursor", pos[1], pos[2] + 1)
+  end,
+})
+vim.api.nvim_create_autocmd({ "TextChanged", "TextChangedI" }, {
+  group = group,
+  buffer = buf,
+  callback = function()
+    timer:stop()
+    timer:start(delay, 0, vim.schedule_wrap(function()
+      if vim.api.nvim_buf_is_valid(buf) then
+        local lines = vim.api.nvim_buf_get_lines(buf, 0, -1, false)
+        notify("yamlist_buffer", table.concat(lines, "\n") .. "\n")
+      end
+    end))
+  end,
+})
+return { win, buf, attached }
+`
+
+// teardownLua removes the autocmds installed by setupLua
+const teardownLua = `
+local chan = ...
+pcall(vim.api.nvim_del_augroup_by_name, "yamlist_rpc_" .. chan)
+`
+
+// diagnosticLua sets (or clears, for line 0) the parse error diagnostic,
+// in the namespace used by the Lua plugin
+const diagnosticLua = `
+local buf, line, col, message = ...
+local ns = vim.api.nvim_create_namespace("yamlist")
+if not vim.api.nvim_buf_is_valid(buf) then
+  return
+end
+if line == 0 then
+  vim.diagnostic.reset(ns, buf)
+  return
+end
+vim.diagnostic.set(ns, buf, {
+  {
+    lnum = line - 1,
+    col = math.max(0, col - 1),
+    message = message,
+    severity = vim.diagnostic.severity.ERROR,
+    source = "yamlist",
+  },
+})
+`
+
+// yankLua puts text into the unnamed register, and the clipboard register
+// when a clipboard provider is available (as the Lua plugin does)
+const yankLua = `
+local text = ...
+vim.fn.setreg('"', text)
+if vim.fn.has("clipboard") == 1 then
+  pcall(vim.fn.setreg, "+", text)
+end
+`
+
+// bufferSyncDelay is the debounce delay in milliseconds for text changes
+const bufferSyncDelay = 150
+
+// NewRPCClient connects to Neovim's own msgpack-RPC server (the $NVIM
+// socket of :terminal jobs, or an `nvim --listen` address)
+// The client syncs with the window showing filePath (an absolute path),
+// or the previous window if none does, so no plugin is needed.
+func NewRPCClient(address, filePath string) (*Client, error) {
+	c := &Client{
+		socketPath: address,
+		events:     make(chan Message, 16),
+		done:       make(chan struct{}),
+	}
+
+	rpc, err := dialRPC(address, c.handleNotification)
+	if err != nil {
+		return nil, err
+	}
+	c.rpc = rpc
+	go func() {
+		rpc.readLoop()
+		close(c.events)
+	}()
+
+	if err := c.setupRPC(filePath); err != nil {
+		rpc.close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// setupRPC finds the target window and subscribes to its events
+func (c *Client) setupRPC(filePath string) error {
+	info, err := c.rpc.call("nvim_get_api_info")
+	if err != nil {
+		return err
+	}
+	infoItems, ok := info.([]interface{})
+	if !ok || len(infoItems) == 0 {
+		return fmt.Errorf("nvim: unexpected nvim_get_api_info result")
+	}
+	c.target.channel, _ = infoItems[0].(int64)
+
+	result, err := c.rpc.call("nvim_exec_lua", setupLua,
+		[]interface{}{c.target.channel, filePath, bufferSyncDelay})
+	if err != nil {
+		return err
+	}
+	handles, ok := result.([]interface{})
+	if !ok || len(handles) != 3 {
+		return fmt.Errorf("nvim: unexpected setup result")
+	}
+	c.target.win, _ = handles[0].(int64)
+	c.target.buf, _ = handles[1].(int64)
+	// The buffer is the document when it shows the file (or is the input)
+	c.attached, _ = handles[2].(bool)
+	return nil
+}
+
+// closeRPC removes the autocmds installed by setupRPC
+func (c *Client) closeRPC() {
+	c.rpc.notify("nvim_exec_lua", teardownLua, []interface{}{c.target.channel})
+}
+
+// handleNotification turns subscribed Neovim events into Messages
+func (c *Client) handleNotification(method string, args []interface{}) {
+	var msg Message
+	switch method {
+	case "yamlist_cursor":
+		if len(args) != 2 {
+			return
+		}
+		line, _ := args[0].(int64)
+		col, _ := args[1].(int64)
+		msg = Message{Op: "cursor", Line: int(line), Col: int(col)}
+	case "yamlist_buffer":
+		if len(args) != 1 {
+			return
+		}
+		text, _ := args[0].(string)
+		msg = Message{Op: "buffer", Text: text}
+	default:
+		return
+	}
+
+	select {
+	case c.events <- msg:
+	case <-c.done:
+	}
+}
+
+// writeRPC performs the API calls matching a message
+// Notifications are used so the UI never waits on Neovim.
+func (c *Client) writeRPC(msg Message) error {
+	switch msg.Op {
+	case "cursor":
+		// Message columns are 1-based byte columns, Neovim's are 0-based
+		col := msg.Col - 1
+		if col < 0 {
+			col = 0
+		}
+		return c.rpc.notify("nvim_win_set_cursor", c.target.win, []interface{}{msg.Line, col})
+	case "diagnostic":
+		return c.rpc.notify("nvim_exec_lua", diagnosticLua,
+			[]interface{}{c.target.buf, msg.Line, msg.Col, msg.Message})
+	case "replace":
+		return c.rpc.notify("nvim_exec_lua", replaceLua,
+			[]interface{}{c.target.buf, msg.Text})
+	case "yank":
+		return c.rpc.notify("nvim_exec_lua", yankLua, []interface{}{msg.Text})
+	}
+	return nil
+}
+
+// BufferText returns the contents of the synced buffer, including unsaved
+// edits, and its file name (empty for unnamed buffers)
+// Only available over RPC.
+func (c *Client) BufferText() (text string, name string, err error) {
+	if c == nil || c.rpc == nil {
+		return "", "", fmt.Errorf("nvim: buffer access needs an RPC connection")
+	}
+
+	result, err := c.rpc.call("nvim_buf_get_lines", c.target.buf, 0, -1, false)
+	if err != nil {
+		return "", "", err
+	}
+	items, _ := result.([]interface{})
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		line, _ := item.(string)
+		lines = append(lines, line)
+	}
+
+	nameValue, err := c.rpc.call("nvim_buf_get_name", c.target.buf)
+	if err != nil {
+		return "", "", err
+	}
+	name, _ = nameValue.(string)
+
+	return strings.Join(lines, "\n") + "\n", name, nil
+}
@@ -0,0 +1,205 @@
+package query
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/uznog/yamlist/internal/model"
+)
+
+// expr is a filter expression, evaluated against a candidate node (@)
+type expr interface {
+	matches(node *model.Node) bool
+}
+
+// andExpr matches if both sides match
+type andExpr struct{ left, right expr }
+
+func (e andExpr) matches(node *model.Node) bool {
+	return e.left.matches(node) && e.right.matches(node)
+}
+
+// orExpr matches if either side matches
+type orExpr struct{ left, right expr }
+
+func (e orExpr) matches(node *model.Node) bool {
+	return e.left.matches(node) || e.right.matches(node)
+}
+
+// notExpr matches if its operand does not
+type notExpr struct{ operand expr }
+
+func (e notExpr) matches(node *model.Node) bool {
+	return !e.operand.matches(node)
+}
+
+// existsExpr matches if a relative path selects any node
+type existsExpr struct{ path []step }
+
+func (e existsExpr) matches(node *model.Node) bool {
+	return len(evalSteps(e.path, []*model.Node{node})) > 0
+}
+
+// compareExpr compares the nodes a relative path selects with a literal
+// It matches if any selected node satisfies the comparison.
+type compareExpr struct {
+	path    []step
+	op      string
+	literal model.Value
+	re      *regexp.Regexp
+}
+
+func (e compareExpr) matches(node *model.Node) bool {
+	for _, n := range evalSteps(e.path, []*model.Node{node}) {
+		if n.Kind != model.KindScalar {
+			continue
+		}
+		if e.re != nil {
+			if e.re.MatchString(n.ScalarValue) {
+				return true
+			}
+			continue
+		}
+		if model.Compare(model.ValueOf(n), e.op, e.literal) {
+			return true
+		}
+	}
+	return false
+}
+
+// comparisonOps are the comparison operators, longest first
+var comparisonOps = []string{"==", "!=", "<=", ">=", "=~", "<", ">"}
+
+// parseFilter parses (expr) after [?; the parentheses are optional
+func (p *parser) parseFilter() (step, error) {
+	p.skipSpaces()
+	e, err := p.parseOr()
+	if err != nil {
+		return step{}, err
+	}
+	return step{kind: stepFilter, filter: e}, nil
+}
+
+// parseOr parses a || b
+func (p *parser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.skipSpaces(); strings.HasPrefix(p.src[p.pos:], "||"); p.skipSpaces() {
+		p.pos += 2
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd parses a && b
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.skipSpaces(); strings.HasPrefix(p.src[p.pos:], "&&"); p.skipSpaces() {
+		p.pos += 2
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+	return left, nil
+}
+
+// parseUnary parses !a, (a) and comparisons
+func (p *parser) parseUnary() (expr, error) {
+	p.skipSpaces()
+	switch p.peek() {
+	case '!':
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{operand}, nil
+	case '(':
+		p.pos++
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if p.peek() != ')' {
+			return nil, p.errorf("expected )")
+		}
+		p.pos++
+		return e, nil
+	}
+	return p.parseComparison()
+}
+
+// parseComparison parses @.path, optionally compared with a literal
+func (p *parser) parseComparison() (expr, error) {
+	if p.peek() != '@' {
+		return nil, p.errorf("expected @")
+	}
+	p.pos++
+	path, err := p.parseSteps(false)
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpaces()
+	op := ""
+	for _, candidate := range comparisonOps {
+		if strings.HasPrefix(p.src[p.pos:], candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return existsExpr{path}, nil
+	}
+	p.pos += len(op)
+	p.skipSpaces()
+
+	literal, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+
+	e := compareExpr{path: path, op: op, literal: literal}
+	if op == "=~" {
+		if literal.Kind != model.ValueString {
+			return nil, p.errorf("=~ needs a string pattern")
+		}
+		if e.re, err = regexp.Compile(literal.String); err != nil {
+			return nil, p.errorf("invalid pattern: %v", err)
+		}
+	}
+	return e, nil
+}
+
+// parseLiteral parses a string, number, true, false or null
+func (p *parser) parseLiteral() (model.Value, error) {
+	if c := p.peek(); c == '"' || c == '\'' {
+		s, err := p.parseString()
+		if err != nil {
+			return model.Value{}, err
+		}
+		return model.Value{Kind: model.ValueString, String: s}, nil
+	}
+
+	start := p.pos
+	for !p.done() && isKeyByte(p.peek()) {
+		p.pos++
+	}
+	if v := model.ParseValue(p.src[start:p.pos]); v.Kind != model.ValueString {
+		return v, nil
+	}
+	p.pos = start
+	return model.Value{}, p.errorf("expected a string, number, true, false or null")
+}
@@ -0,0 +1,532 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/uznog/yamlist/internal/model"
+)
+
+// Query is a compiled path query
+// The syntax covers JSONPath and yq paths:
+//
+//	$.spec.containers[0].image    .spec.containers[0].image
+//	$..image                      recursive descent
+//	.items[*].name  .items[].name wildcards
+//	.items[1:3]  .items[-1]       slices and negative indices
+//	.items[?(@.name == "api")]    filters (==, !=, <, <=, >, >=, =~, &&, ||, !)
+//	metadata.annotations."a.b/c"  canonical paths, as yamlist displays them
+type Query struct {
+	expr  string
+	steps []step
+
+	// document is true if the query starts by selecting a document (#N)
+	document bool
+}
+
+// stepKind is the kind of selector of a step
+type stepKind int
+
+const (
+	stepKeys     stepKind = iota // Children with the given keys
+	stepWildcard                 // All children
+	stepIndices                  // List items at the given indices
+	stepSlice                    // List items in a range
+	stepFilter                   // Children matching a predicate
+	stepDocument                 // A document of a stream (#N)
+)
+
+// step selects nodes relative to the nodes matched so far
+type step struct {
+	kind stepKind
+
+	// recursive applies the selector to all descendants (..)
+	recursive bool
+
+	keys    []string
+	indices []int
+
+	// Slice bounds; nil means unset
+	start, end, stride *int
+
+	filter expr
+}
+
+// Compile parses a query
+func Compile(expr string) (*Query, error) {
+	p := &parser{src: expr}
+	p.skipSpaces()
+	if p.peek() == '$' {
+		p.pos++
+	}
+
+	steps, err := p.parseSteps(true)
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if !p.done() {
+		return nil, p.errorf("unexpected %q", p.src[p.pos:p.pos+1])
+	}
+	document := strings.HasPrefix(strings.TrimLeft(expr, " \t$"), "#")
+	return &Query{expr: expr, steps: steps, document: document}, nil
+}
+
+// String returns the source of the query
+func (q *Query) String() string {
+	return q.expr
+}
+
+// Eval returns the nodes matched by the query under root, in order of
+// discovery and without duplicates
+// In a multi-document stream the query runs against every document, as in
+// yq, unless it selects one with #N.
+func (q *Query) Eval(root *model.Node) []*model.Node {
+	start := []*model.Node{root}
+	if !q.document && isStream(root) {
+		start = childrenOf(root)
+	}
+	return dedupe(evalSteps(q.steps, start))
+}
+
+// isStream returns true if root holds the documents of a multi-document stream
+func isStream(root *model.Node) bool {
+	return len(root.Children) > 0 && root.Children[0].IsDocument
+}
+
+// Find compiles and evaluates a query
+func Find(root *model.Node, expr string) ([]*model.Node, error) {
+	q, err := Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return q.Eval(root), nil
+}
+
+// evalSteps applies steps in turn, starting from nodes
+func evalSteps(steps []step, nodes []*model.Node) []*model.Node {
+	for _, s := range steps {
+		if s.recursive {
+			var all []*model.Node
+			for _, node := range nodes {
+				all = appendDescendants(all, node)
+			}
+			nodes = all
+		}
+
+		var next []*model.Node
+		for _, node := range nodes {
+			next = s.selectFrom(next, node)
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// selectFrom appends the nodes the step selects from node to result
+func (s *step) selectFrom(result []*model.Node, node *model.Node) []*model.Node {
+	children := childrenOf(node)
+
+	switch s.kind {
+	case stepKeys:
+		if node.Kind != model.KindMap {
+			return result
+		}
+		for _, key := range s.keys {
+			for _, child := range children {
+				if child.Key == key {
+					result = append(result, child)
+					break
+				}
+			}
+		}
+
+	case stepWildcard:
+		result = append(result, children...)
+
+	case stepIndices:
+		if node.Kind != model.KindList {
+			return result
+		}
+		for _, index := range s.indices {
+			if index < 0 {
+				index += len(children)
+			}
+			if index >= 0 && index < len(children) {
+				result = append(result, children[index])
+			}
+		}
+
+	case stepSlice:
+		if node.Kind != model.KindList {
+			return result
+		}
+		for _, i := range sliceIndices(len(children), s.start, s.end, s.stride) {
+			result = append(result, children[i])
+		}
+
+	case stepFilter:
+		for _, child := range children {
+			if s.filter.matches(child) {
+				result = append(result, child)
+			}
+		}
+
+	case stepDocument:
+		// A single document is document #0
+		if !isStream(node) {
+			if s.indices[0] == 0 {
+				result = append(result, node)
+			}
+			return result
+		}
+		for _, child := range children {
+			if child.Index == s.indices[0] {
+				result = append(result, child)
+			}
+		}
+	}
+	return result
+}
+
+// childrenOf returns the children of node, without parse error nodes
+func childrenOf(node *model.Node) []*model.Node {
+	children := make([]*model.Node, 0, len(node.Children))
+	for _, child := range node.Children {
+		if !child.IsError {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// appendDescendants appends node and all its descendants, depth first
+func appendDescendants(result []*model.Node, node *model.Node) []*model.Node {
+	result = append(result, node)
+	for _, child := range childrenOf(node) {
+		result = appendDescendants(result, child)
+	}
+	return result
+}
+
+// sliceIndices returns the indices selected by a [start:end:stride] slice
+// of a list of length n, following Python semantics
+func sliceIndices(n int, start, end, stride *int) []int {
+	step := 1
+	if stride != nil {
+		step = *stride
+	}
+	if step == 0 {
+		return nil
+	}
+
+	clamp := func(bound *int, def int) int {
+		if bound == nil {
+			return def
+		}
+		i := *bound
+		if i < 0 {
+			i += n
+		}
+		low, high := 0, n
+		if step < 0 {
+			low, high = -1, n-1
+		}
+		return min(max(i, low), high)
+	}
+
+	var indices []int
+	if step > 0 {
+		for i := clamp(start, 0); i < clamp(end, n); i += step {
+			indices = append(indices, i)
+		}
+	} else {
+		for i := clamp(start, n-1); i > clamp(end, -1); i += step {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// dedupe removes repeated nodes, keeping the first occurrence
+func dedupe(nodes []*model.Node) []*model.Node {
+	seen := make(map[*model.Node]bool, len(nodes))
+	result := make([]*model.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if !seen[node] {
+			seen[node] = true
+			result = append(result, node)
+		}
+	}
+	return result
+}
+
+// parser reads a query from src
+type parser struct {
+	src string
+	pos int
+}
+
+// errorf returns a syntax error at the current position
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at column %d", fmt.Sprintf(format, args...), p.pos+1)
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.src)
+}
+
+// peek returns the current byte, or 0 at the end
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) skipSpaces() {
+	for !p.done() && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// parseSteps parses a sequence of steps; at the top level a bare key and a
+// document selector (#N) may start the path
+func (p *parser) parseSteps(top bool) ([]step, error) {
+	var steps []step
+
+	if top {
+		if p.peek() == '#' {
+			p.pos++
+			n, ok := p.parseInt()
+			if !ok || n < 0 {
+				return nil, p.errorf("expected a document index")
+			}
+			steps = append(steps, step{kind: stepDocument, indices: []int{n}})
+		} else if isKeyByte(p.peek()) || p.peek() == '"' {
+			s, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, s)
+		}
+	}
+
+	for !p.done() {
+		switch {
+		case strings.HasPrefix(p.src[p.pos:], ".."):
+			p.pos += 2
+			s, err := p.parseSelector()
+			if err != nil {
+				return nil, err
+			}
+			s.recursive = true
+			steps = append(steps, s)
+
+		case p.peek() == '.':
+			p.pos++
+			if p.done() || p.peek() == ' ' || p.peek() == ')' {
+				// A lone "." is the current node (yq)
+				continue
+			}
+			s, err := p.parseSelector()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, s)
+
+		case p.peek() == '[':
+			s, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, s)
+
+		default:
+			return steps, nil
+		}
+	}
+	return steps, nil
+}
+
+// parseSelector parses what follows a dot: a key, * or a bracket
+func (p *parser) parseSelector() (step, error) {
+	switch {
+	case p.peek() == '*':
+		p.pos++
+		return step{kind: stepWildcard}, nil
+	case p.peek() == '[':
+		return p.parseBracket()
+	default:
+		return p.parseKey()
+	}
+}
+
+// parseKey parses a bare or quoted key
+func (p *parser) parseKey() (step, error) {
+	if p.peek() == '"' || p.peek() == '\'' {
+		key, err := p.parseString()
+		if err != nil {
+			return step{}, err
+		}
+		return step{kind: stepKeys, keys: []string{key}}, nil
+	}
+
+	start := p.pos
+	for !p.done() && isKeyByte(p.peek()) {
+		p.pos++
+	}
+	if p.pos == start {
+		return step{}, p.errorf("expected a key")
+	}
+	return step{kind: stepKeys, keys: []string{p.src[start:p.pos]}}, nil
+}
+
+// isKeyByte returns true for bytes that may appear in a bare key
+func isKeyByte(c byte) bool {
+	return c != 0 && !strings.ContainsRune(" \t.[]()=!<>&|,'\"*@$~#", rune(c))
+}
+
+// parseBracket parses [ ... ]: a wildcard, keys, indices, a slice or a filter
+func (p *parser) parseBracket() (step, error) {
+	p.pos++ // [
+	p.skipSpaces()
+
+	var s step
+	var err error
+	switch c := p.peek(); {
+	case c == ']':
+		// yq's .items[]
+		s = step{kind: stepWildcard}
+	case c == '*':
+		p.pos++
+		s = step{kind: stepWildcard}
+	case c == '?':
+		p.pos++
+		s, err = p.parseFilter()
+	case c == '"' || c == '\'':
+		s, err = p.parseKeyUnion()
+	default:
+		s, err = p.parseIndexOrSlice()
+	}
+	if err != nil {
+		return step{}, err
+	}
+
+	p.skipSpaces()
+	if p.peek() != ']' {
+		return step{}, p.errorf("expected ]")
+	}
+	p.pos++
+	return s, nil
+}
+
+// parseKeyUnion parses 'a', "b", ...
+func (p *parser) parseKeyUnion() (step, error) {
+	s := step{kind: stepKeys}
+	for {
+		key, err := p.parseString()
+		if err != nil {
+			return step{}, err
+		}
+		s.keys = append(s.keys, key)
+
+		p.skipSpaces()
+		if p.peek() != ',' {
+			return s, nil
+		}
+		p.pos++
+		p.skipSpaces()
+	}
+}
+
+// parseIndexOrSlice parses 1, -1, 0,2 or start:end:stride
+func (p *parser) parseIndexOrSlice() (step, error) {
+	var bounds [3]*int
+	part := 0
+	var indices []int
+
+	for {
+		p.skipSpaces()
+		if n, ok := p.parseInt(); ok {
+			bounds[part] = &n
+		}
+		p.skipSpaces()
+
+		switch p.peek() {
+		case ':':
+			if part == 2 || len(indices) > 0 {
+				return step{}, p.errorf("unexpected :")
+			}
+			part++
+			p.pos++
+			continue
+		case ',':
+			if part > 0 || bounds[0] == nil {
+				return step{}, p.errorf("unexpected ,")
+			}
+			indices = append(indices, *bounds[0])
+			bounds[0] = nil
+			p.pos++
+			continue
+		}
+		break
+	}
+
+	if part > 0 {
+		return step{kind: stepSlice, start: bounds[0], end: bounds[1], stride: bounds[2]}, nil
+	}
+	if bounds[0] == nil {
+		return step{}, p.errorf("expected an index, key or filter")
+	}
+	return step{kind: stepIndices, indices: append(indices, *bounds[0])}, nil
+}
+
+// parseInt parses an optionally negative integer
+func (p *parser) parseInt() (int, bool) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for !p.done() && p.peek() >= '0' && p.peek() <= '9' {
+		p.pos++
+	}
+	n, err := strconv.Atoi(p.src[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return 0, false
+	}
+	return n, true
+}
+
+// parseString parses a single- or double-quoted string
+// Double-quoted strings take Go escapes; single-quoted ones \' and \\.
+func (p *parser) parseString() (string, error) {
+	quote := p.peek()
+	if quote == '"' {
+		s, err := strconv.QuotedPrefix(p.src[p.pos:])
+		if err != nil {
+			return "", p.errorf("invalid string")
+		}
+		p.pos += len(s)
+		value, _ := strconv.Unquote(s)
+		return value, nil
+	}
+
+	var b strings.Builder
+	for p.pos++; !p.done(); p.pos++ {
+		c := p.peek()
+		switch {
+		case c == '\\' && p.pos+1 < len(p.src):
+			p.pos++
+			b.WriteByte(p.src[p.pos])
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unclosed string")
+}
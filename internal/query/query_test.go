@@ -0,0 +1,134 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/uznog/yamlist/internal/model"
+	"github.com/uznog/yamlist/internal/yamlparse"
+)
+
+const querySource = `spec:
+  replicas: 3
+  containers:
+    - name: api
+      image: api:1.2
+      port: 8080
+    - name: worker
+      image: worker:1.0
+    - name: sidecar
+      image: proxy:2.1
+      port: 9090
+      debug: true
+labels:
+  app.kubernetes.io/name: shop
+  tier: backend
+`
+
+// paths returns the canonical paths of nodes
+func paths(nodes []*model.Node) []string {
+	result := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		result = append(result, node.Path.String())
+	}
+	return result
+}
+
+func TestEval(t *testing.T) {
+	doc, err := yamlparse.ParseString(querySource)
+	if err != nil {
+		t.Fatalf("ParseString failed: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"$", []string{"(root)"}},
+		{".", []string{"(root)"}},
+		{"$.spec.replicas", []string{"spec.replicas"}},
+		{".spec.replicas", []string{"spec.replicas"}},
+		{"spec.replicas", []string{"spec.replicas"}},
+		{`labels."app.kubernetes.io/name"`, []string{`labels."app.kubernetes.io/name"`}},
+		{`$.labels['app.kubernetes.io/name']`, []string{`labels."app.kubernetes.io/name"`}},
+		{`.labels["tier", "missing"]`, []string{"labels.tier"}},
+		{"$.labels.*", []string{`labels."app.kubernetes.io/name"`, "labels.tier"}},
+		{".spec.containers[].name", []string{"spec.containers[0].name", "spec.containers[1].name", "spec.containers[2].name"}},
+		{"$.spec.containers[*].port", []string{"spec.containers[0].port", "spec.containers[2].port"}},
+		{"$.spec.containers[-1].name", []string{"spec.containers[2].name"}},
+		{"$.spec.containers[0,2].name", []string{"spec.containers[0].name", "spec.containers[2].name"}},
+		{"$.spec.containers[1:].name", []string{"spec.containers[1].name", "spec.containers[2].name"}},
+		{"$.spec.containers[::-2].name", []string{"spec.containers[2].name", "spec.containers[0].name"}},
+		{"$.spec.containers[5]", []string{}},
+		{"$..image", []string{"spec.containers[0].image", "spec.containers[1].image", "spec.containers[2].image"}},
+		{"$..[1].name", []string{"spec.containers[1].name"}},
+		{`$.spec.containers[?(@.name == "api")].image`, []string{"spec.containers[0].image"}},
+		{`.spec.containers[?(@.port > 8080)].name`, []string{"spec.containers[2].name"}},
+		{`.spec.containers[?(@.port)].name`, []string{"spec.containers[0].name", "spec.containers[2].name"}},
+		{`.spec.containers[?(!@.port)].name`, []string{"spec.containers[1].name"}},
+		{`.spec.containers[?(@.image =~ "^(api|proxy):")].name`, []string{"spec.containers[0].name", "spec.containers[2].name"}},
+		{`.spec.containers[?(@.port >= 8080 && @.debug == true)].name`, []string{"spec.containers[2].name"}},
+		{`.spec.containers[?(@.name == 'worker' || (@.port < 9000))].name`, []string{"spec.containers[0].name", "spec.containers[1].name"}},
+		{`.spec.containers[?(@.port == "8080")]`, []string{}},
+		{`$..[?(@ == "backend")]`, []string{"labels.tier"}},
+		{"$..*..name", []string{"spec.containers[0].name", "spec.containers[1].name", "spec.containers[2].name"}},
+	}
+
+	for _, tt := range tests {
+		got, err := Find(doc.Root, tt.query)
+		if err != nil {
+			t.Errorf("Find(%q) failed: %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(paths(got), tt.want) {
+			t.Errorf("Find(%q) = %q, want %q", tt.query, paths(got), tt.want)
+		}
+	}
+}
+
+func TestEval_Stream(t *testing.T) {
+	doc, err := yamlparse.ParseString("kind: Service\n---\nkind: Deployment\n")
+	if err != nil {
+		t.Fatalf("ParseString failed: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{".kind", []string{"#0.kind", "#1.kind"}},
+		{"#1.kind", []string{"#1.kind"}},
+		{`$[?(@.kind == "Deployment")]`, []string{}},
+		{`.[?(@ == "Deployment")]`, []string{"#1.kind"}},
+	}
+
+	for _, tt := range tests {
+		got, err := Find(doc.Root, tt.query)
+		if err != nil {
+			t.Errorf("Find(%q) failed: %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(paths(got), tt.want) {
+			t.Errorf("Find(%q) = %q, want %q", tt.query, paths(got), tt.want)
+		}
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	for _, s := range []string{
+		"a[",
+		"a[x]",
+		"a.[1",
+		`a["unclosed]`,
+		"a[?(@.b ==)]",
+		"a[?(@.b == 1]",
+		`a[?(@.b =~ "(")]`,
+		"a[?(b)]",
+		"a b",
+		"#x",
+	} {
+		if _, err := Compile(s); err == nil {
+			t.Errorf("Compile(%q) succeeded, want an error", s)
+		}
+	}
+}
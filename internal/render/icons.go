@@ -17,6 +17,7 @@ type IconSet struct {
 	Bool       string
 	Null       string
 	Timestamp  string
+	Error      string
 
 	// Tree lines
 	Connector  string
@@ -39,6 +40,7 @@ func NerdFontIcons() *IconSet {
 		Bool:       "󰨙",
 		Null:       "󰟢",
 		Timestamp:  "",
+		Error:      "",
 
 		Connector:  "├",
 		LastItem:   "└",
@@ -61,6 +63,7 @@ func ASCIIIcons() *IconSet {
 		Bool:       "?",
 		Null:       "~",
 		Timestamp:  "@",
+		Error:      "!",
 
 		Connector:  "|-",
 		LastItem:   "`-",
@@ -0,0 +1,88 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Span is a range of runes [Start, End) in a line
+type Span struct {
+	Start, End int
+}
+
+// ScreenLine is the part of a source line shown on one screen line
+type ScreenLine struct {
+	Source int // Index of the source line
+	Span
+}
+
+// WrapLines splits lines into screen lines of at most width runes
+// With width <= 0 every source line is one screen line.
+func WrapLines(lines []string, width int) []ScreenLine {
+	screen := make([]ScreenLine, 0, len(lines))
+	for i, line := range lines {
+		n := runeCount(line)
+		if width <= 0 || n <= width {
+			screen = append(screen, ScreenLine{Source: i, Span: Span{0, n}})
+			continue
+		}
+		for start := 0; start < n; start += width {
+			screen = append(screen, ScreenLine{Source: i, Span: Span{start, min(start+width, n)}})
+		}
+	}
+	return screen
+}
+
+// HighlightLine renders the runes of line in span, colored by their token
+// kinds, with the runes inside matches in the match highlight style and
+// those inside current in the selected row style
+func (s *Styles) HighlightLine(line string, kinds []TokenKind, span Span, matches []Span, current Span) string {
+	runes := []rune(line)
+	span.End = min(span.End, len(runes))
+	if span.Start >= span.End {
+		return ""
+	}
+
+	// Classes of runes: token kinds, or a match
+	const (
+		classMatch   = -1
+		classCurrent = -2
+	)
+	classAt := func(i int) int {
+		if i >= current.Start && i < current.End {
+			return classCurrent
+		}
+		for _, m := range matches {
+			if i >= m.Start && i < m.End {
+				return classMatch
+			}
+		}
+		if i < len(kinds) {
+			return int(kinds[i])
+		}
+		return int(TokenText)
+	}
+
+	var b strings.Builder
+	for start := span.Start; start < span.End; {
+		class := classAt(start)
+		end := start + 1
+		for end < span.End && classAt(end) == class {
+			end++
+		}
+
+		var style lipgloss.Style
+		switch class {
+		case classCurrent:
+			style = s.SelectedRow
+		case classMatch:
+			style = s.MatchHighlight
+		default:
+			style = s.TokenStyle(TokenKind(class))
+		}
+		b.WriteString(style.Render(string(runes[start:end])))
+		start = end
+	}
+	return b.String()
+}
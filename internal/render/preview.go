@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/uznog/yamlist/internal/decode"
 	"github.com/uznog/yamlist/internal/model"
 )
 
@@ -24,7 +25,9 @@ func NewPreviewRenderer(styles *Styles, maxLines int) *PreviewRenderer {
 }
 
 // RenderPreview renders the preview for a node
-func (p *PreviewRenderer) RenderPreview(node *model.Node, width, height int) string {
+// decoded is the decoded form of its value (nil if none); decoding is left
+// to the caller so it can be done once per value rather than per render.
+func (p *PreviewRenderer) RenderPreview(node *model.Node, decoded *decode.Decoded, width, height int) string {
 	if node == nil {
 		return p.Styles.NullValue.Render("(no selection)")
 	}
@@ -44,8 +47,20 @@ func (p *PreviewRenderer) RenderPreview(node *model.Node, width, height int) str
 	b.WriteString(p.Styles.ChildCount.Render(typeInfo))
 	b.WriteString("\n\n")
 
+	// Comments
+	headerLines := 4
+	if comments := node.CommentText(); comments != "" {
+		for _, line := range strings.Split(comments, "\n") {
+			b.WriteString(p.Styles.Comment.Render("# " + line))
+			b.WriteString("\n")
+			headerLines++
+		}
+		b.WriteString("\n")
+		headerLines++
+	}
+
 	// Content
-	content := p.renderContent(node, width, height-4) // Reserve lines for header
+	content := p.renderContent(node, decoded, width, height-headerLines) // Reserve lines for header
 	b.WriteString(content)
 
 	return b.String()
@@ -72,10 +87,10 @@ func (p *PreviewRenderer) formatTypeInfo(node *model.Node) string {
 }
 
 // renderContent renders the main content based on node type
-func (p *PreviewRenderer) renderContent(node *model.Node, width, maxLines int) string {
+func (p *PreviewRenderer) renderContent(node *model.Node, decoded *decode.Decoded, width, maxLines int) string {
 	switch node.Kind {
 	case model.KindScalar:
-		return p.renderScalar(node, width, maxLines)
+		return p.renderScalar(node, width, maxLines) + p.renderDecoded(decoded, maxLines)
 	case model.KindMap:
 		return p.renderMap(node, width, maxLines)
 	case model.KindList:
@@ -110,6 +125,40 @@ func (p *PreviewRenderer) renderScalar(node *model.Node, width, maxLines int) st
 	return value
 }
 
+// renderDecoded renders the decoded form of a base64, JSON, YAML or PEM
+// string value below the value itself ("" if there is none)
+func (p *PreviewRenderer) renderDecoded(decoded *decode.Decoded, maxLines int) string {
+	if decoded == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	header := "decoded " + decoded.Label()
+	if decoded.IsTree() {
+		header += " · x to browse"
+	}
+	b.WriteString("\n\n")
+	b.WriteString(p.Styles.ChildCount.Render(header))
+
+	syntax := DecodedSyntax(decoded)
+	lines := decoded.Lines()
+	for i, line := range lines {
+		if i == maxLines {
+			b.WriteString("\n")
+			b.WriteString(p.Styles.ChildCount.Render(fmt.Sprintf("... (%d more lines)", len(lines)-maxLines)))
+			break
+		}
+		b.WriteString("\n")
+		if syntax == SyntaxPlain {
+			b.WriteString(p.Styles.StringValue.Render(line))
+		} else {
+			kinds := Tokenize(syntax, line)
+			b.WriteString(p.Styles.HighlightLine(line, kinds, Span{0, len(kinds)}, nil, Span{}))
+		}
+	}
+	return b.String()
+}
+
 // renderMultilineString renders a multiline string with line numbers
 func (p *PreviewRenderer) renderMultilineString(value string, width, maxLines int) string {
 	lines := strings.Split(value, "\n")
@@ -5,22 +5,25 @@ import (
 	"strings"
 	"unicode/utf8"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/uznog/yamlist/internal/model"
 )
 
 // RowRenderer handles rendering of tree rows
 type RowRenderer struct {
-	Icons  *IconSet
-	Styles *Styles
-	Indent int // Spaces per indent level
+	Icons        *IconSet
+	Styles       *Styles
+	Indent       int  // Spaces per indent level
+	ShowComments bool // Show YAML comments next to rows
 }
 
 // NewRowRenderer creates a new row renderer
 func NewRowRenderer(icons *IconSet, styles *Styles) *RowRenderer {
 	return &RowRenderer{
-		Icons:  icons,
-		Styles: styles,
-		Indent: 2,
+		Icons:        icons,
+		Styles:       styles,
+		Indent:       2,
+		ShowComments: true,
 	}
 }
 
@@ -35,18 +38,21 @@ func (r *RowRenderer) FormatRow(row *model.VisibleRow, width int, isFlatMode boo
 		// In flat mode, show full path instead of indentation
 		pathStr := row.PathString()
 		if row.IsSelected {
-			b.WriteString(r.Styles.SelectedKey.Render(pathStr))
+			b.WriteString(r.highlightMatch(pathStr, row.MatchPositions, r.Styles.SelectedKey))
+		} else if row.Node.IsError {
+			b.WriteString(r.Styles.Error.Render(pathStr))
 		} else if isDimmed {
 			b.WriteString(r.Styles.DimmedKey.Render(pathStr))
 		} else {
-			b.WriteString(r.Styles.Key.Render(pathStr))
+			b.WriteString(r.highlightMatch(pathStr, row.MatchPositions, r.Styles.Key))
 		}
+		b.WriteString(r.formatLabel(row, isDimmed))
+		b.WriteString(r.formatAnchorMarkers(row.Node, row.IsSelected, isDimmed))
 
 		// Add value for scalars
 		if row.Kind() == model.KindScalar {
 			b.WriteString(": ")
-			value := r.formatScalarValue(row.ScalarValue(), row.ScalarType(), row.IsSelected, isDimmed)
-			b.WriteString(value)
+			b.WriteString(r.formatValue(row, isDimmed))
 		}
 	} else {
 		// Tree mode rendering (existing behavior)
@@ -67,8 +73,13 @@ func (r *RowRenderer) FormatRow(row *model.VisibleRow, width int, isFlatMode boo
 
 		// Type icon
 		typeIcon := r.Icons.GetTypeIcon(row.Kind(), row.ScalarType())
+		if row.Node.IsError {
+			typeIcon = r.Icons.Error
+		}
 		if row.IsSelected {
 			b.WriteString(typeIcon)
+		} else if row.Node.IsError {
+			b.WriteString(r.Styles.Error.Render(typeIcon))
 		} else if isDimmed {
 			b.WriteString(r.Styles.DimmedRow.Render(typeIcon))
 		} else {
@@ -79,18 +90,23 @@ func (r *RowRenderer) FormatRow(row *model.VisibleRow, width int, isFlatMode boo
 		// Key
 		key := row.DisplayKey()
 		if row.IsSelected {
-			b.WriteString(r.Styles.SelectedKey.Render(key))
+			b.WriteString(r.highlightMatch(key, row.MatchPositions, r.Styles.SelectedKey))
+		} else if row.Node.IsError {
+			b.WriteString(r.Styles.Error.Render(key))
 		} else if isDimmed {
 			b.WriteString(r.Styles.DimmedKey.Render(key))
+		} else if row.Node.Inherited {
+			b.WriteString(r.highlightMatch(key, row.MatchPositions, r.Styles.InheritedKey))
 		} else {
-			b.WriteString(r.Styles.Key.Render(key))
+			b.WriteString(r.highlightMatch(key, row.MatchPositions, r.Styles.Key))
 		}
+		b.WriteString(r.formatLabel(row, isDimmed))
+		b.WriteString(r.formatAnchorMarkers(row.Node, row.IsSelected, isDimmed))
 
 		// Value or child count
 		if row.Kind() == model.KindScalar {
 			b.WriteString(": ")
-			value := r.formatScalarValue(row.ScalarValue(), row.ScalarType(), row.IsSelected, isDimmed)
-			b.WriteString(value)
+			b.WriteString(r.formatValue(row, isDimmed))
 		} else if row.HasChildren {
 			countStr := fmt.Sprintf(" (%d)", row.ChildCount)
 			if row.IsSelected {
@@ -103,6 +119,11 @@ func (r *RowRenderer) FormatRow(row *model.VisibleRow, width int, isFlatMode boo
 		}
 	}
 
+	// Trailing comment
+	if r.ShowComments {
+		b.WriteString(r.formatComment(row.Node, row.IsSelected))
+	}
+
 	content := b.String()
 
 	// Apply row-level styling
@@ -138,6 +159,162 @@ func (r *RowRenderer) FormatRow(row *model.VisibleRow, width int, isFlatMode boo
 	return content
 }
 
+// highlightMatch renders text in style, with the runes at the search match
+// positions in the match highlight style
+func (r *RowRenderer) highlightMatch(text string, positions []int, style lipgloss.Style) string {
+	if len(positions) == 0 {
+		return style.Render(text)
+	}
+
+	matched := make(map[int]bool, len(positions))
+	for _, pos := range positions {
+		matched[pos] = true
+	}
+
+	var b strings.Builder
+	runes := []rune(text)
+	for start := 0; start < len(runes); {
+		end := start + 1
+		for end < len(runes) && matched[end] == matched[start] {
+			end++
+		}
+		chunk := string(runes[start:end])
+		if matched[start] {
+			b.WriteString(r.Styles.MatchHighlight.Render(chunk))
+		} else {
+			b.WriteString(style.Render(chunk))
+		}
+		start = end
+	}
+	return b.String()
+}
+
+// formatLabel formats the label shown after the index of a list item
+// ("" if it has none), with its search match highlighted
+func (r *RowRenderer) formatLabel(row *model.VisibleRow, isDimmed bool) string {
+	label := row.Node.Label
+	if label == "" {
+		return ""
+	}
+
+	maxLen := 40
+	positions := row.LabelMatchPositions
+	if runeCount(label) > maxLen {
+		label = truncateRunes(label, maxLen-3) + "..."
+		visible := make([]int, 0, len(positions))
+		for _, pos := range positions {
+			if pos < maxLen-3 {
+				visible = append(visible, pos)
+			}
+		}
+		positions = visible
+	}
+
+	switch {
+	case row.IsSelected:
+		return " " + r.highlightMatch(label, positions, r.Styles.SelectedKey)
+	case isDimmed:
+		return " " + r.Styles.DimmedKey.Render(label)
+	default:
+		return " " + r.highlightMatch(label, positions, r.Styles.Key)
+	}
+}
+
+// formatAnchorMarkers formats the &anchor, *alias and << (inherited) markers
+// shown after a node's key
+func (r *RowRenderer) formatAnchorMarkers(node *model.Node, isSelected bool, isDimmed bool) string {
+	var markers []string
+	if node.Anchor != "" {
+		markers = append(markers, "&"+node.Anchor)
+	}
+	if node.Alias != "" {
+		markers = append(markers, "*"+node.Alias)
+	}
+	if node.Inherited {
+		marker := "<<"
+		if node.MergedFrom != nil && node.MergedFrom.Parent != nil && node.MergedFrom.Parent.Anchor != "" {
+			marker += " *" + node.MergedFrom.Parent.Anchor
+		}
+		markers = append(markers, marker)
+	}
+	if len(markers) == 0 {
+		return ""
+	}
+
+	text := " " + strings.Join(markers, " ")
+	if isSelected {
+		return text
+	}
+	if isDimmed {
+		return r.Styles.DimmedRow.Render(text)
+	}
+	return r.Styles.AnchorMarker.Render(text)
+}
+
+// formatComment formats the inline comment shown after a row
+func (r *RowRenderer) formatComment(node *model.Node, isSelected bool) string {
+	comment := node.InlineComment()
+	if comment == "" {
+		return ""
+	}
+
+	maxLen := 60
+	if runeCount(comment) > maxLen {
+		comment = truncateRunes(comment, maxLen-3) + "..."
+	}
+
+	text := "  # " + comment
+	if isSelected {
+		return text
+	}
+	return r.Styles.Comment.Render(text)
+}
+
+// formatValue formats the value of a scalar row
+// The parse error pseudo-node shows its message in the error style; search
+// matches in single-line values are highlighted.
+func (r *RowRenderer) formatValue(row *model.VisibleRow, isDimmed bool) string {
+	if row.Node.IsError && !row.IsSelected {
+		return r.Styles.Error.Render(row.ScalarValue())
+	}
+	if len(row.ValueMatchPositions) > 0 && !isDimmed {
+		if value, ok := r.highlightValue(row); ok {
+			return value
+		}
+	}
+	return r.formatScalarValue(row.ScalarValue(), row.ScalarType(), row.IsSelected, isDimmed)
+}
+
+// highlightValue formats a single-line scalar value with its search match
+// highlighted; values shown as a summary or escaped are not highlighted
+func (r *RowRenderer) highlightValue(row *model.VisibleRow) (string, bool) {
+	value := row.ScalarValue()
+	if row.ScalarType() == model.ScalarNull || strings.ContainsAny(value, "\n\t") {
+		return "", false
+	}
+
+	// Same truncation as formatScalarValue; matches past it are not shown
+	maxLen := 50
+	positions := row.ValueMatchPositions
+	if runeCount(value) > maxLen {
+		value = truncateRunes(value, maxLen-3)
+		visible := make([]int, 0, len(positions))
+		for _, pos := range positions {
+			if pos < maxLen-3 {
+				visible = append(visible, pos)
+			}
+		}
+		positions = visible
+		value += "..."
+	}
+
+	style := r.Styles.GetValueStyle(int(row.ScalarType()))
+	if row.IsSelected {
+		style = lipgloss.NewStyle()
+	}
+	return r.highlightMatch(value, positions, style), true
+}
+
 // formatScalarValue formats a scalar value with appropriate styling
 func (r *RowRenderer) formatScalarValue(value string, scalarType model.ScalarType, isSelected bool, isDimmed bool) string {
 	displayValue := value
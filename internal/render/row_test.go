@@ -1,10 +1,13 @@
 package render
 
 import (
+	"io"
 	"regexp"
 	"strings"
 	"testing"
 
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 	"github.com/uznog/yamlist/internal/model"
 )
 
@@ -139,3 +142,145 @@ func TestTruncateRunes(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatAnchorMarkers(t *testing.T) {
+	r := NewRowRenderer(ASCIIIcons(), DefaultStyles())
+
+	anchorMap := &model.Node{Key: "defaults", Kind: model.KindMap, Anchor: "defaults"}
+	definition := &model.Node{Key: "host", Kind: model.KindScalar, Parent: anchorMap}
+
+	tests := []struct {
+		name     string
+		node     *model.Node
+		expected string
+	}{
+		{"plain", &model.Node{Key: "name"}, ""},
+		{"anchor", anchorMap, " &defaults"},
+		{"alias", &model.Node{Key: "tags", Alias: "tags"}, " *tags"},
+		{"inherited", &model.Node{Key: "host", Inherited: true, MergedFrom: definition}, " << *defaults"},
+		{"inherited_inline", &model.Node{Key: "host", Inherited: true}, " <<"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stripANSI(r.formatAnchorMarkers(tt.node, false, false))
+			if got != tt.expected {
+				t.Errorf("formatAnchorMarkers() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatLabel(t *testing.T) {
+	r := NewRowRenderer(ASCIIIcons(), DefaultStyles())
+
+	tests := []struct {
+		name     string
+		label    string
+		expected string
+	}{
+		{"none", "", ""},
+		{"label", "web", " web"},
+		{"long", strings.Repeat("x", 45), " " + strings.Repeat("x", 37) + "..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := &model.VisibleRow{Node: &model.Node{Index: 0, Label: tt.label}}
+			got := stripANSI(r.formatLabel(row, false))
+			if got != tt.expected {
+				t.Errorf("formatLabel() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatComment(t *testing.T) {
+	r := NewRowRenderer(ASCIIIcons(), DefaultStyles())
+
+	tests := []struct {
+		name     string
+		node     *model.Node
+		expected string
+	}{
+		{"none", &model.Node{}, ""},
+		{"line", &model.Node{LineComment: "# inline"}, "  # inline"},
+		{"head_first_line", &model.Node{HeadComment: "# first\n# second"}, "  # first"},
+		{"line_wins", &model.Node{HeadComment: "# head", LineComment: "# line"}, "  # line"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stripANSI(r.formatComment(tt.node, false))
+			if got != tt.expected {
+				t.Errorf("formatComment() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHighlightMatch(t *testing.T) {
+	r := NewRowRenderer(ASCIIIcons(), DefaultStyles())
+	// Matches in bold, whatever the terminal of the test run
+	renderer := lipgloss.NewRenderer(io.Discard)
+	renderer.SetColorProfile(termenv.ANSI)
+	r.Styles.MatchHighlight = renderer.NewStyle().Bold(true)
+	markMatches := strings.NewReplacer("\x1b[1m", "<", "\x1b[0m", ">")
+
+	tests := []struct {
+		name      string
+		text      string
+		positions []int
+		expected  string
+	}{
+		{"none", "image", nil, "image"},
+		{"runs", "image", []int{0, 1, 3}, "<im>a<g>e"},
+		{"all", "port", []int{0, 1, 2, 3}, "<port>"},
+		{"unicode", "größe", []int{2, 3}, "gr<öß>e"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stripANSI(markMatches.Replace(r.highlightMatch(tt.text, tt.positions, r.Styles.Key)))
+			if got != tt.expected {
+				t.Errorf("highlightMatch() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHighlightValue(t *testing.T) {
+	r := NewRowRenderer(ASCIIIcons(), DefaultStyles())
+	renderer := lipgloss.NewRenderer(io.Discard)
+	renderer.SetColorProfile(termenv.ANSI)
+	r.Styles.MatchHighlight = renderer.NewStyle().Bold(true)
+	markMatches := strings.NewReplacer("\x1b[1m", "<", "\x1b[0m", ">")
+
+	tests := []struct {
+		name      string
+		value     string
+		positions []int
+		expected  string
+		ok        bool
+	}{
+		{"match", "nginx:1.25", []int{6, 7, 8, 9}, "nginx:<1.25>", true},
+		{"truncated", strings.Repeat("a", 45) + "tag:v1", []int{45, 46, 47, 48}, strings.Repeat("a", 45) + "<ta>...", true},
+		{"multiline", "a\nb", []int{0}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := &model.VisibleRow{
+				Node:                &model.Node{Kind: model.KindScalar, ScalarValue: tt.value},
+				ValueMatchPositions: tt.positions,
+			}
+			got, ok := r.highlightValue(row)
+			if ok != tt.ok {
+				t.Fatalf("highlightValue() ok = %v, want %v", ok, tt.ok)
+			}
+			if got := stripANSI(markMatches.Replace(got)); got != tt.expected {
+				t.Errorf("highlightValue() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -37,6 +37,16 @@ type Styles struct {
 	TreeLine      lipgloss.Style
 	ChildCount    lipgloss.Style
 
+	// Anchor styles
+	AnchorMarker lipgloss.Style // &anchor, *alias and << markers
+	InheritedKey lipgloss.Style // Keys inherited through a merge key
+
+	// Comment style for YAML comments
+	Comment lipgloss.Style
+
+	// Error style for the parse error pseudo-node
+	Error lipgloss.Style
+
 	// Preview pane
 	PreviewTitle  lipgloss.Style
 	PreviewPath   lipgloss.Style
@@ -52,6 +62,7 @@ type Styles struct {
 	StatusBar     lipgloss.Style
 	StatusMode    lipgloss.Style
 	StatusInfo    lipgloss.Style
+	StatusError   lipgloss.Style
 }
 
 // DefaultStyles returns the default color scheme
@@ -101,6 +112,23 @@ func DefaultStyles() *Styles {
 			Foreground(lipgloss.Color("245")).
 			Italic(true),
 
+		// Anchor styles
+		AnchorMarker: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("141")), // Purple
+		InheritedKey: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("110")). // Muted blue
+			Italic(true),
+
+		// Comment style
+		Comment: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("242")).
+			Italic(true),
+
+		// Error style
+		Error: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("203")). // Red
+			Bold(true),
+
 		// Preview pane
 		PreviewTitle: lipgloss.NewStyle().
 			Bold(true).
@@ -133,6 +161,10 @@ func DefaultStyles() *Styles {
 			Padding(0, 1),
 		StatusInfo: lipgloss.NewStyle().
 			Foreground(lipgloss.Color("245")),
+		StatusError: lipgloss.NewStyle().
+			Background(lipgloss.Color("160")).
+			Foreground(lipgloss.Color("230")).
+			Padding(0, 1),
 	}
 }
 
@@ -213,6 +245,23 @@ func DarkStyles() *Styles {
 			Foreground(lipgloss.Color("245")).
 			Italic(true),
 
+		// Anchor styles
+		AnchorMarker: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("141")), // Purple
+		InheritedKey: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("110")). // Muted blue
+			Italic(true),
+
+		// Comment style
+		Comment: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("242")).
+			Italic(true),
+
+		// Error style
+		Error: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("203")). // Red
+			Bold(true),
+
 		// Preview pane
 		PreviewTitle: lipgloss.NewStyle().
 			Bold(true).
@@ -245,6 +294,10 @@ func DarkStyles() *Styles {
 			Padding(0, 1),
 		StatusInfo: lipgloss.NewStyle().
 			Foreground(lipgloss.Color("245")),
+		StatusError: lipgloss.NewStyle().
+			Background(lipgloss.Color("160")).
+			Foreground(lipgloss.Color("230")).
+			Padding(0, 1),
 	}
 }
 
@@ -301,6 +354,23 @@ func MonoStyles() *Styles {
 			Foreground(gray).
 			Italic(true),
 
+		// Anchor styles
+		AnchorMarker: lipgloss.NewStyle().
+			Foreground(accentColor),
+		InheritedKey: lipgloss.NewStyle().
+			Foreground(white).
+			Italic(true),
+
+		// Comment style
+		Comment: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("242")).
+			Italic(true),
+
+		// Error style
+		Error: lipgloss.NewStyle().
+			Foreground(white).
+			Bold(true),
+
 		// Preview pane
 		PreviewTitle: lipgloss.NewStyle().
 			Bold(true).
@@ -333,5 +403,9 @@ func MonoStyles() *Styles {
 			Padding(0, 1),
 		StatusInfo: lipgloss.NewStyle().
 			Foreground(gray),
+		StatusError: lipgloss.NewStyle().
+			Background(white).
+			Foreground(lipgloss.Color("0")).
+			Padding(0, 1),
 	}
 }
@@ -0,0 +1,392 @@
+package render
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/uznog/yamlist/internal/decode"
+	"gopkg.in/yaml.v3"
+)
+
+// Syntax is the detected language of a multiline value
+type Syntax int
+
+const (
+	SyntaxPlain Syntax = iota
+	SyntaxJSON
+	SyntaxYAML
+	SyntaxShell
+	SyntaxPEM
+)
+
+func (s Syntax) String() string {
+	switch s {
+	case SyntaxJSON:
+		return "json"
+	case SyntaxYAML:
+		return "yaml"
+	case SyntaxShell:
+		return "shell"
+	case SyntaxPEM:
+		return "pem"
+	default:
+		return "text"
+	}
+}
+
+// shellKeywords are the words highlighted as keywords in shell scripts
+var shellKeywords = map[string]bool{
+	"if": true, "then": true, "else": true, "elif": true, "fi": true,
+	"for": true, "while": true, "until": true, "do": true, "done": true,
+	"case": true, "esac": true, "in": true, "function": true, "return": true,
+	"export": true, "local": true, "set": true, "exit": true, "echo": true,
+	"source": true, "exec": true, "cd": true, "shift": true, "trap": true,
+}
+
+// DetectSyntax guesses the language of text
+// PEM blocks and valid JSON are recognized exactly; shell needs a shebang
+// or several lines starting with shell keywords; YAML must parse to a map
+// or list.
+func DetectSyntax(text string) Syntax {
+	trimmed := strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(trimmed, "-----BEGIN "):
+		return SyntaxPEM
+	case (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)):
+		return SyntaxJSON
+	case strings.HasPrefix(trimmed, "#!") && strings.Contains(strings.SplitN(trimmed, "\n", 2)[0], "sh"):
+		return SyntaxShell
+	}
+
+	keywordLines := 0
+	for _, line := range strings.Split(trimmed, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 && shellKeywords[fields[0]] {
+			keywordLines++
+		}
+	}
+	if keywordLines >= 2 {
+		return SyntaxShell
+	}
+
+	var value interface{}
+	if strings.Contains(trimmed, "\n") && yaml.Unmarshal([]byte(text), &value) == nil {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return SyntaxYAML
+		}
+	}
+	return SyntaxPlain
+}
+
+// DecodedSyntax returns the syntax of the lines of a decoded value
+// PEM blocks are shown as a plain summary.
+func DecodedSyntax(decoded *decode.Decoded) Syntax {
+	switch decoded.Last() {
+	case decode.JSON:
+		return SyntaxJSON
+	case decode.YAML:
+		return SyntaxYAML
+	case decode.PEM:
+		return SyntaxPlain
+	}
+	return DetectSyntax(decoded.Text)
+}
+
+// TokenKind classifies a rune of highlighted text
+type TokenKind int
+
+const (
+	TokenText TokenKind = iota
+	TokenKey
+	TokenString
+	TokenNumber
+	TokenKeyword // true, false, null; shell keywords
+	TokenComment
+	TokenPunct
+	TokenVariable // Shell variables, YAML anchors and aliases
+	TokenHeader   // PEM BEGIN/END lines
+)
+
+// Tokenize classifies each rune of a line of text in a syntax
+// Lines are tokenized on their own, so constructs spanning lines (block
+// strings, heredocs) are not followed.
+func Tokenize(syntax Syntax, line string) []TokenKind {
+	runes := []rune(line)
+	kinds := make([]TokenKind, len(runes))
+
+	switch syntax {
+	case SyntaxJSON:
+		tokenizeJSON(runes, kinds)
+	case SyntaxYAML:
+		tokenizeYAML(runes, kinds)
+	case SyntaxShell:
+		tokenizeShell(runes, kinds)
+	case SyntaxPEM:
+		if strings.HasPrefix(strings.TrimSpace(line), "-----") {
+			fill(kinds, 0, len(kinds), TokenHeader)
+		}
+	}
+	return kinds
+}
+
+// TokenStyle returns the style of a token kind
+func (s *Styles) TokenStyle(kind TokenKind) lipgloss.Style {
+	switch kind {
+	case TokenKey:
+		return s.Key
+	case TokenString:
+		return s.StringValue
+	case TokenNumber:
+		return s.NumberValue
+	case TokenKeyword:
+		return s.BoolValue
+	case TokenComment:
+		return s.Comment
+	case TokenPunct:
+		return s.ChildCount
+	case TokenVariable:
+		return s.AnchorMarker
+	case TokenHeader:
+		return s.PreviewPath
+	default:
+		return s.NormalRow
+	}
+}
+
+// fill sets kinds[start:end] to kind
+func fill(kinds []TokenKind, start, end int, kind TokenKind) {
+	for i := start; i < end && i < len(kinds); i++ {
+		kinds[i] = kind
+	}
+}
+
+// scanQuoted returns the index after the string starting at runes[start]
+// Backslash escapes apply in double-quoted strings.
+func scanQuoted(runes []rune, start int) int {
+	quote := runes[start]
+	i := start + 1
+	for i < len(runes) && runes[i] != quote {
+		if runes[i] == '\\' && quote == '"' {
+			i++
+		}
+		i++
+	}
+	return min(i+1, len(runes))
+}
+
+// scanWord returns the index after the word starting at runes[start]
+func scanWord(runes []rune, start int, isPart func(rune) bool) int {
+	i := start
+	for i < len(runes) && isPart(runes[i]) {
+		i++
+	}
+	return i
+}
+
+// isNumberPart returns true for runes that may appear in a number
+func isNumberPart(r rune) bool {
+	return unicode.IsDigit(r) || strings.ContainsRune(".eE+-_xXoabcdefABCDEF", r)
+}
+
+// tokenizeJSON classifies a line of JSON
+func tokenizeJSON(runes []rune, kinds []TokenKind) {
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '"':
+			end := scanQuoted(runes, i)
+			kind := TokenString
+			rest := strings.TrimLeftFunc(string(runes[end:]), unicode.IsSpace)
+			if strings.HasPrefix(rest, ":") {
+				kind = TokenKey
+			}
+			fill(kinds, i, end, kind)
+			i = end
+		case r == '-' || unicode.IsDigit(r):
+			end := scanWord(runes, i+1, isNumberPart)
+			fill(kinds, i, end, TokenNumber)
+			i = end
+		case unicode.IsLetter(r):
+			end := scanWord(runes, i, unicode.IsLetter)
+			switch string(runes[i:end]) {
+			case "true", "false", "null":
+				fill(kinds, i, end, TokenKeyword)
+			}
+			i = end
+		case strings.ContainsRune("{}[],:", r):
+			kinds[i] = TokenPunct
+			i++
+		default:
+			i++
+		}
+	}
+}
+
+// tokenizeYAML classifies a line of YAML
+func tokenizeYAML(runes []rune, kinds []TokenKind) {
+	i := scanWord(runes, 0, unicode.IsSpace)
+
+	// List item markers
+	for i+1 < len(runes) && runes[i] == '-' && runes[i+1] == ' ' {
+		kinds[i] = TokenPunct
+		i = scanWord(runes, i+1, unicode.IsSpace)
+	}
+	if i < len(runes) && runes[i] == '#' {
+		fill(kinds, i, len(runes), TokenComment)
+		return
+	}
+
+	// A key runs up to ": " or a final ":"
+	valueStart := i
+	for j := i; j < len(runes); j++ {
+		if runes[j] == '"' || runes[j] == '\'' {
+			if j != i {
+				break
+			}
+			j = scanQuoted(runes, j) - 1
+			continue
+		}
+		if runes[j] == ':' && (j+1 == len(runes) || runes[j+1] == ' ') {
+			fill(kinds, i, j, TokenKey)
+			kinds[j] = TokenPunct
+			valueStart = j + 1
+			break
+		}
+		if runes[j] == ' ' && j+1 < len(runes) && runes[j+1] == '#' {
+			break
+		}
+	}
+
+	tokenizeYAMLValue(runes, kinds, valueStart)
+}
+
+// tokenizeYAMLValue classifies the value part of a YAML line
+func tokenizeYAMLValue(runes []rune, kinds []TokenKind, start int) {
+	i := scanWord(runes, start, unicode.IsSpace)
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case r == '#' && (i == 0 || unicode.IsSpace(runes[i-1])):
+			fill(kinds, i, len(runes), TokenComment)
+			return
+		case r == '"' || r == '\'':
+			end := scanQuoted(runes, i)
+			fill(kinds, i, end, TokenString)
+			i = end
+		case r == '&' || r == '*':
+			end := scanWord(runes, i+1, func(r rune) bool { return !unicode.IsSpace(r) })
+			fill(kinds, i, end, TokenVariable)
+			i = end
+		case strings.ContainsRune("{}[],|>", r):
+			kinds[i] = TokenPunct
+			i++
+		case unicode.IsSpace(r):
+			i++
+		default:
+			end := scanWord(runes, i, func(r rune) bool {
+				return !unicode.IsSpace(r) && !strings.ContainsRune(",]}", r)
+			})
+			word := string(runes[i:end])
+			switch {
+			case word == "true" || word == "false" || word == "null" || word == "~":
+				fill(kinds, i, end, TokenKeyword)
+			case isNumber(word):
+				fill(kinds, i, end, TokenNumber)
+			default:
+				fill(kinds, i, end, TokenString)
+			}
+			i = end
+		}
+	}
+}
+
+// isNumber returns true if word looks like a number
+func isNumber(word string) bool {
+	if word == "" || word == "-" {
+		return false
+	}
+	first := []rune(word)[0]
+	if !unicode.IsDigit(first) && first != '-' && first != '+' && first != '.' {
+		return false
+	}
+	for _, r := range word {
+		if !isNumberPart(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// tokenizeShell classifies a line of a shell script
+func tokenizeShell(runes []rune, kinds []TokenKind) {
+	wordStart := true
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '#' && wordStart:
+			fill(kinds, i, len(runes), TokenComment)
+			return
+		case r == '\'' || r == '"':
+			end := scanQuoted(runes, i)
+			fill(kinds, i, end, TokenString)
+			// Variables still expand inside double quotes
+			if r == '"' {
+				markShellVariables(runes[:end], kinds, i+1)
+			}
+			i = end
+			wordStart = false
+		case r == '$':
+			i = markShellVariable(runes, kinds, i)
+			wordStart = false
+		case unicode.IsSpace(r) || strings.ContainsRune(";|&()", r):
+			if !unicode.IsSpace(r) {
+				kinds[i] = TokenPunct
+			}
+			i++
+			wordStart = true
+		default:
+			end := scanWord(runes, i, func(r rune) bool {
+				return !unicode.IsSpace(r) && !strings.ContainsRune(";|&()'\"$", r)
+			})
+			if wordStart && shellKeywords[string(runes[i:end])] {
+				fill(kinds, i, end, TokenKeyword)
+			}
+			i = end
+			wordStart = false
+		}
+	}
+}
+
+// markShellVariables marks the variables in runes from start
+func markShellVariables(runes []rune, kinds []TokenKind, start int) {
+	for i := start; i < len(runes); {
+		if runes[i] == '$' {
+			i = markShellVariable(runes, kinds, i)
+		} else {
+			i++
+		}
+	}
+}
+
+// markShellVariable marks $NAME, ${...} or $1 at runes[i] and returns the
+// index after it
+func markShellVariable(runes []rune, kinds []TokenKind, i int) int {
+	end := i + 1
+	switch {
+	case end < len(runes) && runes[end] == '{':
+		for end < len(runes) && runes[end] != '}' {
+			end++
+		}
+		end = min(end+1, len(runes))
+	case end < len(runes) && (unicode.IsDigit(runes[end]) || strings.ContainsRune("?@#*$!", runes[end])):
+		end++
+	default:
+		end = scanWord(runes, end, func(r rune) bool {
+			return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+		})
+	}
+	fill(kinds, i, end, TokenVariable)
+	return end
+}
@@ -0,0 +1,76 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectSyntax(t *testing.T) {
+	tests := []struct {
+		text string
+		want Syntax
+	}{
+		{"-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n", SyntaxPEM},
+		{`{"a": [1, 2], "b": null}`, SyntaxJSON},
+		{"[\n  1,\n  2\n]\n", SyntaxJSON},
+		{"{not json", SyntaxPlain},
+		{"#!/bin/bash\necho hi\n", SyntaxShell},
+		{"set -e\nif [ -f x ]; then\n  rm x\nfi\n", SyntaxShell},
+		{"server:\n  port: 8080\n  hosts:\n    - a\n", SyntaxYAML},
+		{"- a\n- b\n", SyntaxYAML},
+		{"Dear reader,\nthis is prose.\n", SyntaxPlain},
+		{"key: value", SyntaxPlain},
+	}
+
+	for _, tt := range tests {
+		if got := DetectSyntax(tt.text); got != tt.want {
+			t.Errorf("DetectSyntax(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	// One letter per rune: t(ext) k(ey) s(tring) n(umber) w (keyword)
+	// c(omment) p(unct) v(ariable) h(eader)
+	letters := "tksnwcpvh"
+
+	tests := []struct {
+		syntax Syntax
+		line   string
+		want   string
+	}{
+		{SyntaxJSON, `{"a": 1.5, "b": [true]}`, "pkkkptnnnptkkkptpwwwwpp"},
+		{SyntaxYAML, "- name: web # app", "ptkkkkptssstccccc"},
+		{SyntaxYAML, "base: &base {x: 1}", "kkkkptvvvvvtpsstnp"},
+		{SyntaxShell, `if [ "$HOME" ]; then # x`, "wwtttsvvvvvsttptwwwwtccc"},
+		{SyntaxPEM, "-----END KEY-----", strings.Repeat("h", 17)},
+		{SyntaxPlain, "plain", "ttttt"},
+	}
+
+	for _, tt := range tests {
+		var b strings.Builder
+		for _, kind := range Tokenize(tt.syntax, tt.line) {
+			b.WriteByte(letters[kind])
+		}
+		if got := b.String(); got != tt.want {
+			t.Errorf("Tokenize(%v, %q)\n got %s\nwant %s", tt.syntax, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestWrapLines(t *testing.T) {
+	got := WrapLines([]string{"abcdefg", "", "xy"}, 3)
+	want := []ScreenLine{
+		{0, Span{0, 3}}, {0, Span{3, 6}}, {0, Span{6, 7}},
+		{1, Span{0, 0}},
+		{2, Span{0, 2}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("WrapLines() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("WrapLines()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,721 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/uznog/yamlist/internal/model"
+)
+
+// Scope is what plain search text is matched against
+type Scope int
+
+const (
+	ScopeAll      Scope = iota // Keys, paths, values and comments
+	ScopeKeys                  // Map keys
+	ScopeValues                // Scalar values
+	ScopePaths                 // Full paths
+	ScopeComments              // Comments
+)
+
+func (s Scope) String() string {
+	switch s {
+	case ScopeKeys:
+		return "keys"
+	case ScopeValues:
+		return "values"
+	case ScopePaths:
+		return "paths"
+	case ScopeComments:
+		return "comments"
+	default:
+		return "all"
+	}
+}
+
+// Next returns the scope after s, cycling back to ScopeAll
+func (s Scope) Next() Scope {
+	return (s + 1) % (ScopeComments + 1)
+}
+
+// scopePrefixes maps field prefixes (k:, value:, ...) to scopes
+var scopePrefixes = map[string]Scope{
+	"a": ScopeAll, "all": ScopeAll,
+	"k": ScopeKeys, "key": ScopeKeys,
+	"v": ScopeValues, "value": ScopeValues,
+	"p": ScopePaths, "path": ScopePaths,
+	"c": ScopeComments, "comment": ScopeComments,
+}
+
+// keyMatchBonus ranks matches on a node's own key above other matches
+const keyMatchBonus = 1000
+
+// Hit is where a filter matched a node
+type Hit struct {
+	// Score ranks the match; higher is better
+	Score int
+
+	// Rune positions of the match in the key, path string and value
+	Key, Path, Value []int
+}
+
+// merge adds the positions of other to h, keeping the higher score
+func (h Hit) merge(other Hit) Hit {
+	return Hit{
+		Score: max(h.Score, other.Score),
+		Key:   union(h.Key, other.Key),
+		Path:  union(h.Path, other.Path),
+		Value: union(h.Value, other.Value),
+	}
+}
+
+// union returns the sorted union of two position lists
+func union(a, b []int) []int {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	seen := make(map[int]bool, len(a)+len(b))
+	var result []int
+	for _, pos := range append(append([]int{}, a...), b...) {
+		if !seen[pos] {
+			seen[pos] = true
+			result = append(result, pos)
+		}
+	}
+	sort.Ints(result)
+	return result
+}
+
+// Filter is a compiled search
+// Words are ANDed; each is plain text, matched in the default scope, or a
+// field:
+//
+//	k:name v:nginx p:spec.img c:todo a:text  text in one scope
+//	re:_timeout$ k:re:^x-                    regular expressions
+//	type:int kind:list depth:>3              node type and nesting
+//	v>30 v:<=1.5 v=true v!=prod              value comparisons
+//	a and b, a or b, not a, !a, (a or b) c   boolean combinations
+//
+// Keys and paths match fuzzily, values and comments by substring; text and
+// regular expressions are case-insensitive unless they have an upper case
+// letter. Quote text to search for spaces, parentheses or keywords.
+type Filter struct {
+	root matcher
+}
+
+// Compile parses a search in the given default scope
+func Compile(input string, scope Scope) (*Filter, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty search")
+	}
+
+	p := &filterParser{tokens: tokens, scope: scope}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	return &Filter{root: root}, nil
+}
+
+// Match returns where the filter matches node, if it does
+func (f *Filter) Match(node *model.Node) (Hit, bool) {
+	if node.IsError {
+		return Hit{}, false
+	}
+	return f.root.match(node)
+}
+
+// matcher is a compiled part of a filter
+type matcher interface {
+	match(node *model.Node) (Hit, bool)
+}
+
+type andMatcher struct{ left, right matcher }
+
+func (m andMatcher) match(node *model.Node) (Hit, bool) {
+	left, ok := m.left.match(node)
+	if !ok {
+		return Hit{}, false
+	}
+	right, ok := m.right.match(node)
+	if !ok {
+		return Hit{}, false
+	}
+	hit := left.merge(right)
+	hit.Score = left.Score + right.Score
+	return hit, true
+}
+
+type orMatcher struct{ left, right matcher }
+
+func (m orMatcher) match(node *model.Node) (Hit, bool) {
+	left, okLeft := m.left.match(node)
+	right, okRight := m.right.match(node)
+	switch {
+	case okLeft && okRight:
+		return left.merge(right), true
+	case okLeft:
+		return left, true
+	case okRight:
+		return right, true
+	}
+	return Hit{}, false
+}
+
+type notMatcher struct{ operand matcher }
+
+func (m notMatcher) match(node *model.Node) (Hit, bool) {
+	_, ok := m.operand.match(node)
+	return Hit{}, !ok
+}
+
+// textMatcher matches text in a scope
+type textMatcher struct {
+	scope Scope
+	text  string
+}
+
+func (m textMatcher) match(node *model.Node) (Hit, bool) {
+	var hit Hit
+	found := false
+	add := func(h Hit, ok bool) {
+		if ok {
+			hit = hit.merge(h)
+			found = true
+		}
+	}
+
+	if m.scope == ScopeAll || m.scope == ScopeKeys || m.scope == ScopePaths {
+		add(matchKeyPath(m.text, node, m.scope))
+	}
+	if m.scope == ScopeAll || m.scope == ScopeValues {
+		if value, ok := scalarText(node); ok {
+			if result, ok := Contains(m.text, value); ok {
+				add(Hit{Score: result.Score, Value: result.Positions}, true)
+			}
+		}
+	}
+	if m.scope == ScopeAll || m.scope == ScopeComments {
+		if result, ok := Contains(m.text, node.CommentText()); ok {
+			add(Hit{Score: result.Score}, true)
+		}
+	}
+	return hit, found
+}
+
+// matchKeyPath fuzzy-matches text against a node's key and path
+// A path only matches if the match ends in the node's own segment, so the
+// descendants of a matching key are not all matches too.
+func matchKeyPath(text string, node *model.Node, scope Scope) (Hit, bool) {
+	key := node.Key
+	if key == "" {
+		key = node.Label // List items match by their label
+	}
+	path := node.Path.String()
+	segment := lastSegment(node)
+	segStart := utf8.RuneCountInString(path) - utf8.RuneCountInString(segment)
+
+	var hit Hit
+	found := false
+	if scope != ScopeKeys {
+		if result, ok := MatchEnding(text, path, segStart); ok {
+			hit = Hit{Score: result.Score, Path: result.Positions}
+			found = true
+
+			// Highlight the part of the match inside the key, when the key
+			// reads the same in the path
+			if segment == key {
+				for _, pos := range result.Positions {
+					if pos >= segStart {
+						hit.Key = append(hit.Key, pos-segStart)
+					}
+				}
+			}
+		}
+	}
+
+	if scope != ScopePaths && key != "" {
+		if result, ok := Match(text, key); ok && (!found || result.Score+keyMatchBonus > hit.Score) {
+			hit = Hit{Score: result.Score + keyMatchBonus, Key: result.Positions}
+			if segment == key {
+				hit.Path = shift(result.Positions, segStart)
+			}
+			found = true
+		}
+	}
+	return hit, found
+}
+
+// regexMatcher matches a regular expression in a scope
+// In ScopeAll, keys, values and comments are tried.
+type regexMatcher struct {
+	scope Scope
+	re    *regexp.Regexp
+}
+
+func (m regexMatcher) match(node *model.Node) (Hit, bool) {
+	var hit Hit
+	found := false
+
+	if m.scope == ScopeAll || m.scope == ScopeKeys {
+		if positions, ok := regexPositions(m.re, node.Key); ok && node.Key != "" {
+			hit = hit.merge(Hit{Score: keyMatchBonus + len(positions)*scoreMatch, Key: positions})
+			if lastSegment(node) == node.Key {
+				path := node.Path.String()
+				segStart := utf8.RuneCountInString(path) - utf8.RuneCountInString(node.Key)
+				hit.Path = shift(positions, segStart)
+			}
+			found = true
+		}
+	}
+	if m.scope == ScopePaths {
+		if positions, ok := regexPositions(m.re, node.Path.String()); ok {
+			hit = hit.merge(Hit{Score: len(positions) * scoreMatch, Path: positions})
+			found = true
+		}
+	}
+	if m.scope == ScopeAll || m.scope == ScopeValues {
+		if value, ok := scalarText(node); ok {
+			if positions, ok := regexPositions(m.re, value); ok {
+				hit = hit.merge(Hit{Score: len(positions) * scoreMatch, Value: positions})
+				found = true
+			}
+		}
+	}
+	if m.scope == ScopeAll || m.scope == ScopeComments {
+		if m.re.MatchString(node.CommentText()) {
+			found = true
+		}
+	}
+	return hit, found
+}
+
+// regexPositions returns the rune positions of the first match of re in s
+func regexPositions(re *regexp.Regexp, s string) ([]int, bool) {
+	loc := re.FindStringIndex(s)
+	if loc == nil {
+		return nil, false
+	}
+	start := utf8.RuneCountInString(s[:loc[0]])
+	return span(start, utf8.RuneCountInString(s[loc[0]:loc[1]])), true
+}
+
+// typeMatcher matches scalars of a type
+type typeMatcher struct{ scalarType model.ScalarType }
+
+func (m typeMatcher) match(node *model.Node) (Hit, bool) {
+	return Hit{}, node.Kind == model.KindScalar && node.ScalarType == m.scalarType
+}
+
+// kindMatcher matches nodes of a kind
+type kindMatcher struct{ kind model.NodeKind }
+
+func (m kindMatcher) match(node *model.Node) (Hit, bool) {
+	return Hit{}, node.Kind == m.kind
+}
+
+// depthMatcher compares the nesting depth of a node; top-level keys are
+// at depth 1 and documents of a stream don't count
+type depthMatcher struct {
+	op    string
+	depth int
+}
+
+func (m depthMatcher) match(node *model.Node) (Hit, bool) {
+	depth := 0
+	for _, seg := range node.Path.Segments {
+		if !seg.IsDocument() {
+			depth++
+		}
+	}
+	return Hit{}, compareOrdered(depth-m.depth, m.op)
+}
+
+// valueMatcher compares scalar values with an operand
+// Values compare by their type, as in path queries: a number is never equal
+// to a string, and a quoted "50" is a string.
+type valueMatcher struct {
+	op      string
+	operand model.Value
+}
+
+func (m valueMatcher) match(node *model.Node) (Hit, bool) {
+	value, ok := scalarText(node)
+	if !ok || !model.Compare(model.ValueOf(node), m.op, m.operand) {
+		return Hit{}, false
+	}
+	return Hit{Value: span(0, utf8.RuneCountInString(value))}, true
+}
+
+// compareOrdered applies a comparison operator to the sign of a difference
+func compareOrdered(c int, op string) bool {
+	switch op {
+	case "=", "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// scalarText returns the value of a scalar node
+func scalarText(node *model.Node) (string, bool) {
+	if node.Kind != model.KindScalar || node.IsError {
+		return "", false
+	}
+	return node.ScalarValue, true
+}
+
+// lastSegment returns the last segment of a node's path string
+func lastSegment(node *model.Node) string {
+	path := node.Path.String()
+	if node.Parent == nil || len(node.Parent.Path.Segments) == 0 {
+		return path
+	}
+	segment := strings.TrimPrefix(path, node.Parent.Path.String())
+	return strings.TrimPrefix(segment, ".")
+}
+
+// shift returns positions moved by offset
+func shift(positions []int, offset int) []int {
+	result := make([]int, len(positions))
+	for i, pos := range positions {
+		result[i] = pos + offset
+	}
+	return result
+}
+
+// span returns the n positions starting at start
+func span(start, n int) []int {
+	positions := make([]int, n)
+	for i := range positions {
+		positions[i] = start + i
+	}
+	return positions
+}
+
+// token is a word of a search
+type token struct {
+	text   string
+	quoted bool // Parts of the word were quoted: never a keyword
+}
+
+// tokenize splits a search into words and parentheses
+// Quotes group text with spaces. A backslash escapes a quote, space or
+// parenthesis and is otherwise kept, so regular expressions read as usual.
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		// Opening parentheses before a word
+		if runes[i] == '(' {
+			tokens = append(tokens, token{text: "("})
+			i++
+			continue
+		}
+
+		var b strings.Builder
+		quoted := false
+		depth := 0 // Parentheses opened inside the word, as in re:(a|b)
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			r := runes[i]
+			if r == '"' {
+				quoted = true
+				for i++; i < len(runes) && runes[i] != '"'; i++ {
+					if runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == '"' {
+						i++
+					}
+					b.WriteRune(runes[i])
+				}
+				if i == len(runes) {
+					return nil, fmt.Errorf("unclosed quote")
+				}
+				i++
+				continue
+			}
+			if r == ')' && depth == 0 {
+				break
+			}
+			if r == '(' {
+				depth++
+			} else if r == ')' {
+				depth--
+			}
+			if r == '\\' && i+1 < len(runes) && isEscapable(runes[i+1]) {
+				i++
+				b.WriteRune(runes[i])
+				i++
+				continue
+			}
+			b.WriteRune(r)
+			i++
+		}
+		if b.Len() > 0 || quoted {
+			tokens = append(tokens, token{text: b.String(), quoted: quoted})
+		}
+
+		// Closing parentheses after a word
+		for i < len(runes) && runes[i] == ')' {
+			tokens = append(tokens, token{text: ")"})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// isEscapable returns true for runes a backslash escapes outside quotes
+func isEscapable(r rune) bool {
+	return r == '"' || r == '(' || r == ')' || unicode.IsSpace(r)
+}
+
+// filterParser builds a matcher from tokens
+type filterParser struct {
+	tokens []token
+	pos    int
+	scope  Scope
+}
+
+func (p *filterParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *filterParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// keyword returns true and consumes the next token if it is one of words
+func (p *filterParser) keyword(words ...string) bool {
+	if p.done() || p.peek().quoted {
+		return false
+	}
+	for _, word := range words {
+		if p.peek().text == word {
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+// parseOr parses a or b
+func (p *filterParser) parseOr() (matcher, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or", "OR", "||", "|") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orMatcher{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd parses a and b, or just a b
+func (p *filterParser) parseAnd() (matcher, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		explicit := p.keyword("and", "AND", "&&", "&")
+		if !explicit && (p.done() || p.peek().text == ")" && !p.peek().quoted || p.isOr()) {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andMatcher{left, right}
+	}
+}
+
+// isOr returns true if the next token is an or keyword
+func (p *filterParser) isOr() bool {
+	if p.done() || p.peek().quoted {
+		return false
+	}
+	switch p.peek().text {
+	case "or", "OR", "||", "|":
+		return true
+	}
+	return false
+}
+
+// parseUnary parses not a, !a, (a) and single terms
+func (p *filterParser) parseUnary() (matcher, error) {
+	if p.done() {
+		return nil, fmt.Errorf("incomplete search")
+	}
+	if p.keyword("not", "NOT", "!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notMatcher{operand}, nil
+	}
+
+	tok := p.peek()
+	p.pos++
+	if !tok.quoted {
+		switch {
+		case tok.text == "(":
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if !p.keyword(")") {
+				return nil, fmt.Errorf("missing )")
+			}
+			return inner, nil
+		case tok.text == ")":
+			return nil, fmt.Errorf("unexpected )")
+		case strings.HasPrefix(tok.text, "!") && len(tok.text) > 1:
+			operand, err := parseTerm(token{text: tok.text[1:]}, p.scope)
+			if err != nil {
+				return nil, err
+			}
+			return notMatcher{operand}, nil
+		}
+	}
+	return parseTerm(tok, p.scope)
+}
+
+// comparisonOps are the comparison operators, longest first
+var comparisonOps = []string{"==", "!=", "<=", ">=", "=", "<", ">"}
+
+// cutComparison splits an operator off the start of s
+func cutComparison(s string) (op, rest string, ok bool) {
+	for _, op := range comparisonOps {
+		if strings.HasPrefix(s, op) {
+			return op, s[len(op):], true
+		}
+	}
+	return "", s, false
+}
+
+// parseTerm parses a single search word
+func parseTerm(tok token, scope Scope) (matcher, error) {
+	if tok.quoted {
+		return textMatcher{scope: scope, text: tok.text}, nil
+	}
+	text := tok.text
+
+	// Value and depth comparisons without a colon: v>30, depth<=2
+	for _, field := range []string{"value", "v", "depth"} {
+		if rest, ok := strings.CutPrefix(text, field); ok {
+			if op, operand, ok := cutComparison(rest); ok {
+				return parseComparison(field, op, operand)
+			}
+		}
+	}
+
+	field, rest, hasField := strings.Cut(text, ":")
+	if !hasField {
+		return textMatcher{scope: scope, text: text}, nil
+	}
+
+	switch field {
+	case "re":
+		return parseRegex(rest, scope)
+	case "type":
+		for t := model.ScalarString; t <= model.ScalarTimestamp; t++ {
+			if t.String() == rest {
+				return typeMatcher{t}, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown type %q (string, int, float, bool, null or timestamp)", rest)
+	case "kind":
+		for k := model.KindScalar; k <= model.KindList; k++ {
+			if k.String() == rest {
+				return kindMatcher{k}, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown kind %q (map, list or scalar)", rest)
+	case "depth":
+		op, operand, ok := cutComparison(rest)
+		if !ok {
+			op = "="
+		}
+		return parseComparison(field, op, operand)
+	}
+
+	fieldScope, ok := scopePrefixes[field]
+	if !ok {
+		// Not a field: keys may contain colons
+		return textMatcher{scope: scope, text: text}, nil
+	}
+	if pattern, ok := strings.CutPrefix(rest, "re:"); ok {
+		return parseRegex(pattern, fieldScope)
+	}
+	if fieldScope == ScopeValues {
+		if op, operand, ok := cutComparison(rest); ok {
+			return parseComparison("value", op, operand)
+		}
+	}
+	if rest == "" {
+		return nil, fmt.Errorf("missing text after %s:", field)
+	}
+	return textMatcher{scope: fieldScope, text: rest}, nil
+}
+
+// parseComparison builds a value or depth comparison
+func parseComparison(field, op, operand string) (matcher, error) {
+	if operand == "" {
+		return nil, fmt.Errorf("missing value after %s%s", field, op)
+	}
+	if field != "depth" {
+		return valueMatcher{op: op, operand: model.ParseValue(operand)}, nil
+	}
+	depth, err := strconv.Atoi(operand)
+	if err != nil {
+		return nil, fmt.Errorf("depth must be a number, not %q", operand)
+	}
+	return depthMatcher{op: op, depth: depth}, nil
+}
+
+// parseRegex compiles a smart-case regular expression
+func parseRegex(pattern string, scope Scope) (matcher, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("missing pattern after re:")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %v", err)
+	}
+	if !hasUpper([]rune(pattern)) {
+		re = regexp.MustCompile("(?i)" + pattern)
+	}
+	return regexMatcher{scope: scope, re: re}, nil
+}
@@ -0,0 +1,219 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/uznog/yamlist/internal/model"
+	"github.com/uznog/yamlist/internal/yamlparse"
+)
+
+const filterSource = `server:
+  host: api.example.com
+  read_timeout: 60
+  write_timeout: 15
+  idle: null
+  tags: [web, Edge]
+image: nginx:1.25 # pinned for the host check
+replicas: 3
+`
+
+// filterPaths returns the paths of the nodes of doc that match a search
+func filterPaths(t *testing.T, doc *yamlparse.Document, input string, scope Scope) []string {
+	t.Helper()
+	f, err := Compile(input, scope)
+	if err != nil {
+		t.Fatalf("Compile(%q) failed: %v", input, err)
+	}
+	paths := []string{}
+	for _, entry := range doc.Index.Entries() {
+		if _, ok := f.Match(entry.Node); ok {
+			paths = append(paths, entry.Node.Path.String())
+		}
+	}
+	return paths
+}
+
+func TestFilter(t *testing.T) {
+	doc, err := yamlparse.ParseString(filterSource)
+	if err != nil {
+		t.Fatalf("ParseString failed: %v", err)
+	}
+
+	tests := []struct {
+		input string
+		scope Scope
+		want  []string
+	}{
+		// Scopes
+		{"host", ScopeAll, []string{"server.host", "image"}},
+		{"host", ScopeKeys, []string{"server.host"}},
+		{"host", ScopeComments, []string{"image"}},
+		{"example", ScopeAll, []string{"server.host"}},
+		{"example", ScopeKeys, []string{}},
+		{"v:nginx", ScopeKeys, []string{"image"}},
+		{"k:host", ScopeValues, []string{"server.host"}},
+		{"p:srvhost", ScopeAll, []string{"server.host"}},
+		{"c:pinned", ScopeAll, []string{"image"}},
+		{"edge", ScopeValues, []string{"server.tags[1]"}},
+		{"Edge", ScopeValues, []string{"server.tags[1]"}},
+		{"EDGE", ScopeValues, []string{}},
+		{"key:colon:in", ScopeAll, []string{}},
+
+		// Regular expressions
+		{"re:_timeout$", ScopeAll, []string{"server.read_timeout", "server.write_timeout"}},
+		{"k:re:^r", ScopeAll, []string{"server.read_timeout", "replicas"}},
+		{"v:re:^\\d+$", ScopeAll, []string{"server.read_timeout", "server.write_timeout", "replicas"}},
+		{"p:re:^server\\.tags\\[", ScopeAll, []string{"server.tags[0]", "server.tags[1]"}},
+		{"re:(web|edge)", ScopeValues, []string{"server.tags[0]", "server.tags[1]"}},
+
+		// Types, kinds and depth
+		{"type:null", ScopeAll, []string{"server.idle"}},
+		{"type:int", ScopeAll, []string{"server.read_timeout", "server.write_timeout", "replicas"}},
+		{"kind:list", ScopeAll, []string{"server.tags"}},
+		{"kind:map depth:1", ScopeAll, []string{"server"}},
+		{"depth:>2", ScopeAll, []string{"server.tags[0]", "server.tags[1]"}},
+		{"depth<1", ScopeAll, []string{"(root)"}},
+
+		// Value comparisons
+		{"re:_timeout$ v>30", ScopeAll, []string{"server.read_timeout"}},
+		{"v:<=15", ScopeAll, []string{"server.write_timeout", "replicas"}},
+		{"value=web", ScopeAll, []string{"server.tags[0]"}},
+		{"v!=3 type:int", ScopeAll, []string{"server.read_timeout", "server.write_timeout"}},
+
+		// Boolean combinations
+		{"type:null or replicas", ScopeKeys, []string{"server.idle", "replicas"}},
+		{"kind:scalar and not type:int and depth:1", ScopeAll, []string{"image"}},
+		{"!kind:scalar", ScopeAll, []string{"(root)", "server", "server.tags"}},
+		{"(web || edge) depth:3", ScopeValues, []string{"server.tags[0]", "server.tags[1]"}},
+		{`"or"`, ScopeKeys, []string{}},
+		{`"api.example" or "a b"`, ScopeValues, []string{"server.host"}},
+		{`c:the\ host`, ScopeAll, []string{"image"}},
+	}
+
+	for _, tt := range tests {
+		got := filterPaths(t, doc, tt.input, tt.scope)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q in %v: got %q, want %q", tt.input, tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestFilter_Labels(t *testing.T) {
+	doc, err := yamlparse.ParseString("services:\n  - name: web\n    port: 80\n  - name: db\n    port: 5432\n")
+	if err != nil {
+		t.Fatalf("ParseString failed: %v", err)
+	}
+	model.LabelItems(doc.Root, model.DefaultLabelKeys)
+
+	tests := []struct {
+		input string
+		scope Scope
+		want  []string
+	}{
+		{"web", ScopeKeys, []string{"services[0]"}},
+		{"k:db", ScopeAll, []string{"services[1]"}},
+		{"web", ScopeValues, []string{"services[0].name"}},
+	}
+
+	for _, tt := range tests {
+		got := filterPaths(t, doc, tt.input, tt.scope)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q in %v: got %q, want %q", tt.input, tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestFilter_QuotedNumbers(t *testing.T) {
+	doc, err := yamlparse.ParseString("a: 50\nb: \"50\"\nc: '7'\nd: true\n")
+	if err != nil {
+		t.Fatalf("ParseString failed: %v", err)
+	}
+
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"v>30", []string{"a"}},
+		{"v=50", []string{"a"}},
+		{"v<10", []string{}},
+		{"v!=50 depth:1", []string{"b", "c", "d"}},
+		{"v=true", []string{"d"}},
+	}
+
+	for _, tt := range tests {
+		got := filterPaths(t, doc, tt.input, ScopeAll)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestFilter_Hits(t *testing.T) {
+	doc, err := yamlparse.ParseString(filterSource)
+	if err != nil {
+		t.Fatalf("ParseString failed: %v", err)
+	}
+	node := doc.Root.Children[0].Children[0] // server.host
+
+	tests := []struct {
+		input string
+		want  Hit
+	}{
+		{"k:hst", Hit{Key: []int{0, 2, 3}, Path: []int{7, 9, 10}}},
+		{"v:example", Hit{Value: []int{4, 5, 6, 7, 8, 9, 10}}},
+		{"k:re:os v:re:com$", Hit{Key: []int{1, 2}, Path: []int{8, 9}, Value: []int{12, 13, 14}}},
+	}
+
+	for _, tt := range tests {
+		f, err := Compile(tt.input, ScopeAll)
+		if err != nil {
+			t.Fatalf("Compile(%q) failed: %v", tt.input, err)
+		}
+		got, ok := f.Match(node)
+		if !ok {
+			t.Errorf("%q did not match server.host", tt.input)
+			continue
+		}
+		got.Score = 0
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: hit = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"   ",
+		"type:integer",
+		"kind:array",
+		"depth:>deep",
+		"re:(",
+		"re:",
+		"v:",
+		"v>",
+		`"unclosed`,
+		"(a or b",
+		"a)",
+		"a and",
+		"not",
+	} {
+		if _, err := Compile(input, ScopeAll); err == nil {
+			t.Errorf("Compile(%q) succeeded, want an error", input)
+		}
+	}
+}
+
+func TestScopeNext(t *testing.T) {
+	scope := ScopeAll
+	var seen []string
+	for i := 0; i < 6; i++ {
+		seen = append(seen, scope.String())
+		scope = scope.Next()
+	}
+	want := []string{"all", "keys", "values", "paths", "comments", "all"}
+	if !reflect.DeepEqual(seen, want) {
+		t.Errorf("scopes = %v, want %v", seen, want)
+	}
+}
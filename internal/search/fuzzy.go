@@ -0,0 +1,241 @@
+package search
+
+import (
+	"unicode"
+)
+
+// Scoring, after fzf: every matched character scores, with bonuses for
+// characters that start a word or continue a run, and penalties for gaps
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+
+	bonusStart       = 10 // First character of the text
+	bonusBoundary    = 8  // After a separator such as . / _ - or a space
+	bonusCamel       = 7  // An upper case letter after a lower case one, or a digit after a letter
+	bonusConsecutive = 4  // Directly after the previous matched character; runs keep the bonus they started with
+
+	// The first pattern character's bonus counts double
+	bonusFirstCharMultiplier = 2
+)
+
+// Result is a successful fuzzy match
+type Result struct {
+	// Score ranks the match; higher is better
+	Score int
+
+	// Positions are the indices of the matched runes in the text, ascending
+	Positions []int
+}
+
+// Match fuzzy-matches pattern against text
+// Pattern runes must appear in text in order, not necessarily adjacent. The
+// match is case-insensitive unless the pattern has an upper case letter
+// (smart case). Of all possible alignments the best scoring one is returned.
+func Match(pattern, text string) (Result, bool) {
+	return MatchEnding(pattern, text, 0)
+}
+
+// MatchEnding is Match, where the last pattern rune must match at rune
+// index end or later
+// Matching a path with end set to the start of its last segment finds the
+// node itself rather than everything below a matching ancestor.
+func MatchEnding(pattern, text string, end int) (Result, bool) {
+	p := []rune(pattern)
+	t := []rune(text)
+	if len(p) == 0 {
+		return Result{}, true
+	}
+	if len(p) > len(t) {
+		return Result{}, false
+	}
+
+	caseSensitive := hasUpper(p)
+	folded := t
+	if !caseSensitive {
+		folded = make([]rune, len(t))
+		for i, r := range t {
+			folded[i] = unicode.ToLower(r)
+		}
+	}
+
+	// Cheap check that the pattern is a subsequence ending late enough
+	if !isSubsequence(p, folded, end) {
+		return Result{}, false
+	}
+
+	bonus := make([]int, len(t))
+	for i := range t {
+		bonus[i] = charBonus(t, i)
+	}
+
+	// score[i][j] is the best score with p[i] matched at t[j]; from[i][j]
+	// is where p[i-1] was matched for it and run[i][j] the bonus of the
+	// character starting the run of consecutive matches
+	const none = -1 << 30
+	score := make([][]int, len(p))
+	from := make([][]int, len(p))
+	run := make([][]int, len(p))
+	for i := range p {
+		score[i] = make([]int, len(t))
+		from[i] = make([]int, len(t))
+		run[i] = make([]int, len(t))
+		for j := range t {
+			score[i][j] = none
+		}
+	}
+
+	for j := range t {
+		if folded[j] == p[0] {
+			score[0][j] = scoreMatch + bonus[j]*bonusFirstCharMultiplier
+			run[0][j] = bonus[j]
+		}
+	}
+
+	for i := 1; i < len(p); i++ {
+		// best tracks max(score[i-1][k] - scoreGapExtension*k) over
+		// k <= j-2, as a gap from k to j costs
+		// scoreGapStart + scoreGapExtension*(j-k-2)
+		best, bestAt := none, -1
+		for j := i; j < len(t); j++ {
+			if k := j - 2; k >= 0 && score[i-1][k] != none && score[i-1][k]-scoreGapExtension*k > best {
+				best, bestAt = score[i-1][k]-scoreGapExtension*k, k
+			}
+			if folded[j] != p[i] {
+				continue
+			}
+
+			if prev := score[i-1][j-1]; prev != none {
+				runBonus := max(bonus[j], run[i-1][j-1], bonusConsecutive)
+				score[i][j] = prev + scoreMatch + runBonus
+				from[i][j] = j - 1
+				run[i][j] = runBonus
+			}
+			if best != none {
+				gapped := best + scoreGapStart + scoreGapExtension*(j-2) + scoreMatch + bonus[j]
+				if gapped > score[i][j] {
+					score[i][j] = gapped
+					from[i][j] = bestAt
+					run[i][j] = bonus[j]
+				}
+			}
+		}
+	}
+
+	last := len(p) - 1
+	at := -1
+	for j := max(end, last); j < len(t); j++ {
+		if score[last][j] != none && (at < 0 || score[last][j] > score[last][at]) {
+			at = j
+		}
+	}
+	if at < 0 {
+		return Result{}, false
+	}
+
+	result := Result{Score: score[last][at], Positions: make([]int, len(p))}
+	for i := last; i >= 0; i-- {
+		result.Positions[i] = at
+		at = from[i][at]
+	}
+	return result, true
+}
+
+// Contains matches pattern as a substring of text, with the same smart
+// case as Match
+// Matches at word boundaries, and whole-text matches, score higher.
+func Contains(pattern, text string) (Result, bool) {
+	p := []rune(pattern)
+	t := []rune(text)
+	if len(p) == 0 {
+		return Result{}, true
+	}
+
+	caseSensitive := hasUpper(p)
+	equal := func(a, b rune) bool {
+		return a == b || !caseSensitive && unicode.ToLower(a) == b
+	}
+
+	best := Result{Score: -1}
+	for start := 0; start+len(p) <= len(t); start++ {
+		matched := true
+		for i, r := range p {
+			if !equal(t[start+i], r) {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		score := scoreMatch*len(p) + charBonus(t, start)*bonusFirstCharMultiplier
+		if len(p) == len(t) {
+			score += bonusStart
+		}
+		if score > best.Score {
+			best = Result{Score: score, Positions: make([]int, len(p))}
+			for i := range p {
+				best.Positions[i] = start + i
+			}
+		}
+	}
+	return best, best.Score >= 0
+}
+
+// hasUpper returns true if any rune is upper case
+func hasUpper(runes []rune) bool {
+	for _, r := range runes {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSubsequence returns true if p appears in t in order, with its last rune
+// at index end or later
+func isSubsequence(p, t []rune, end int) bool {
+	i := len(p) - 1
+	j := len(t) - 1
+	for ; j >= end && t[j] != p[i]; j-- {
+	}
+	if j < end {
+		return false
+	}
+	for i--; i >= 0; i-- {
+		for j--; j >= 0 && t[j] != p[i]; j-- {
+		}
+		if j < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// charBonus returns the bonus for matching the rune at index i
+func charBonus(t []rune, i int) int {
+	if i == 0 {
+		return bonusStart
+	}
+	prev, cur := t[i-1], t[i]
+	switch {
+	case isSeparator(prev) && !isSeparator(cur):
+		return bonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return bonusCamel
+	case unicode.IsLetter(prev) && unicode.IsDigit(cur):
+		return bonusCamel
+	}
+	return 0
+}
+
+// isSeparator returns true for runes that separate words in keys and paths
+func isSeparator(r rune) bool {
+	switch r {
+	case '.', '/', '_', '-', ' ', ':', '[', ']', '"', '#':
+		return true
+	}
+	return unicode.IsSpace(r)
+}
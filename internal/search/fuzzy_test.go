@@ -0,0 +1,83 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		text      string
+		ok        bool
+		positions []int
+	}{
+		{"", "anything", true, nil},
+		{"img", "image", true, []int{0, 1, 3}},
+		{"image", "image", true, []int{0, 1, 2, 3, 4}},
+		{"ci", "spec.containers[0].image", true, []int{5, 10}},
+		{"cim", "spec.containers[0].image", true, []int{5, 19, 20}},
+		{"port", "spec.ports[0].targetPort", true, []int{5, 6, 7, 8}},
+		{"tp", "targetPort", true, []int{0, 6}},
+		{"Port", "targetPort", true, []int{6, 7, 8, 9}},
+		{"Port", "ports", false, nil},
+		{"PORT", "ports", false, nil},
+		{"PORT", "PORTS", true, []int{0, 1, 2, 3}},
+		{"xyz", "image", false, nil},
+		{"imagex", "image", false, nil},
+		{"ée", "Éclair ée", true, []int{7, 8}},
+	}
+
+	for _, tt := range tests {
+		got, ok := Match(tt.pattern, tt.text)
+		if ok != tt.ok {
+			t.Errorf("Match(%q, %q) ok = %v, want %v", tt.pattern, tt.text, ok, tt.ok)
+			continue
+		}
+		if ok && !reflect.DeepEqual(got.Positions, tt.positions) {
+			t.Errorf("Match(%q, %q) positions = %v, want %v", tt.pattern, tt.text, got.Positions, tt.positions)
+		}
+	}
+}
+
+func TestMatch_Ranking(t *testing.T) {
+	// Each pattern should score the first text above the second
+	tests := []struct {
+		pattern      string
+		better, less string
+	}{
+		{"name", "metadata.name", "metadata.annotations.mode"},
+		{"name", "name", "n_a_m_e"},
+		{"img", "image", "limit_memory_gb"},
+		{"rep", "replicas", "strategy.type.rep"},
+		{"tp", "targetPort", "timeoutSeconds.sleep"},
+		{"ab", "ab", "a_b"},
+	}
+
+	for _, tt := range tests {
+		better, ok1 := Match(tt.pattern, tt.better)
+		less, ok2 := Match(tt.pattern, tt.less)
+		if !ok1 || !ok2 {
+			t.Errorf("%q: expected both %q and %q to match", tt.pattern, tt.better, tt.less)
+			continue
+		}
+		if better.Score <= less.Score {
+			t.Errorf("%q: %q scored %d, want more than %q (%d)", tt.pattern, tt.better, better.Score, tt.less, less.Score)
+		}
+	}
+}
+
+func TestMatchEnding(t *testing.T) {
+	path := "spec.template.spec.containers[0].name"
+	if _, ok := MatchEnding("spec", path, 33); ok {
+		t.Error("MatchEnding matched an ancestor segment")
+	}
+
+	got, ok := MatchEnding("cname", path, 33)
+	if !ok {
+		t.Fatal("MatchEnding(cname) failed")
+	}
+	if want := []int{19, 33, 34, 35, 36}; !reflect.DeepEqual(got.Positions, want) {
+		t.Errorf("positions = %v, want %v", got.Positions, want)
+	}
+}
@@ -0,0 +1,120 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/uznog/yamlist/internal/decode"
+	"github.com/uznog/yamlist/internal/model"
+	"github.com/uznog/yamlist/internal/yamlparse"
+)
+
+// docFrame is a document set aside while a value decoded from it is browsed
+type docFrame struct {
+	Document  *yamlparse.Document
+	TreeState *model.TreeState
+	ViewMode  ViewMode
+}
+
+// openDecoded browses the decoded form of the selected value
+// JSON and YAML (possibly inside base64) become a read-only tree in place
+// of the document; other encodings open decoded in the pager.
+func (m *Model) openDecoded() {
+	row := m.TreeState.GetSelectedRow()
+	if row == nil || row.Kind() != model.KindScalar || row.Node.IsError {
+		m.SetNotice("only values can be decoded")
+		return
+	}
+	decoded, ok := decode.Decode(row.Node.ScalarValue)
+	if !ok {
+		m.SetNotice("no base64, JSON, YAML or PEM found in the value")
+		return
+	}
+	if !decoded.IsTree() {
+		m.openPager()
+		m.Pager.ShowDecoded = true
+		m.loadPagerLines()
+		return
+	}
+
+	format := yamlparse.FormatYAML
+	if decoded.Last() == decode.JSON {
+		format = yamlparse.FormatJSON
+	}
+	doc, err := yamlparse.ParseBytesAs([]byte(decoded.Text), "", format)
+	if err != nil {
+		m.SetError("decode: " + err.Error())
+		return
+	}
+	doc.DecodedFrom = row.Node.Path.String()
+	model.LabelItems(doc.Root, m.Config.LabelKeys)
+
+	m.clearSearch()
+	m.DocStack = append(m.DocStack, docFrame{
+		Document:  m.Document,
+		TreeState: m.TreeState,
+		ViewMode:  m.ViewMode,
+	})
+	m.Document = doc
+	m.TreeState = model.NewTreeState(doc.Root)
+	m.TreeState.ExpandAll()
+	m.ViewMode = TreeView
+	m.computeVisibleRows()
+	m.ensureSelectedVisible()
+	m.SetNotice("decoded " + decoded.Label() + " (backspace to go back)")
+}
+
+// closeDecoded returns to the document the current one was decoded from
+func (m *Model) closeDecoded() bool {
+	if len(m.DocStack) == 0 {
+		return false
+	}
+	frame := m.DocStack[len(m.DocStack)-1]
+	m.DocStack = m.DocStack[:len(m.DocStack)-1]
+
+	m.clearSearch()
+	m.Document = frame.Document
+	m.TreeState = frame.TreeState
+	m.ViewMode = frame.ViewMode
+	m.computeVisibleRows()
+	m.ensureSelectedVisible()
+	m.notifyLineChange()
+	return true
+}
+
+// closeAllDecoded returns to the source document
+func (m *Model) closeAllDecoded() {
+	for m.closeDecoded() {
+	}
+}
+
+// leaveDecoded returns to the source document before it is reloaded
+func (m *Model) leaveDecoded() {
+	if !m.isDecoded() {
+		return
+	}
+	if m.Mode == PagerMode {
+		m.closePager()
+	}
+	m.closeAllDecoded()
+	m.SetNotice("the source changed: closed the decoded value")
+}
+
+// isDecoded returns true while a decoded value is browsed
+func (m *Model) isDecoded() bool {
+	return len(m.DocStack) > 0
+}
+
+// decodedPrefix returns the paths of the values being browsed, for the
+// status bar, e.g. "data.config › "
+func (m *Model) decodedPrefix() string {
+	var b strings.Builder
+	for i := range m.DocStack {
+		// The path of each decoded document is kept on the next one
+		from := m.Document.DecodedFrom
+		if i+1 < len(m.DocStack) {
+			from = m.DocStack[i+1].Document.DecodedFrom
+		}
+		b.WriteString(from + " › ")
+	}
+	return b.String()
+}
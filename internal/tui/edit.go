@@ -0,0 +1,304 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/uznog/yamlist/internal/history"
+	"github.com/uznog/yamlist/internal/model"
+	"github.com/uznog/yamlist/internal/yamlparse"
+)
+
+// startEdit opens the inline input for the selected scalar value
+func (m *Model) startEdit() (tea.Model, tea.Cmd) {
+	row := m.TreeState.GetSelectedRow()
+	if row == nil {
+		return m, nil
+	}
+	if err := m.Document.CanEditScalar(row.Node); err != nil {
+		m.SetError(err.Error())
+		return m, nil
+	}
+
+	value := row.Node.ScalarValue
+	if row.Node.ScalarType == model.ScalarNull {
+		value = ""
+	}
+	m.openEdit(row.Node, EditValue, value, "")
+	return m, nil
+}
+
+// startRename opens the inline input for the key of the selected node
+func (m *Model) startRename() (tea.Model, tea.Cmd) {
+	row := m.TreeState.GetSelectedRow()
+	if row == nil {
+		return m, nil
+	}
+	if err := m.Document.CanRename(row.Node); err != nil {
+		m.SetError(err.Error())
+		return m, nil
+	}
+	m.openEdit(row.Node, EditRename, row.Node.Key, "")
+	return m, nil
+}
+
+// startAdd opens the inline input for a new entry
+// With child set, the entry is added to the selected map or list; otherwise
+// (or when the selection is a scalar) it is added after the selection.
+func (m *Model) startAdd(child bool) (tea.Model, tea.Cmd) {
+	row := m.TreeState.GetSelectedRow()
+	if row == nil {
+		return m, nil
+	}
+
+	action, container := EditAddSibling, row.Node.Parent
+	if child && row.Node.IsExpandable() || container == nil {
+		action, container = EditAddChild, row.Node
+	}
+
+	placeholder := "value"
+	if container != nil && container.Kind == model.KindMap {
+		placeholder = "key: value"
+	}
+	m.openEdit(row.Node, action, "", placeholder)
+	return m, nil
+}
+
+// startDelete asks for confirmation before deleting the selected node
+func (m *Model) startDelete() (tea.Model, tea.Cmd) {
+	row := m.TreeState.GetSelectedRow()
+	if row == nil {
+		return m, nil
+	}
+	m.openEdit(row.Node, EditDelete, "", "")
+	m.EditInput.Blur()
+	return m, nil
+}
+
+// openEdit switches to edit mode for node
+func (m *Model) openEdit(node *model.Node, action EditAction, value, placeholder string) {
+	m.Mode = EditMode
+	m.EditNode = node
+	m.EditAction = action
+	m.EditInput.Placeholder = placeholder
+	m.EditInput.SetValue(value)
+	m.EditInput.CursorEnd()
+	m.EditInput.Focus()
+}
+
+// handleEditKey handles key input in edit mode
+func (m *Model) handleEditKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.EditAction == EditDelete {
+		// Any key but y cancels
+		node := m.EditNode
+		m.stopEdit()
+		if msg.String() == "y" {
+			m.restructure("delete", node.Path, func() (*model.Path, error) {
+				return m.Document.Delete(node)
+			})
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		m.stopEdit()
+		return m, nil
+
+	case "enter":
+		m.commitEdit()
+		return m, nil
+
+	case "ctrl+c":
+		return m, tea.Quit
+
+	default:
+		var cmd tea.Cmd
+		m.EditInput, cmd = m.EditInput.Update(msg)
+		return m, cmd
+	}
+}
+
+// stopEdit leaves edit mode without changes
+func (m *Model) stopEdit() {
+	m.Mode = TreeMode
+	m.EditNode = nil
+	m.EditInput.Blur()
+}
+
+// commitEdit applies the input, then saves
+// An invalid input keeps the input open so it can be corrected.
+func (m *Model) commitEdit() {
+	node, value := m.EditNode, m.EditInput.Value()
+
+	var selectPath *model.Path
+	var action string
+	var err error
+	switch m.EditAction {
+	case EditValue:
+		action = "set value"
+		err = m.Document.SetScalar(node, value)
+	case EditRename:
+		action = "rename"
+		selectPath, err = m.Document.Rename(node, value)
+	case EditAddChild:
+		action = "add"
+		selectPath, err = m.Document.AddChild(node, value)
+	case EditAddSibling:
+		action = "add"
+		selectPath, err = m.Document.AddSibling(node, value)
+	}
+	if err != nil {
+		m.SetError(err.Error())
+		return
+	}
+
+	changed := node.Path
+	if selectPath != nil {
+		changed = selectPath
+	}
+	m.stopEdit()
+	m.save(action, changed, selectPath)
+}
+
+// restructure applies a structural edit, then saves
+// The history records the change at changed, or at the path the edit
+// returns when nil.
+func (m *Model) restructure(action string, changed *model.Path, edit func() (*model.Path, error)) {
+	selectPath, err := edit()
+	if err != nil {
+		m.SetError(err.Error())
+		return
+	}
+	if changed == nil {
+		changed = selectPath
+	}
+	m.save(action, changed, selectPath)
+}
+
+// duplicateSelected inserts a copy of the selected list item after it
+func (m *Model) duplicateSelected() {
+	if row := m.TreeState.GetSelectedRow(); row != nil {
+		m.restructure("duplicate", nil, func() (*model.Path, error) {
+			return m.Document.Duplicate(row.Node)
+		})
+	}
+}
+
+// moveSelected moves the selected node among its siblings
+func (m *Model) moveSelected(delta int) {
+	action := "move down"
+	if delta < 0 {
+		action = "move up"
+	}
+	if row := m.TreeState.GetSelectedRow(); row != nil {
+		m.restructure(action, nil, func() (*model.Path, error) {
+			return m.Document.Move(row.Node, delta)
+		})
+	}
+}
+
+// save writes the edited document back, records the change at path
+// changed in the history and re-parses the document, then selects
+// selectPath (or keeps the selection when nil)
+// If saving fails the edits are dropped.
+func (m *Model) save(action string, changed, selectPath *model.Path) {
+	before := m.Document.Source()
+	data, err := m.Document.Encode()
+	if err == nil {
+		err = m.writeBack(data)
+	}
+	if err != nil {
+		if errors.Is(err, errFileChanged) {
+			m.reloadFile()
+		} else {
+			m.reload(m.Document.Reverted())
+		}
+		m.SetError(err.Error())
+		return
+	}
+
+	m.History.Push(history.Entry{
+		Action: action,
+		Path:   changed.String(),
+		Before: before,
+		After:  data,
+	})
+	m.reload(yamlparse.ParseBytesTolerant(data, m.Document.FilePath, m.Document.Format))
+	if selectPath != nil {
+		m.selectPath(selectPath)
+	}
+}
+
+// errFileChanged is returned by writeBack when the file changed on disk
+// since it was loaded, which writing it would undo
+var errFileChanged = errors.New("the file changed on disk: reloaded it, try again")
+
+// writeBack replaces the source with data and sets a notice saying where
+// it went
+// When the document is a Neovim buffer the buffer is replaced instead of
+// the file, and saving is left to the editor. A file that changed since it
+// was loaded is not written (see errFileChanged).
+func (m *Model) writeBack(data []byte) error {
+	if m.NvimClient.HasBuffer() {
+		if err := m.NvimClient.ReplaceBuffer(string(data)); err != nil {
+			return fmt.Errorf("could not update the Neovim buffer: %w", err)
+		}
+		m.SetNotice("buffer updated")
+		return nil
+	}
+
+	path := m.Document.FilePath
+	if stamp, err := statFile(path); err == nil && stamp != m.fileStamp {
+		return errFileChanged
+	}
+	if err := writeFile(path, data); err != nil {
+		return fmt.Errorf("could not save: %w", err)
+	}
+	// Don't reload our own write
+	if stamp, err := statFile(path); err == nil {
+		m.fileStamp = stamp
+	}
+	m.SetNotice("saved " + filepath.Base(path))
+	return nil
+}
+
+// reloadFile re-reads the source file after it changed on disk, recording
+// the change in the history
+func (m *Model) reloadFile() {
+	path := m.Document.FilePath
+	stamp, err := statFile(path)
+	if err != nil {
+		m.SetError("reload failed: " + err.Error())
+		return
+	}
+	doc, err := yamlparse.ParseFileTolerant(path, m.Document.Format)
+	if err != nil {
+		m.SetError("reload failed: " + err.Error())
+		return
+	}
+	m.fileStamp = stamp
+	m.recordReload("reload", doc)
+	m.reload(doc)
+	m.ReloadedAt = time.Now()
+}
+
+// selectPath selects the node at path, expanding its ancestors
+func (m *Model) selectPath(path *model.Path) {
+	if node := m.Document.NodeByPath(path); node != nil {
+		m.jumpToNode(node)
+	}
+}
+
+// writeFile replaces the contents of an existing file, keeping its mode
+func writeFile(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, info.Mode().Perm())
+}
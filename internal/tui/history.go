@@ -0,0 +1,202 @@
+package tui
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/uznog/yamlist/internal/history"
+	"github.com/uznog/yamlist/internal/yamlparse"
+)
+
+// recordReload adds a change of the source made outside yamlist (file or
+// buffer) to the history, before doc replaces the current document
+func (m *Model) recordReload(action string, doc *yamlparse.Document) {
+	m.History.Push(history.Entry{
+		Action: action,
+		Before: m.Document.Source(),
+		After:  doc.Source(),
+	})
+}
+
+// undo reverts the last change
+func (m *Model) undo() {
+	entry, ok := m.History.Undo()
+	if !ok {
+		m.SetNotice("nothing to undo")
+		return
+	}
+	if err := m.restore(entry.Before, entry.After, entry.Path); err != nil {
+		m.History.Redo()
+		m.restoreFailed(err)
+		return
+	}
+	m.SetNotice("undo " + describeEntry(entry))
+}
+
+// redo re-applies the last undone change
+func (m *Model) redo() {
+	entry, ok := m.History.Redo()
+	if !ok {
+		m.SetNotice("nothing to redo")
+		return
+	}
+	if err := m.restore(entry.After, entry.Before, entry.Path); err != nil {
+		m.History.Undo()
+		m.restoreFailed(err)
+		return
+	}
+	m.SetNotice("redo " + describeEntry(entry))
+}
+
+// restore writes data back as the source, re-parses it and selects the
+// node at path (if any)
+// The source must still read expect, the state the history is at, or the
+// changes made since would be lost.
+func (m *Model) restore(data, expect []byte, path string) error {
+	if m.Document.IsStdin() {
+		return errors.New("cannot change a document read from stdin")
+	}
+	if m.isDecoded() {
+		return errors.New("go back to the file (backspace) to undo")
+	}
+	if !bytes.Equal(m.Document.Source(), expect) {
+		return errors.New("the document changed outside of the history: not restoring over it")
+	}
+	if err := m.writeBack(data); err != nil {
+		return err
+	}
+
+	m.reload(yamlparse.ParseBytesTolerant(data, m.Document.FilePath, m.Document.Format))
+	if node := m.Document.FindByPath(path); path != "" && node != nil {
+		m.jumpToNode(node)
+	}
+	return nil
+}
+
+// restoreFailed reports a failed restore once the history position is back
+// where it was; a file changed on disk is reloaded so the change is kept
+func (m *Model) restoreFailed(err error) {
+	if errors.Is(err, errFileChanged) {
+		m.reloadFile()
+	}
+	m.SetError(err.Error())
+}
+
+// describeEntry returns a one-line description of a history entry
+func describeEntry(entry history.Entry) string {
+	if entry.Path == "" {
+		return entry.Action
+	}
+	return entry.Action + " " + entry.Path
+}
+
+// enterHistoryMode shows the history list with the current state selected
+func (m *Model) enterHistoryMode() (tea.Model, tea.Cmd) {
+	m.Mode = HistoryMode
+	m.HistoryIndex = m.History.Len() - m.History.Position()
+	return m, nil
+}
+
+// handleHistoryKey handles key input in history mode
+// Rows run from the newest state (0) to the original document (Len).
+func (m *Model) handleHistoryKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "j", "down":
+		if m.HistoryIndex < m.History.Len() {
+			m.HistoryIndex++
+		}
+	case "k", "up":
+		if m.HistoryIndex > 0 {
+			m.HistoryIndex--
+		}
+	case "g":
+		m.HistoryIndex = 0
+	case "G":
+		m.HistoryIndex = m.History.Len()
+
+	// Go to the selected state
+	case "enter":
+		m.gotoHistory(m.History.Len() - m.HistoryIndex)
+
+	case "u":
+		m.undo()
+		m.HistoryIndex = m.History.Len() - m.History.Position()
+	case "ctrl+r":
+		m.redo()
+		m.HistoryIndex = m.History.Len() - m.History.Position()
+
+	case "esc", "U", "q":
+		m.Mode = TreeMode
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// gotoHistory restores the state after the first pos history entries
+func (m *Model) gotoHistory(pos int) {
+	current := m.History.Position()
+	if pos == current {
+		return
+	}
+	expect, _ := m.History.State(current)
+	data, ok := m.History.Goto(pos)
+	if !ok {
+		return
+	}
+	if err := m.restore(data, expect, ""); err != nil {
+		m.History.Goto(current)
+		m.restoreFailed(err)
+		return
+	}
+	m.SetNotice("restored state " + intToString(pos) + " of " + intToString(m.History.Len()))
+}
+
+// renderHistoryPane renders the history list, newest first
+// Undone entries are dimmed; the current state is marked.
+func (m *Model) renderHistoryPane(height int) string {
+	entries := m.History.Entries()
+	total := len(entries) + 1 // Entries plus the original document
+
+	// Keep the selected row visible
+	start := 0
+	if m.HistoryIndex >= height {
+		start = m.HistoryIndex - height + 1
+	}
+
+	var lines []string
+	for i := start; i < total && len(lines) < height; i++ {
+		pos := len(entries) - i // State after the first pos entries
+
+		var text string
+		if pos == 0 {
+			text = "(original)"
+		} else {
+			entry := entries[pos-1]
+			text = entry.Time.Format("15:04:05") + "  " + describeEntry(entry)
+		}
+
+		marker := "  "
+		if pos == m.History.Position() {
+			marker = "> "
+		}
+		line := truncateOrPad(marker+text, m.Width)
+
+		switch {
+		case i == m.HistoryIndex:
+			line = m.Styles.SelectedRow.Render(line)
+		case pos > m.History.Position():
+			line = m.Styles.DimmedRow.Render(line)
+		default:
+			line = m.Styles.NormalRow.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", m.Width))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,12 +1,21 @@
 package tui
 
 import (
+	"strings"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/uznog/yamlist/internal/model"
 )
 
 // handleTreeKey handles key input in tree mode
 func (m *Model) handleTreeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.YankPending {
+		return m.handleYankKey(msg)
+	}
+	if m.ViewMode == TableView {
+		return m.handleTableKey(msg)
+	}
+
 	switch msg.String() {
 	// Navigation
 	case "j", "down":
@@ -24,8 +33,13 @@ func (m *Model) handleTreeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.expandSelected()
 		}
 	case "enter", " ":
-		if m.ViewMode == TreeView {
-			m.toggleExpand()
+		if m.ViewMode == TreeView && m.toggleExpand() {
+			break
+		}
+		// Multiline values open in the pager
+		if row := m.TreeState.GetSelectedRow(); row != nil && msg.String() == "enter" &&
+			row.Kind() == model.KindScalar && strings.Contains(row.ScalarValue(), "\n") {
+			m.openPager()
 		}
 
 	// Collapse/expand all
@@ -54,10 +68,90 @@ func (m *Model) handleTreeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "N":
 		m.prevMatch()
 
+	// Jump to the anchor an alias or inherited key refers to
+	case "&":
+		m.jumpToAnchor()
+
+	// Jump to the parse error
+	case "!":
+		m.jumpToParseError()
+
+	// Edit the selected value
+	case "e":
+		return m.startEdit()
+
+	// Structural editing
+	case "a":
+		return m.startAdd(true)
+	case "o":
+		return m.startAdd(false)
+	case "r":
+		return m.startRename()
+	case "d":
+		return m.startDelete()
+	case "D":
+		m.duplicateSelected()
+	case "J":
+		m.moveSelected(1)
+	case "K":
+		m.moveSelected(-1)
+
+	// Copy path, value or subtree (y then what to copy)
+	case "y":
+		m.YankPending = true
+
+	// Undo/redo
+	case "u":
+		m.undo()
+	case "ctrl+r":
+		m.redo()
+	case "U":
+		return m.enterHistoryMode()
+
+	// Preview pane
+	case "p":
+		m.togglePreview()
+	case "P":
+		m.movePreview()
+	case "+", "=":
+		m.resizePreview(1)
+	case "-":
+		m.resizePreview(-1)
+	case "ctrl+e":
+		m.scrollPreview(1)
+	case "ctrl+y":
+		m.scrollPreview(-1)
+	case "ctrl+f":
+		m.scrollPreview(m.previewPageSize())
+	case "ctrl+b":
+		m.scrollPreview(-m.previewPageSize())
+
+	// Full-screen view of the selected value
+	case "v":
+		m.openPager()
+
+	// Show the list of maps around the selection as a table
+	case "T":
+		m.openTable()
+
+	// Browse the decoded value, and go back
+	case "x":
+		m.openDecoded()
+	case "backspace":
+		m.closeDecoded()
+
+	// Toggle inline comments
+	case "c":
+		m.RowRenderer.ShowComments = !m.RowRenderer.ShowComments
+
 	// Search
 	case "/":
 		return m.enterSearchMode()
 
+	// Path query
+	case ":", "ctrl+g":
+		return m.enterQueryMode()
+
 	// Toggle view mode (tree <-> flat)
 	case "tab":
 		return m.toggleViewMode()
@@ -91,6 +185,10 @@ func (m *Model) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.prevMatch()
 		return m, nil
 
+	case "tab":
+		m.cycleSearchScope()
+		return m, nil
+
 	case "ctrl+c":
 		return m, tea.Quit
 
@@ -106,6 +204,10 @@ func (m *Model) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 // enterSearchMode switches to search mode
 func (m *Model) enterSearchMode() (tea.Model, tea.Cmd) {
 	m.Mode = SearchMode
+	// A path query's results are replaced by the new search
+	if m.PathQuery != "" {
+		m.clearSearch()
+	}
 	// If there's an existing search, keep it and allow editing
 	// Only reset if starting fresh (no active search)
 	if !m.SearchActive {
@@ -156,7 +258,10 @@ func (m *Model) exitSearchMode(keep bool) (tea.Model, tea.Cmd) {
 // clearSearch clears the search state and removes all highlighting
 func (m *Model) clearSearch() {
 	m.SearchInput.Reset()
+	m.PathQuery = ""
+	m.SearchErr = ""
 	m.SearchMatches = nil
+	m.searchHits = nil
 	m.SearchIndex = 0
 	m.SearchActive = false
 	m.updateRowDimming()
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/uznog/yamlist/internal/search"
 )
 
 const (
@@ -16,31 +17,70 @@ const (
 
 // updateLayout recalculates pane dimensions
 func (m *Model) updateLayout() {
-	// Full-width tree (no preview pane)
 	m.TreeWidth = m.Width
 	m.PreviewWidth = 0
+	if !m.previewVisible() {
+		return
+	}
+
+	if m.PreviewBottom {
+		m.PreviewWidth = m.Width
+	} else {
+		m.PreviewWidth = int(float64(m.Width) * m.PreviewRatio)
+		m.TreeWidth = m.Width - m.PreviewWidth - separatorWidth
+	}
 }
 
 // renderLayout renders the complete layout
 func (m *Model) renderLayout() string {
-	// Calculate content height
-	contentHeight := m.Height - StatusBarHeight
 	// Show search bar when in search mode OR when search is active (confirmed with Enter)
 	showSearchBar := m.Mode == SearchMode || m.SearchActive
-	if showSearchBar {
-		contentHeight -= SearchBarHeight
+	showEditBar := m.Mode == EditMode
+	showQueryBar := m.Mode == QueryMode
+	contentHeight := m.contentHeight()
+
+	// Render the tree with the preview beside or below it, or the history list
+	var mainContent string
+	switch {
+	case m.Mode == HistoryMode:
+		mainContent = m.renderHistoryPane(contentHeight)
+	case m.Mode == PagerMode:
+		mainContent = m.renderPager(contentHeight)
+	case m.ViewMode == TableView:
+		mainContent = m.renderTable(contentHeight)
+	case !m.previewVisible():
+		mainContent = m.renderTreePane(contentHeight)
+	case m.PreviewBottom:
+		separator := m.Styles.TreeLine.Render(strings.Repeat("─", m.Width))
+		mainContent = m.renderTreePane(m.treeHeight()) + "\n" + separator + "\n" +
+			m.renderPreviewPane(m.previewHeight())
+	default:
+		mainContent = lipgloss.JoinHorizontal(lipgloss.Top,
+			m.renderTreePane(contentHeight),
+			m.renderSeparator(contentHeight),
+			m.renderPreviewPane(contentHeight),
+		)
 	}
 
-	// Render tree pane (full width, no preview)
-	mainContent := m.renderTreePane(contentHeight)
-
 	// Build final layout
 	var b strings.Builder
 	b.WriteString(mainContent)
 	b.WriteString("\n")
 
-	// Search bar (if in search mode or search is active)
-	if showSearchBar {
+	// Edit and query bars take the place of the search bar while open;
+	// the pager has its own search
+	if m.Mode == PagerMode {
+		if m.Pager.Searching || m.Pager.Query != "" {
+			b.WriteString(m.renderPagerSearchBar())
+			b.WriteString("\n")
+		}
+	} else if showEditBar {
+		b.WriteString(m.renderEditBar())
+		b.WriteString("\n")
+	} else if showQueryBar {
+		b.WriteString(m.renderQueryBar())
+		b.WriteString("\n")
+	} else if showSearchBar {
 		b.WriteString(m.renderSearchBar())
 		b.WriteString("\n")
 	}
@@ -79,30 +119,33 @@ func (m *Model) renderTreePane(height int) string {
 }
 
 // renderPreviewPane renders the preview pane
+// The preview of the selected node (see updatePreview) is rendered in full
+// (up to the configured line limit) and scrolled.
 func (m *Model) renderPreviewPane(height int) string {
-	// Get selected node
-	var selectedNode *Model
-	row := m.TreeState.GetSelectedRow()
-	var node = m.Document.Root
-	if row != nil {
-		node = row.Node
+	node, decoded := m.previewTarget(), m.previewDecoded
+	if node != m.previewNode {
+		decoded = nil
 	}
 
-	// Render preview
-	content := m.PreviewRenderer.RenderPreview(node, m.PreviewWidth, height)
-
-	// Split into lines and pad
+	content := m.PreviewRenderer.RenderPreview(node, decoded, m.PreviewWidth, m.PreviewRenderer.MaxLines)
 	lines := strings.Split(content, "\n")
-	var result []string
-	for i := 0; i < height; i++ {
+	m.PreviewScroll = min(m.PreviewScroll, max(len(lines)-height, 0))
+	lines = lines[m.PreviewScroll:]
+
+	result := make([]string, height)
+	for i := range result {
 		if i < len(lines) {
-			result = append(result, truncateOrPad(lines[i], m.PreviewWidth))
+			result[i] = truncateOrPad(lines[i], m.PreviewWidth)
 		} else {
-			result = append(result, strings.Repeat(" ", m.PreviewWidth))
+			result[i] = strings.Repeat(" ", m.PreviewWidth)
 		}
 	}
 
-	_ = selectedNode // unused, keeping for potential future use
+	// Show that there is more to scroll to
+	if more := len(lines) - height; more > 0 && height > 1 {
+		result[height-1] = truncateOrPad(m.Styles.ChildCount.Render(
+			"↓ "+intToString(more)+" more lines (ctrl+e/ctrl+f)"), m.PreviewWidth)
+	}
 	return strings.Join(result, "\n")
 }
 
@@ -122,25 +165,76 @@ func (m *Model) renderStatusBar() string {
 	var modeStr string
 	if m.Mode == SearchMode {
 		modeStr = "SEARCH"
+	} else if m.Mode == EditMode {
+		modeStr = "EDIT"
+	} else if m.Mode == HistoryMode {
+		modeStr = "HISTORY"
+	} else if m.Mode == QueryMode {
+		modeStr = "QUERY"
+	} else if m.Mode == PagerMode {
+		modeStr = "PAGER"
 	} else if m.ViewMode == FlatView {
 		modeStr = "FLAT"
+	} else if m.ViewMode == TableView {
+		modeStr = "TABLE"
 	} else {
 		modeStr = "TREE"
 	}
 
 	mode := m.Styles.StatusMode.Render(modeStr)
 
+	// Parse error badge
+	if parseErr := m.Document.ParseError; parseErr != nil {
+		location := intToString(parseErr.Line)
+		if parseErr.Column > 0 {
+			location += ":" + intToString(parseErr.Column)
+		}
+		mode += " " + m.Styles.StatusError.Render("ERR "+location)
+	}
+
+	// Reload indicator
+	if !m.ReloadedAt.IsZero() {
+		mode += " " + m.Styles.StatusInfo.Render("reloaded "+m.ReloadedAt.Format("15:04:05"))
+	}
+
 	// Help hint - updated to include Tab
-	help := m.Styles.StatusInfo.Render("j/k:nav tab:view h/l:fold n/N:match /:search q:quit")
+	help := m.Styles.StatusInfo.Render("j/k:nav tab:view h/l:fold n/N:match /:search e:edit q:quit")
+	if m.YankPending {
+		help = m.Styles.StatusInfo.Render("copy " + YankHelp)
+	} else if m.Mode == HistoryMode {
+		help = m.Styles.StatusInfo.Render("j/k:nav enter:restore u/ctrl+r:undo/redo esc:close")
+	} else if m.Mode == QueryMode {
+		help = m.Styles.StatusInfo.Render("enter:go esc:cancel")
+	} else if m.Mode == PagerMode && m.Pager.Searching {
+		help = m.Styles.StatusInfo.Render("enter:confirm esc:clear")
+	} else if m.Mode == PagerMode {
+		help = m.Styles.StatusInfo.Render("j/k:scroll w:wrap #:numbers /:search q:close")
+	} else if m.ViewMode == TableView {
+		help = m.Styles.StatusInfo.Render("h/l:column s:sort -/+:hide/show enter:open esc:back")
+	} else if m.Mode == SearchMode {
+		help = m.Styles.StatusInfo.Render("tab:scope enter:confirm esc:clear")
+	} else if m.Mode == EditMode {
+		if m.EditAction == EditDelete {
+			help = m.Styles.StatusInfo.Render("y:delete n:cancel")
+		} else {
+			help = m.Styles.StatusInfo.Render("enter:save esc:cancel")
+		}
+	}
 
 	// Path section - show full path of selected node
 	var pathStr string
 	if m.Error != "" {
 		pathStr = m.Styles.MatchHighlight.Render(m.Error)
+	} else if m.Notice != "" {
+		pathStr = m.Notice
 	} else {
 		row := m.TreeState.GetSelectedRow()
-		if row != nil {
-			pathStr = row.PathString()
+		if m.ViewMode == TableView {
+			pathStr = m.decodedPrefix() + m.tableSelection().Path.String()
+		} else if row != nil && row.Node.IsError {
+			pathStr = m.Document.ParseError.Error()
+		} else if row != nil {
+			pathStr = m.decodedPrefix() + row.PathString()
 		}
 	}
 
@@ -176,30 +270,64 @@ func (m *Model) renderSearchBar() string {
 
 	// In tree mode with active search, show the term without cursor (non-editable display)
 	var input string
-	if m.Mode == SearchMode {
+	if m.PathQuery != "" {
+		prompt = m.Styles.SearchPrompt.Render(":")
+		input = m.Styles.SearchPrompt.Render(m.PathQuery)
+	} else if m.Mode == SearchMode {
 		input = m.SearchInput.View()
 	} else {
 		// Just show the search term text (no cursor/editing)
 		input = m.Styles.SearchPrompt.Render(m.SearchInput.Value())
 	}
 
-	// Match count
+	// Match count, or why the search does not compile
 	matchInfo := ""
-	if len(m.SearchMatches) > 0 {
+	if m.SearchErr != "" && m.PathQuery == "" {
+		matchInfo = m.Styles.MatchCount.Render(m.SearchErr)
+	} else if len(m.SearchMatches) > 0 {
 		matchInfo = m.Styles.MatchCount.Render(
 			formatMatchInfo(m.SearchIndex+1, len(m.SearchMatches)),
 		)
 	}
 
+	// Scope of plain search text, unless searching everything
+	if m.SearchScope != search.ScopeAll && m.PathQuery == "" {
+		matchInfo = m.Styles.MatchCount.Render("["+m.SearchScope.String()+"]") + " " + matchInfo
+	}
+
 	return prompt + input + " " + matchInfo
 }
 
+// renderQueryBar renders the path query prompt
+func (m *Model) renderQueryBar() string {
+	return m.Styles.SearchPrompt.Render(":") + m.QueryInput.View()
+}
+
+// renderEditBar renders the input (or confirmation) of edit mode
+func (m *Model) renderEditBar() string {
+	var prompt string
+	switch m.EditAction {
+	case EditValue:
+		prompt = m.EditNode.DisplayKey() + ": "
+	case EditRename:
+		prompt = "rename " + m.EditNode.DisplayKey() + " to: "
+	case EditAddChild:
+		prompt = "add to " + m.EditNode.Path.DisplayString() + ": "
+	case EditAddSibling:
+		prompt = "add after " + m.EditNode.DisplayKey() + ": "
+	case EditDelete:
+		return m.Styles.SearchPrompt.Render("delete " + m.EditNode.Path.DisplayString() + "? (y/n)")
+	}
+	return m.Styles.SearchPrompt.Render(prompt) + m.EditInput.View()
+}
+
 // truncateOrPad ensures a string is exactly the given width
 func truncateOrPad(s string, width int) string {
 	visWidth := lipgloss.Width(s)
 	if visWidth > width {
-		// Truncate - this is approximate due to ANSI codes
-		return s[:width]
+		// Cut by display width, keeping ANSI styling intact
+		s = lipgloss.NewStyle().MaxWidth(width).Render(s)
+		visWidth = lipgloss.Width(s)
 	}
 	if visWidth < width {
 		return s + strings.Repeat(" ", width-visWidth)
@@ -276,12 +404,29 @@ func truncatePathMiddle(path string, maxWidth int) string {
 }
 
 // splitPathSegments splits a path into segments, keeping delimiters attached
-// e.g., "a.b[0].c" → ["a", ".b", "[0]", ".c"]
+// e.g., "a.b[0].c" → ["a", ".b", "[0]", ".c"]; quoted keys are one segment
 func splitPathSegments(path string) []string {
 	var segments []string
 	var current strings.Builder
 
+	inQuote, escaped := false, false
 	for i, r := range path {
+		if inQuote {
+			current.WriteRune(r)
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == '"':
+				inQuote = false
+			}
+			continue
+		}
+		if r == '"' {
+			inQuote = true
+		}
+
 		if r == '.' || r == '[' {
 			if current.Len() > 0 {
 				segments = append(segments, current.String())
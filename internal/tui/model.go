@@ -1,11 +1,16 @@
 package tui
 
 import (
+	"time"
+
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/uznog/yamlist/internal/decode"
+	"github.com/uznog/yamlist/internal/history"
 	"github.com/uznog/yamlist/internal/model"
 	"github.com/uznog/yamlist/internal/nvim"
 	"github.com/uznog/yamlist/internal/render"
+	"github.com/uznog/yamlist/internal/search"
 	"github.com/uznog/yamlist/internal/yamlparse"
 )
 
@@ -15,14 +20,30 @@ type Mode int
 const (
 	TreeMode Mode = iota
 	SearchMode
+	EditMode
+	HistoryMode
+	QueryMode
+	PagerMode
+)
+
+// EditAction is what the input of edit mode is for
+type EditAction int
+
+const (
+	EditValue      EditAction = iota // Change a scalar value
+	EditRename                       // Rename a map key
+	EditAddChild                     // Add to a map or list
+	EditAddSibling                   // Add after a node
+	EditDelete                       // Confirm deleting a node
 )
 
-// ViewMode represents the current view mode (tree or flat)
+// ViewMode represents the current view mode (tree, flat or table)
 type ViewMode int
 
 const (
 	TreeView ViewMode = iota
 	FlatView
+	TableView
 )
 
 // Config holds configuration options
@@ -30,6 +51,12 @@ type Config struct {
 	UseIcons        bool
 	MaxPreviewLines int
 	Theme           string // "auto", "dark", "mono"
+	ShowComments    bool
+	Watch           bool   // Reload when the source file changes
+	Preview         string // Preview pane at startup: "off", "right", "bottom"
+
+	// LabelKeys are the keys that label list items, in order of preference
+	LabelKeys []string
 }
 
 // DefaultConfig returns the default configuration
@@ -38,6 +65,10 @@ func DefaultConfig() *Config {
 		UseIcons:        true,
 		MaxPreviewLines: 200,
 		Theme:           "auto",
+		ShowComments:    true,
+		Watch:           true,
+		Preview:         "off",
+		LabelKeys:       model.DefaultLabelKeys,
 	}
 }
 
@@ -59,7 +90,36 @@ type Model struct {
 	SearchInput   textinput.Model
 	SearchMatches []*model.PathEntry
 	SearchIndex   int
-	SearchActive  bool // True when search results should be highlighted/dimmed
+	SearchActive  bool         // True when search results should be highlighted/dimmed
+	SearchScope   search.Scope // What plain search text matches (tab in search mode)
+	SearchErr     string       // Why the search input does not compile
+	searchHits    map[*model.Node]search.Hit
+
+	// Path query state; when PathQuery is set the search matches are its results
+	QueryInput textinput.Model
+	PathQuery  string
+
+	// Table is the table view of a list of maps (nil unless in TableView)
+	Table *TableState
+
+	// Pager is the full-screen view of a value (nil when closed)
+	Pager *Pager
+
+	// DocStack holds the documents the shown one was decoded from, the
+	// source document first
+	DocStack []docFrame
+
+	// Edit state
+	EditInput  textinput.Model
+	EditNode   *model.Node // Node being edited
+	EditAction EditAction
+
+	// YankPending is true after y, until the key choosing what to copy
+	YankPending bool
+
+	// Undo/redo history
+	History      *history.History
+	HistoryIndex int // Selected row of the history view (0 = newest)
 
 	// Rendering
 	RowRenderer     *render.RowRenderer
@@ -73,12 +133,33 @@ type Model struct {
 	TreeWidth     int
 	PreviewWidth  int
 
+	// Preview pane (p toggles, P moves, +/- resize)
+	ShowPreview   bool
+	PreviewBottom bool    // Below the tree instead of to its right
+	PreviewRatio  float64 // Share of the width (or height) for the preview
+	PreviewScroll int     // First line of the preview shown
+	previewNode   *model.Node
+
+	// previewDecoded is the decoded form of previewValue, the string value
+	// of previewNode (nil if it has none)
+	previewValue   string
+	previewDecoded *decode.Decoded
+
 	// Config
 	Config *Config
 
 	// Error message (if any)
 	Error string
 
+	// Notice is an informational message (if any), e.g. after saving
+	Notice string
+
+	// ReloadedAt is when the source file was last reloaded (zero if never)
+	ReloadedAt time.Time
+
+	// fileStamp is the version of the source file currently shown
+	fileStamp fileStamp
+
 	// NvimClient for cursor sync with Neovim (nil if standalone)
 	NvimClient *nvim.Client
 }
@@ -104,45 +185,106 @@ func NewModel(doc *yamlparse.Document, config *Config, nvimClient *nvim.Client)
 	ti.Placeholder = "Search..."
 	ti.CharLimit = 256
 
-	// Create tree state
+	// Initialize path query input
+	qi := textinput.New()
+	qi.Prompt = ""
+	qi.Placeholder = "$..name"
+	qi.CharLimit = 1024
+
+	// Initialize edit input
+	ei := textinput.New()
+	ei.Prompt = ""
+	ei.CharLimit = 4096
+
+	// Label list items, then create tree state
+	model.LabelItems(doc.Root, config.LabelKeys)
 	treeState := model.NewTreeState(doc.Root)
 
 	// Expand all nodes by default
 	treeState.ExpandAll()
 
+	rowRenderer := render.NewRowRenderer(icons, styles)
+	rowRenderer.ShowComments = config.ShowComments
+
 	m := &Model{
 		Document:        doc,
 		TreeState:       treeState,
 		Mode:            TreeMode,
 		ViewMode:        TreeView,
 		SearchInput:     ti,
+		QueryInput:      qi,
+		EditInput:       ei,
+		History:         history.New(history.DefaultMaxEntries),
 		SearchMatches:   make([]*model.PathEntry, 0),
 		SearchIndex:     0,
-		RowRenderer:     render.NewRowRenderer(icons, styles),
+		RowRenderer:     rowRenderer,
 		PreviewRenderer: render.NewPreviewRenderer(styles, config.MaxPreviewLines),
 		Icons:           icons,
 		Styles:          styles,
 		Config:          config,
 		NvimClient:      nvimClient,
+		ShowPreview:     config.Preview == "right" || config.Preview == "bottom",
+		PreviewBottom:   config.Preview == "bottom",
+		PreviewRatio:    DefaultPreviewRatio,
 	}
 
 	// Initialize visible rows
 	m.computeVisibleRows()
+	m.updatePreview()
 
 	return m
 }
 
 // Init implements tea.Model
 func (m *Model) Init() tea.Cmd {
-	return nil
+	m.notifyDiagnostic()
+
+	cmds := []tea.Cmd{m.listenNvim()}
+	if !m.Document.IsStdin() && m.Document.FilePath != "" {
+		// Edits check the stamp before writing, watched or not
+		if stamp, err := statFile(m.Document.FilePath); err == nil {
+			m.fileStamp = stamp
+			if m.canWatch() {
+				cmds = append(cmds, m.watchFile())
+			}
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// notifyDiagnostic sends the parse error (or its absence) to Neovim if connected
+func (m *Model) notifyDiagnostic() {
+	if m.NvimClient == nil {
+		return
+	}
+
+	if parseErr := m.Document.ParseError; parseErr != nil {
+		pos := model.Position{Line: parseErr.Line, Column: parseErr.Column}
+		m.NvimClient.SendDiagnostic(parseErr.Line, m.Document.ByteColumn(pos), parseErr.Message)
+	} else {
+		m.NvimClient.SendDiagnostic(0, 0, "")
+	}
 }
 
 // Update implements tea.Model
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	m.updatePreview()
+	return next, cmd
+}
+
+// update applies a message to the model
+func (m *Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		return m.handleKeyMsg(msg)
 
+	case fileCheckMsg:
+		return m.handleFileCheck(msg)
+
+	case nvimMsg:
+		return m.handleNvimMsg(msg)
+
 	case tea.WindowSizeMsg:
 		m.Width = msg.Width
 		m.Height = msg.Height
@@ -157,6 +299,11 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.updateSearchMatches()
 		return m, cmd
 	}
+	if m.Mode == PagerMode && m.Pager.Searching {
+		var cmd tea.Cmd
+		m.Pager.SearchInput, cmd = m.Pager.SearchInput.Update(msg)
+		return m, cmd
+	}
 
 	return m, nil
 }
@@ -172,17 +319,31 @@ func (m *Model) View() string {
 
 // handleKeyMsg handles keyboard input
 func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Errors and notices are shown until the next key press
+	m.ClearError()
+	m.Notice = ""
+
 	// Global keys
 	switch msg.String() {
 	case "q", "ctrl+c":
-		if m.Mode == TreeMode {
+		// After y, q copies the yq path and ctrl+c cancels
+		if m.Mode == TreeMode && !m.YankPending {
 			return m, tea.Quit
 		}
 	}
 
 	// Mode-specific handling
-	if m.Mode == SearchMode {
+	switch m.Mode {
+	case SearchMode:
 		return m.handleSearchKey(msg)
+	case EditMode:
+		return m.handleEditKey(msg)
+	case HistoryMode:
+		return m.handleHistoryKey(msg)
+	case QueryMode:
+		return m.handleQueryKey(msg)
+	case PagerMode:
+		return m.handlePagerKey(msg)
 	}
 
 	return m.handleTreeKey(msg)
@@ -193,6 +354,11 @@ func (m *Model) SetError(err string) {
 	m.Error = err
 }
 
+// SetNotice sets an informational message
+func (m *Model) SetNotice(notice string) {
+	m.Notice = notice
+}
+
 // ClearError clears any error message
 func (m *Model) ClearError() {
 	m.Error = ""
@@ -0,0 +1,453 @@
+package tui
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/uznog/yamlist/internal/decode"
+	"github.com/uznog/yamlist/internal/model"
+	"github.com/uznog/yamlist/internal/render"
+)
+
+const (
+	// pagerTabWidth is the number of spaces a tab is shown as in the pager
+	pagerTabWidth = 4
+
+	// pagerHScrollStep is how far h and l scroll unwrapped lines
+	pagerHScrollStep = 8
+
+	// pagerHeaderHeight is the height of the pager's title line
+	pagerHeaderHeight = 1
+)
+
+// Pager is the state of the full-screen view of a scalar value
+type Pager struct {
+	Node   *model.Node
+	Lines  []string
+	Syntax render.Syntax
+	kinds  [][]render.TokenKind
+
+	// Decoded is the value with its encodings undone (nil if none were
+	// found); x switches between it and the raw value
+	Decoded     *decode.Decoded
+	ShowDecoded bool
+
+	Scroll      int  // First screen line shown
+	HScroll     int  // First column shown when not wrapping
+	Wrap        bool // Soft-wrap long lines
+	LineNumbers bool
+
+	// In-value search
+	SearchInput textinput.Model
+	Searching   bool // The search prompt is open
+	Query       string
+	Matches     []pagerMatch
+	MatchIndex  int
+}
+
+// pagerMatch is a search match in the pager
+type pagerMatch struct {
+	Line int
+	render.Span
+}
+
+// openPager shows the selected scalar in the pager
+func (m *Model) openPager() {
+	row := m.TreeState.GetSelectedRow()
+	if row == nil || row.Kind() != model.KindScalar || row.Node.IsError {
+		m.SetNotice("only values can be opened in the pager")
+		return
+	}
+
+	si := textinput.New()
+	si.Prompt = ""
+	si.CharLimit = 256
+
+	m.Pager = &Pager{
+		Wrap:        true,
+		LineNumbers: true,
+		SearchInput: si,
+	}
+	m.setPagerNode(row.Node)
+	m.Mode = PagerMode
+}
+
+// setPagerNode loads the value of node into the pager
+func (m *Model) setPagerNode(node *model.Node) {
+	p := m.Pager
+	p.Node = node
+	p.Decoded, _ = decode.Decode(node.ScalarValue)
+	p.ShowDecoded = p.ShowDecoded && p.Decoded != nil
+	m.loadPagerLines()
+}
+
+// loadPagerLines splits the raw or decoded value into lines and
+// highlights them
+func (m *Model) loadPagerLines() {
+	p := m.Pager
+	text := strings.TrimSuffix(p.Node.ScalarValue, "\n")
+	p.Syntax = render.DetectSyntax(text)
+	lines := strings.Split(text, "\n")
+	if p.ShowDecoded {
+		p.Syntax = render.DecodedSyntax(p.Decoded)
+		lines = p.Decoded.Lines()
+	}
+
+	tab := strings.Repeat(" ", pagerTabWidth)
+	p.Lines = make([]string, len(lines))
+	p.kinds = make([][]render.TokenKind, len(lines))
+	for i, line := range lines {
+		p.Lines[i] = strings.ReplaceAll(line, "\t", tab)
+		p.kinds[i] = render.Tokenize(p.Syntax, p.Lines[i])
+	}
+	m.findInPager(p.Query)
+}
+
+// togglePagerDecoded switches between the raw and the decoded value
+func (m *Model) togglePagerDecoded() {
+	p := m.Pager
+	if p.Decoded == nil {
+		m.SetNotice("no base64, JSON, YAML or PEM found in the value")
+		return
+	}
+	p.ShowDecoded = !p.ShowDecoded
+	p.Scroll, p.HScroll = 0, 0
+	m.loadPagerLines()
+}
+
+// refreshPager reloads the pager after the document changed
+// The value at the same path is shown; the pager closes if it is gone.
+func (m *Model) refreshPager() {
+	node := m.Document.FindByPath(m.Pager.Node.Path.String())
+	if node == nil || node.Kind != model.KindScalar {
+		m.closePager()
+		m.SetNotice("the value shown in the pager is gone")
+		return
+	}
+	m.setPagerNode(node)
+}
+
+// closePager returns to the tree
+func (m *Model) closePager() {
+	m.Mode = TreeMode
+	m.Pager = nil
+}
+
+// handlePagerKey handles key input in pager mode
+func (m *Model) handlePagerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.Pager
+	if p.Searching {
+		return m.handlePagerSearchKey(msg)
+	}
+
+	switch msg.String() {
+	case "j", "down":
+		m.scrollPager(1)
+	case "k", "up":
+		m.scrollPager(-1)
+	case "ctrl+d":
+		m.scrollPager(m.pagerHeight() / 2)
+	case "ctrl+u":
+		m.scrollPager(-m.pagerHeight() / 2)
+	case " ", "pgdown", "ctrl+f":
+		m.scrollPager(max(m.pagerHeight()-1, 1))
+	case "b", "pgup", "ctrl+b":
+		m.scrollPager(-max(m.pagerHeight()-1, 1))
+	case "g", "home":
+		p.Scroll = 0
+	case "G", "end":
+		m.scrollPager(len(m.pagerScreenLines()))
+	case "h", "left":
+		p.HScroll = max(p.HScroll-pagerHScrollStep, 0)
+	case "l", "right":
+		if !p.Wrap {
+			p.HScroll += pagerHScrollStep
+		}
+	case "0":
+		p.HScroll = 0
+
+	// Wrapping keeps the top line in place
+	case "w":
+		top := m.pagerTopLine()
+		p.Wrap = !p.Wrap
+		p.HScroll = 0
+		m.scrollPagerToLine(top)
+	case "#":
+		top := m.pagerTopLine()
+		p.LineNumbers = !p.LineNumbers
+		m.scrollPagerToLine(top)
+	case "x":
+		m.togglePagerDecoded()
+
+	// Search
+	case "/":
+		p.Searching = true
+		p.SearchInput.SetValue(p.Query)
+		p.SearchInput.CursorEnd()
+		p.SearchInput.Focus()
+	case "n":
+		m.nextPagerMatch(1)
+	case "N":
+		m.nextPagerMatch(-1)
+
+	case "esc":
+		if p.Query != "" {
+			m.findInPager("")
+		} else {
+			m.closePager()
+		}
+	case "q", "v":
+		m.closePager()
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// handlePagerSearchKey handles key input in the pager's search prompt
+func (m *Model) handlePagerSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.Pager
+	switch msg.String() {
+	case "esc":
+		p.Searching = false
+		p.SearchInput.Blur()
+		m.findInPager("")
+	case "enter":
+		p.Searching = false
+		p.SearchInput.Blur()
+		if p.Query != "" && len(p.Matches) == 0 {
+			m.SetNotice("no match for " + p.Query)
+		}
+	case "ctrl+c":
+		return m, tea.Quit
+	default:
+		var cmd tea.Cmd
+		p.SearchInput, cmd = p.SearchInput.Update(msg)
+		m.findInPager(p.SearchInput.Value())
+		return m, cmd
+	}
+	return m, nil
+}
+
+// findInPager searches the value for query and shows the first match at
+// or after the top of the screen
+// The search ignores case unless query contains an upper case letter.
+func (m *Model) findInPager(query string) {
+	p := m.Pager
+	p.Query = query
+	p.Matches = nil
+	p.MatchIndex = 0
+	if query == "" {
+		return
+	}
+
+	ignoreCase := !strings.ContainsFunc(query, unicode.IsUpper)
+	needle := []rune(query)
+	if ignoreCase {
+		needle = []rune(strings.ToLower(query))
+	}
+	for i, line := range p.Lines {
+		if ignoreCase {
+			line = strings.ToLower(line)
+		}
+		for _, start := range indexAll([]rune(line), needle) {
+			p.Matches = append(p.Matches, pagerMatch{Line: i, Span: render.Span{Start: start, End: start + len(needle)}})
+		}
+	}
+	if len(p.Matches) == 0 {
+		return
+	}
+
+	top := m.pagerTopLine()
+	for i, match := range p.Matches {
+		if match.Line >= top {
+			p.MatchIndex = i
+			break
+		}
+	}
+	m.showPagerMatch()
+}
+
+// indexAll returns the start of every non-overlapping occurrence of needle
+func indexAll(haystack, needle []rune) []int {
+	var starts []int
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		if string(haystack[i:i+len(needle)]) == string(needle) {
+			starts = append(starts, i)
+			i += len(needle) - 1
+		}
+	}
+	return starts
+}
+
+// nextPagerMatch moves to the next (dir 1) or previous (dir -1) match
+func (m *Model) nextPagerMatch(dir int) {
+	p := m.Pager
+	if len(p.Matches) == 0 {
+		if p.Query != "" {
+			m.SetNotice("no match for " + p.Query)
+		}
+		return
+	}
+	p.MatchIndex = (p.MatchIndex + dir + len(p.Matches)) % len(p.Matches)
+	m.showPagerMatch()
+}
+
+// showPagerMatch scrolls the current match into view
+func (m *Model) showPagerMatch() {
+	p := m.Pager
+	match := p.Matches[p.MatchIndex]
+
+	screen := m.pagerScreenLines()
+	for i, line := range screen {
+		if line.Source == match.Line && match.Start >= line.Start && (match.Start < line.End || line.End == line.Start) {
+			if i < p.Scroll || i >= p.Scroll+m.pagerHeight() {
+				p.Scroll = i - m.pagerHeight()/2
+				m.scrollPager(0)
+			}
+			break
+		}
+	}
+
+	if !p.Wrap {
+		width := m.pagerTextWidth()
+		if match.Start < p.HScroll || match.End > p.HScroll+width {
+			p.HScroll = max(match.Start-width/2, 0)
+		}
+	}
+}
+
+// scrollPager scrolls the pager by n screen lines (up if negative)
+func (m *Model) scrollPager(n int) {
+	p := m.Pager
+	last := max(len(m.pagerScreenLines())-m.pagerHeight(), 0)
+	p.Scroll = min(max(p.Scroll+n, 0), last)
+}
+
+// scrollPagerToLine scrolls so that source line line is at the top
+func (m *Model) scrollPagerToLine(line int) {
+	for i, screen := range m.pagerScreenLines() {
+		if screen.Source == line {
+			m.Pager.Scroll = i
+			break
+		}
+	}
+	m.scrollPager(0)
+}
+
+// pagerTopLine returns the source line at the top of the pager
+func (m *Model) pagerTopLine() int {
+	screen := m.pagerScreenLines()
+	if len(screen) == 0 {
+		return 0
+	}
+	return screen[min(m.Pager.Scroll, len(screen)-1)].Source
+}
+
+// pagerScreenLines returns the screen lines of the value
+func (m *Model) pagerScreenLines() []render.ScreenLine {
+	width := 0
+	if m.Pager.Wrap {
+		width = m.pagerTextWidth()
+	}
+	return render.WrapLines(m.Pager.Lines, width)
+}
+
+// pagerGutterWidth returns the width of the line number column, or 0
+func (m *Model) pagerGutterWidth() int {
+	if !m.Pager.LineNumbers {
+		return 0
+	}
+	return len(intToString(len(m.Pager.Lines))) + 1
+}
+
+// pagerTextWidth returns the width left for the text of the value
+func (m *Model) pagerTextWidth() int {
+	return max(m.Width-m.pagerGutterWidth(), 1)
+}
+
+// pagerHeight returns the number of text lines on screen
+func (m *Model) pagerHeight() int {
+	return max(m.contentHeight()-pagerHeaderHeight, 1)
+}
+
+// renderPager renders the title line and the visible part of the value
+func (m *Model) renderPager(height int) string {
+	p := m.Pager
+
+	title := p.Node.Path.DisplayString() + "  " + p.Syntax.String() + " · " +
+		intToString(len(p.Lines)) + " lines"
+	if !p.Wrap {
+		title += " · nowrap"
+	}
+	if p.ShowDecoded {
+		title += " · decoded " + p.Decoded.Label()
+	} else if p.Decoded != nil {
+		title += " · x: decode " + p.Decoded.Label()
+	}
+	lines := []string{truncateOrPad(m.Styles.PreviewPath.Render(title), m.Width)}
+
+	// Matches by source line
+	matches := make(map[int][]render.Span)
+	for _, match := range p.Matches {
+		matches[match.Line] = append(matches[match.Line], match.Span)
+	}
+	var current pagerMatch
+	if len(p.Matches) > 0 && !p.Searching {
+		current = p.Matches[p.MatchIndex]
+	} else {
+		current.Line = -1
+	}
+
+	// The screen may have grown since the last scroll
+	screen := m.pagerScreenLines()
+	p.Scroll = min(p.Scroll, max(len(screen)-(height-pagerHeaderHeight), 0))
+	gutter := m.pagerGutterWidth()
+	width := m.pagerTextWidth()
+	for i := p.Scroll; i < len(screen) && len(lines) < height; i++ {
+		line := screen[i]
+
+		var b strings.Builder
+		if gutter > 0 {
+			number := ""
+			if i == 0 || screen[i-1].Source != line.Source {
+				number = intToString(line.Source + 1)
+			}
+			b.WriteString(m.Styles.ChildCount.Render(strings.Repeat(" ", gutter-1-len(number)) + number + " "))
+		}
+
+		span := line.Span
+		if !p.Wrap {
+			span = render.Span{Start: p.HScroll, End: p.HScroll + width}
+		}
+		var currentSpan render.Span
+		if current.Line == line.Source {
+			currentSpan = current.Span
+		}
+		b.WriteString(m.Styles.HighlightLine(p.Lines[line.Source], p.kinds[line.Source], span, matches[line.Source], currentSpan))
+		lines = append(lines, truncateOrPad(b.String(), m.Width))
+	}
+
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", m.Width))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderPagerSearchBar renders the pager's search prompt or last search
+func (m *Model) renderPagerSearchBar() string {
+	p := m.Pager
+	prompt := m.Styles.SearchPrompt.Render("/")
+	if p.Searching {
+		return prompt + p.SearchInput.View() + " " + m.Styles.MatchCount.Render(
+			"["+intToString(len(p.Matches))+"]")
+	}
+
+	matchInfo := m.Styles.MatchCount.Render("[0/0]")
+	if len(p.Matches) > 0 {
+		matchInfo = m.Styles.MatchCount.Render(formatMatchInfo(p.MatchIndex+1, len(p.Matches)))
+	}
+	return prompt + m.Styles.SearchPrompt.Render(p.Query) + " " + matchInfo
+}
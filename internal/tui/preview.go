@@ -0,0 +1,151 @@
+package tui
+
+import (
+	"github.com/uznog/yamlist/internal/decode"
+	"github.com/uznog/yamlist/internal/model"
+)
+
+const (
+	// MinPreviewWidth is the terminal width below which a right-hand
+	// preview pane is hidden
+	MinPreviewWidth = 80
+
+	// MinPreviewHeight is the terminal height below which a bottom preview
+	// pane is hidden
+	MinPreviewHeight = 20
+
+	// DefaultPreviewRatio is the share of the screen given to the preview
+	DefaultPreviewRatio = 0.4
+
+	// previewRatioStep is how much + and - resize the preview
+	previewRatioStep = 0.05
+
+	// separatorWidth is the width of the separator between side-by-side panes
+	separatorWidth = 3
+)
+
+// previewVisible returns true if the preview pane is on and fits the terminal
+func (m *Model) previewVisible() bool {
+	if !m.ShowPreview {
+		return false
+	}
+	if m.PreviewBottom {
+		return m.Height >= MinPreviewHeight
+	}
+	return m.Width >= MinPreviewWidth
+}
+
+// updatePreview follows the selection with the preview pane
+// Scrolling restarts at the top when the selection changes, and string
+// values are decoded here, once per value, rather than on every render.
+func (m *Model) updatePreview() {
+	if !m.ShowPreview {
+		return
+	}
+	node := m.previewTarget()
+	if node != m.previewNode {
+		m.previewNode = node
+		m.PreviewScroll = 0
+	}
+
+	value := ""
+	if node.Kind == model.KindScalar && node.ScalarType == model.ScalarString {
+		value = node.ScalarValue
+	}
+	if value == m.previewValue {
+		return
+	}
+	m.previewValue, m.previewDecoded = value, nil
+	if decoded, ok := decode.Decode(value); ok {
+		m.previewDecoded = decoded
+	}
+}
+
+// previewTarget returns the node the preview shows: the selection, or the
+// root if nothing is selected
+func (m *Model) previewTarget() *model.Node {
+	if row := m.TreeState.GetSelectedRow(); row != nil {
+		return row.Node
+	}
+	return m.Document.Root
+}
+
+// togglePreview shows or hides the preview pane
+func (m *Model) togglePreview() {
+	m.ShowPreview = !m.ShowPreview
+	m.PreviewScroll = 0
+	m.updateLayout()
+	m.ensureSelectedVisible()
+	if m.ShowPreview && !m.previewVisible() {
+		m.SetNotice("terminal too small for the preview")
+	}
+}
+
+// movePreview switches the preview between the right and the bottom
+func (m *Model) movePreview() {
+	m.PreviewBottom = !m.PreviewBottom
+	m.ShowPreview = true
+	m.updateLayout()
+	m.ensureSelectedVisible()
+}
+
+// resizePreview grows (positive steps) or shrinks the preview pane
+func (m *Model) resizePreview(steps int) {
+	if !m.previewVisible() {
+		return
+	}
+	ratio := m.PreviewRatio + float64(steps)*previewRatioStep
+	m.PreviewRatio = min(max(ratio, 0.2), 0.8)
+	m.updateLayout()
+	m.ensureSelectedVisible()
+}
+
+// scrollPreview scrolls the preview pane by n lines (up if negative)
+// The offset is clamped to the content when the pane is rendered.
+func (m *Model) scrollPreview(n int) {
+	if !m.previewVisible() {
+		return
+	}
+	m.PreviewScroll = max(m.PreviewScroll+n, 0)
+}
+
+// previewPageSize returns how far ctrl+f and ctrl+b scroll the preview
+func (m *Model) previewPageSize() int {
+	if m.PreviewBottom {
+		return max(m.previewHeight()-1, 1)
+	}
+	return max(m.contentHeight()-1, 1)
+}
+
+// contentHeight returns the height above the status bar and the search,
+// query or edit bar
+func (m *Model) contentHeight() int {
+	height := m.Height - StatusBarHeight
+	if m.Mode == PagerMode {
+		if m.Pager.Searching || m.Pager.Query != "" {
+			height -= SearchBarHeight
+		}
+		return height
+	}
+	if m.Mode == SearchMode || m.SearchActive || m.Mode == EditMode || m.Mode == QueryMode {
+		height -= SearchBarHeight
+	}
+	return height
+}
+
+// previewHeight returns the height of a bottom preview pane, or 0
+func (m *Model) previewHeight() int {
+	if !m.previewVisible() || !m.PreviewBottom {
+		return 0
+	}
+	return int(float64(m.contentHeight()) * m.PreviewRatio)
+}
+
+// treeHeight returns the number of tree rows on screen
+func (m *Model) treeHeight() int {
+	height := m.contentHeight()
+	if preview := m.previewHeight(); preview > 0 {
+		height -= preview + 1 // +1 for the separator line
+	}
+	return max(height, 1)
+}
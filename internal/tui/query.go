@@ -0,0 +1,120 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/uznog/yamlist/internal/model"
+	"github.com/uznog/yamlist/internal/query"
+	"github.com/uznog/yamlist/internal/search"
+)
+
+// enterQueryMode opens the path query prompt, starting from the last query
+func (m *Model) enterQueryMode() (tea.Model, tea.Cmd) {
+	m.Mode = QueryMode
+	m.QueryInput.SetValue(m.PathQuery)
+	m.QueryInput.CursorEnd()
+	m.QueryInput.Focus()
+	return m, nil
+}
+
+// handleQueryKey handles key input in query mode
+func (m *Model) handleQueryKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.exitQueryMode()
+	case "enter":
+		m.exitQueryMode()
+		m.runQuery(m.QueryInput.Value())
+	case "ctrl+c":
+		return m, tea.Quit
+	default:
+		var cmd tea.Cmd
+		m.QueryInput, cmd = m.QueryInput.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// exitQueryMode closes the query prompt
+func (m *Model) exitQueryMode() {
+	m.Mode = TreeMode
+	m.QueryInput.Blur()
+}
+
+// runQuery jumps to the node matching a path query
+// When several nodes match, the view is filtered to them like a search and
+// n/N step through them; an empty query clears the filter.
+func (m *Model) runQuery(expr string) {
+	if expr == "" {
+		m.clearSearch()
+		m.computeVisibleRows()
+		m.ensureSelectedVisible()
+		return
+	}
+
+	q, err := query.Compile(expr)
+	if err != nil {
+		m.SetError("query: " + err.Error())
+		return
+	}
+
+	matches := m.queryMatches(q)
+	switch len(matches) {
+	case 0:
+		m.SetError("no match for " + expr)
+	case 1:
+		m.clearSearch()
+		m.jumpToNode(matches[0].Node)
+	default:
+		m.clearSearch()
+		m.PathQuery = expr
+		m.setQueryMatches(matches)
+		m.SearchActive = true
+		m.filterVisibleRowsToMatches()
+		m.jumpToNode(matches[0].Node)
+		m.updateRowDimming()
+		m.SetNotice(intToString(len(matches)) + " matches")
+	}
+}
+
+// updateQueryMatches re-runs the active path query against the document
+func (m *Model) updateQueryMatches() {
+	q, err := query.Compile(m.PathQuery)
+	if err != nil {
+		m.clearSearch()
+		return
+	}
+
+	m.setQueryMatches(m.queryMatches(q))
+	if m.SearchIndex >= len(m.SearchMatches) {
+		m.SearchIndex = 0
+	}
+	m.filterVisibleRowsToMatches()
+	m.updateRowDimming()
+}
+
+// setQueryMatches makes query results the search matches
+// Query matches have no matched characters to highlight.
+func (m *Model) setQueryMatches(matches []*model.PathEntry) {
+	m.SearchMatches = matches
+	m.searchHits = make(map[*model.Node]search.Hit, len(matches))
+	for _, entry := range matches {
+		m.searchHits[entry.Node] = search.Hit{}
+	}
+}
+
+// queryMatches returns the index entries of the nodes a query selects, in
+// document order
+func (m *Model) queryMatches(q *query.Query) []*model.PathEntry {
+	selected := make(map[*model.Node]bool)
+	for _, node := range q.Eval(m.Document.Root) {
+		selected[node] = true
+	}
+
+	matches := make([]*model.PathEntry, 0, len(selected))
+	for _, entry := range m.Document.Index.Entries() {
+		if selected[entry.Node] {
+			matches = append(matches, entry)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,132 @@
+package tui
+
+import (
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/uznog/yamlist/internal/model"
+	"github.com/uznog/yamlist/internal/yamlparse"
+)
+
+// WatchInterval is how often the source file is checked for changes
+const WatchInterval = 500 * time.Millisecond
+
+// fileStamp identifies a version of the source file
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// statFile returns the current stamp of the file at path
+func statFile(path string) (fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// fileCheckMsg reports the result of a periodic source file check
+// Doc is nil if the file did not change.
+type fileCheckMsg struct {
+	stamp fileStamp
+	doc   *yamlparse.Document
+	err   error
+}
+
+// canWatch returns true if the document comes from a file that can be watched
+func (m *Model) canWatch() bool {
+	return m.Config.Watch && !m.Document.IsStdin() && m.Document.FilePath != ""
+}
+
+// watchFile schedules the next check of the source file
+// The file is stat'ed every WatchInterval and re-parsed when its
+// modification time or size changes. Editors that save by renaming a new
+// file into place briefly leave no file; such checks are simply skipped.
+func (m *Model) watchFile() tea.Cmd {
+	path := m.Document.FilePath
+	format := m.Document.Format
+	last := m.fileStamp
+
+	return tea.Tick(WatchInterval, func(time.Time) tea.Msg {
+		stamp, err := statFile(path)
+		if err != nil || stamp == last {
+			return fileCheckMsg{stamp: last}
+		}
+		doc, err := yamlparse.ParseFileTolerant(path, format)
+		return fileCheckMsg{stamp: stamp, doc: doc, err: err}
+	})
+}
+
+// handleFileCheck applies a changed source file and schedules the next check
+func (m *Model) handleFileCheck(msg fileCheckMsg) (tea.Model, tea.Cmd) {
+	m.fileStamp = msg.stamp
+	if msg.err != nil {
+		m.SetError("reload failed: " + msg.err.Error())
+	} else if msg.doc != nil {
+		m.leaveDecoded()
+		m.recordReload("reload", msg.doc)
+		m.reload(msg.doc)
+		m.ReloadedAt = time.Now()
+	}
+	return m, m.watchFile()
+}
+
+// reload replaces the document while keeping the view stable
+// Expanded paths, the selection (by path) and the active search or query carry
+// over; containers that did not exist before are expanded, as at startup.
+func (m *Model) reload(doc *yamlparse.Document) {
+	var selectedPath *model.Path
+	if row := m.TreeState.GetSelectedRow(); row != nil {
+		selectedPath = row.Node.Path
+	}
+
+	known := make(map[string]bool, m.Document.Index.Len())
+	for _, entry := range m.Document.Index.Entries() {
+		known[entry.Node.Path.String()] = true
+	}
+
+	model.LabelItems(doc.Root, m.Config.LabelKeys)
+	m.Document = doc
+	m.TreeState.SetRoot(doc.Root)
+	expandNewNodes(m.TreeState, doc.Root, known)
+
+	// Rebuild rows, re-running the search or query against the new document
+	if m.SearchActive && m.PathQuery != "" {
+		m.updateQueryMatches()
+	} else if m.SearchActive && m.SearchInput.Value() != "" {
+		m.updateSearchMatches()
+	} else {
+		m.computeVisibleRows()
+		m.updateRowDimming()
+	}
+
+	// If the selected node is gone, the clamped row position is kept
+	if selectedPath != nil {
+		m.TreeState.SelectByPath(selectedPath)
+	}
+	m.ensureSelectedVisible()
+
+	if m.ViewMode == TableView {
+		m.refreshTable()
+	}
+	if m.Mode == PagerMode {
+		m.refreshPager()
+	}
+
+	m.notifyDiagnostic()
+}
+
+// expandNewNodes expands the containers whose paths are not in known
+func expandNewNodes(ts *model.TreeState, node *model.Node, known map[string]bool) {
+	if node == nil {
+		return
+	}
+	if node.IsExpandable() && node.HasChildren() && !known[node.Path.String()] {
+		ts.SetExpanded(node, true)
+	}
+	for _, child := range node.Children {
+		expandNewNodes(ts, child, known)
+	}
+}
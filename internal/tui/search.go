@@ -1,16 +1,21 @@
 package tui
 
 import (
-	"strings"
+	"sort"
 
 	"github.com/uznog/yamlist/internal/model"
+	"github.com/uznog/yamlist/internal/search"
 )
 
 // updateSearchMatches updates the search matches based on current input
+// The input is compiled as a search filter (see search.Filter) in the
+// current scope and matches are ranked best first.
 func (m *Model) updateSearchMatches() {
 	query := m.SearchInput.Value()
+	m.SearchErr = ""
 	if query == "" {
 		m.SearchMatches = nil
+		m.searchHits = nil
 		m.SearchIndex = 0
 		m.SearchActive = false
 		m.computeVisibleRows() // Reset to full view
@@ -18,24 +23,39 @@ func (m *Model) updateSearchMatches() {
 		return
 	}
 
-	// Use case-insensitive substring search on node key name only
-	queryLower := strings.ToLower(query)
 	m.SearchMatches = make([]*model.PathEntry, 0)
+	m.searchHits = make(map[*model.Node]search.Hit)
 
-	for i := 0; i < m.Document.Index.Len(); i++ {
-		entry := m.Document.Index.EntryAt(i)
-		// Search only on the key name, not the full path
-		if entry.Node != nil && strings.Contains(strings.ToLower(entry.Node.Key), queryLower) {
-			m.SearchMatches = append(m.SearchMatches, entry)
+	filter, err := search.Compile(query, m.SearchScope)
+	if err != nil {
+		// Usually a search still being typed: show why nothing matches
+		m.SearchErr = err.Error()
+	} else {
+		for i := 0; i < m.Document.Index.Len(); i++ {
+			entry := m.Document.Index.EntryAt(i)
+			if entry.Node == nil {
+				continue
+			}
+			if hit, ok := filter.Match(entry.Node); ok {
+				m.SearchMatches = append(m.SearchMatches, entry)
+				m.searchHits[entry.Node] = hit
+			}
 		}
 	}
 
+	// Best first; ties go to the shorter key, then to document order
+	sort.SliceStable(m.SearchMatches, func(i, j int) bool {
+		a, b := m.SearchMatches[i].Node, m.SearchMatches[j].Node
+		if scoreA, scoreB := m.searchHits[a].Score, m.searchHits[b].Score; scoreA != scoreB {
+			return scoreA > scoreB
+		}
+		return len(a.DisplayKey()) < len(b.DisplayKey())
+	})
+
 	m.SearchActive = true
 
-	// Reset index if out of bounds
-	if m.SearchIndex >= len(m.SearchMatches) {
-		m.SearchIndex = 0
-	}
+	// The ranking changes with every keystroke: start from the best match
+	m.SearchIndex = 0
 
 	// Filter visible rows to show only matches
 	m.filterVisibleRowsToMatches()
@@ -49,13 +69,21 @@ func (m *Model) updateSearchMatches() {
 	m.updateRowDimming()
 }
 
-// updateRowDimming updates the IsDimmed and IsSearchMatch flags on all visible rows
+// cycleSearchScope switches plain search text to the next scope
+func (m *Model) cycleSearchScope() {
+	m.SearchScope = m.SearchScope.Next()
+	m.updateSearchMatches()
+}
+
+// updateRowDimming updates the IsDimmed, IsSearchMatch and MatchPositions
+// fields of all visible rows
 func (m *Model) updateRowDimming() {
 	// If search is active but no matches, dim all rows
-	if m.SearchActive && len(m.SearchMatches) == 0 && m.SearchInput.Value() != "" {
+	if m.SearchActive && len(m.SearchMatches) == 0 && (m.SearchInput.Value() != "" || m.PathQuery != "") {
 		for _, row := range m.TreeState.VisibleRows {
 			row.IsDimmed = true
 			row.IsSearchMatch = false
+			row.MatchPositions = nil
 		}
 		return
 	}
@@ -63,7 +91,34 @@ func (m *Model) updateRowDimming() {
 	// Otherwise, no dimming (rows are filtered, not dimmed)
 	for _, row := range m.TreeState.VisibleRows {
 		row.IsDimmed = false
-		row.IsSearchMatch = false
+		m.markSearchHit(row)
+	}
+}
+
+// markSearchHit sets whether a row is a search match, and where the match
+// is in the key or path and the value the row shows
+func (m *Model) markSearchHit(row *model.VisibleRow) {
+	row.IsSearchMatch = false
+	row.MatchPositions = nil
+	row.ValueMatchPositions = nil
+	row.LabelMatchPositions = nil
+
+	hit, ok := m.searchHits[row.Node]
+	if !m.SearchActive || !ok {
+		return
+	}
+	row.IsSearchMatch = true
+	row.ValueMatchPositions = hit.Value
+	if row.Node.Key == "" {
+		// List items match by their label
+		row.LabelMatchPositions = hit.Key
+		if m.ViewMode == FlatView {
+			row.MatchPositions = hit.Path
+		}
+	} else if m.ViewMode == FlatView {
+		row.MatchPositions = hit.Path
+	} else {
+		row.MatchPositions = hit.Key
 	}
 }
 
@@ -75,11 +130,11 @@ func (m *Model) filterVisibleRowsToMatches() {
 		return
 	}
 
-	// Build set of matching paths
-	matchPaths := make(map[string]bool)
+	// Build set of matching nodes
+	matchNodes := make(map[*model.Node]bool)
 	for _, match := range m.SearchMatches {
-		if match.Node != nil && match.Node.Path != nil {
-			matchPaths[match.Node.Path.String()] = true
+		if match.Node != nil {
+			matchNodes[match.Node] = true
 		}
 	}
 
@@ -96,7 +151,7 @@ func (m *Model) filterVisibleRowsToMatches() {
 		// In flat mode: show only matching nodes
 		filtered := make([]*model.VisibleRow, 0)
 		for _, row := range m.TreeState.VisibleRows {
-			if matchPaths[row.Node.Path.String()] {
+			if matchNodes[row.Node] {
 				filtered = append(filtered, row)
 			}
 		}
@@ -105,7 +160,7 @@ func (m *Model) filterVisibleRowsToMatches() {
 		// In tree mode: show matches + their ancestors (for context)
 		filtered := make([]*model.VisibleRow, 0)
 		for _, row := range m.TreeState.VisibleRows {
-			if matchPaths[row.Node.Path.String()] {
+			if matchNodes[row.Node] {
 				filtered = append(filtered, row)
 			} else {
 				// Check if this row is an ancestor of any match
@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/uznog/yamlist/internal/nvim"
+	"github.com/uznog/yamlist/internal/yamlparse"
+)
+
+// nvimMsg wraps a message received from Neovim
+type nvimMsg nvim.Message
+
+// listenNvim waits for the next message from Neovim
+// Returns nil in standalone mode; stops when the connection ends.
+func (m *Model) listenNvim() tea.Cmd {
+	if m.NvimClient == nil {
+		return nil
+	}
+
+	events := m.NvimClient.Events()
+	return func() tea.Msg {
+		msg, ok := <-events
+		if !ok {
+			return nil
+		}
+		return nvimMsg(msg)
+	}
+}
+
+// handleNvimMsg applies a message from Neovim and waits for the next one
+func (m *Model) handleNvimMsg(msg nvimMsg) (tea.Model, tea.Cmd) {
+	switch msg.Op {
+	case "cursor":
+		m.followCursor(msg.Line, msg.Col)
+	case "buffer":
+		m.applyBuffer(msg.Text)
+	}
+	return m, m.listenNvim()
+}
+
+// followCursor selects the deepest node at the editor cursor, expanding
+// its ancestors
+// Neovim counts columns in bytes; they are converted to characters first.
+// The new selection is not sent back to Neovim so the two cursors don't
+// chase each other.
+func (m *Model) followCursor(line, col int) {
+	if m.isDecoded() {
+		return
+	}
+	node := m.Document.NodeAt(line, m.Document.CharColumn(line, col))
+	if row := m.TreeState.GetSelectedRow(); row != nil && row.Node == node {
+		return
+	}
+
+	m.TreeState.ExpandToNode(node)
+	if m.SearchActive && len(m.SearchMatches) > 0 {
+		m.filterVisibleRowsToMatches()
+		m.updateRowDimming()
+	} else {
+		m.computeVisibleRows()
+	}
+
+	// Rows may be filtered by a search: fall back to the closest visible ancestor
+	for n := node; n != nil; n = n.Parent {
+		if m.TreeState.SelectNode(n) {
+			break
+		}
+	}
+	m.ensureSelectedVisible()
+}
+
+// applyBuffer re-parses the unsaved contents of the Neovim buffer in place
+// The source path and format are kept, so the tree reads as the same file.
+func (m *Model) applyBuffer(text string) {
+	m.leaveDecoded()
+	doc := yamlparse.ParseBytesTolerant([]byte(text), m.Document.FilePath, m.Document.Format)
+	m.recordReload("buffer change", doc)
+	m.reload(doc)
+	m.ReloadedAt = time.Now()
+}
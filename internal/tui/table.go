@@ -0,0 +1,422 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/uznog/yamlist/internal/model"
+)
+
+const (
+	// maxColumnWidth is the widest a table column is drawn
+	maxColumnWidth = 30
+
+	// columnGap is the space between table columns
+	columnGap = 2
+
+	// tableHeaderHeight is the height of the column header line
+	tableHeaderHeight = 1
+)
+
+// TableState is the state of the table view of a list of maps
+type TableState struct {
+	Table *model.Table
+
+	Row       int // Selected row
+	Col       int // Selected column, among the shown ones
+	Scroll    int // First row shown
+	ColScroll int // First column shown, among the shown ones
+
+	Hidden   map[string]bool // Hidden columns by key
+	SortKey  string          // Column the rows are sorted by ("" for list order)
+	SortDesc bool
+
+	prevView ViewMode
+}
+
+// openTable shows the nearest list of maps around the selection as a table
+// The row and column of the selection are selected.
+func (m *Model) openTable() {
+	row := m.TreeState.GetSelectedRow()
+	if row == nil {
+		return
+	}
+
+	var table *model.Table
+	var item, field *model.Node
+	for n := row.Node; n != nil; n = n.Parent {
+		if t, ok := model.NewTable(n); ok {
+			table = t
+			break
+		}
+		item, field = n, item
+	}
+	if table == nil {
+		m.SetNotice("no list of maps here")
+		return
+	}
+
+	m.Table = &TableState{
+		Table:    table,
+		Hidden:   make(map[string]bool),
+		prevView: m.ViewMode,
+	}
+	m.ViewMode = TableView
+	for i, r := range table.Rows {
+		if r == item {
+			m.Table.Row = i
+		}
+	}
+	if field != nil {
+		for i, col := range m.tableColumns() {
+			if table.Columns[col] == field.Key {
+				m.Table.Col = i
+			}
+		}
+	}
+	m.ensureTableCellVisible()
+}
+
+// closeTable returns to the tree (or flat) view with node selected
+func (m *Model) closeTable(node *model.Node) {
+	m.ViewMode = m.Table.prevView
+	m.Table = nil
+	m.computeVisibleRows()
+	if node != nil {
+		m.jumpToNode(node)
+	}
+	m.ensureSelectedVisible()
+	m.notifyLineChange()
+}
+
+// refreshTable rebuilds the table after the document changed
+// Sorting and hidden columns are kept; the table closes if the list is gone.
+func (m *Model) refreshTable() {
+	t := m.Table
+	table, ok := model.NewTable(m.Document.NodeByPath(t.Table.List.Path))
+	if !ok {
+		m.closeTable(nil)
+		return
+	}
+	t.Table = table
+	m.sortTable()
+	t.Row = min(t.Row, len(table.Rows)-1)
+	t.Col = min(t.Col, max(len(m.tableColumns())-1, 0))
+	m.ensureTableCellVisible()
+}
+
+// handleTableKey handles key input in the table view
+func (m *Model) handleTableKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	t := m.Table
+	rows := len(t.Table.Rows)
+	cols := len(m.tableColumns())
+
+	switch msg.String() {
+	case "j", "down":
+		t.Row = min(t.Row+1, rows-1)
+	case "k", "up":
+		t.Row = max(t.Row-1, 0)
+	case "ctrl+d":
+		t.Row = min(t.Row+m.tableHeight()/2, rows-1)
+	case "ctrl+u":
+		t.Row = max(t.Row-m.tableHeight()/2, 0)
+	case "g":
+		t.Row = 0
+	case "G":
+		t.Row = rows - 1
+	case "h", "left":
+		t.Col = max(t.Col-1, 0)
+	case "l", "right":
+		t.Col = min(t.Col+1, max(cols-1, 0))
+	case "0":
+		t.Col = 0
+	case "$":
+		t.Col = max(cols-1, 0)
+
+	// Sort by the column: ascending, descending, list order
+	case "s":
+		m.cycleTableSort()
+
+	// Hide the column, show all
+	case "-":
+		m.hideTableColumn()
+	case "+", "=":
+		if len(t.Hidden) > 0 {
+			m.SetNotice("showing all " + intToString(len(t.Table.Columns)) + " columns")
+		}
+		t.Hidden = make(map[string]bool)
+
+	// Drill into the cell, or go back to the item
+	case "enter":
+		m.closeTable(m.tableSelection())
+		return m, nil
+	case "esc", "T":
+		m.closeTable(t.Table.Rows[t.Row])
+		return m, nil
+
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	}
+
+	m.ensureTableCellVisible()
+	return m, nil
+}
+
+// tableSelection returns the selected cell, or its item if the item has
+// no such key
+func (m *Model) tableSelection() *model.Node {
+	t := m.Table
+	item := t.Table.Rows[t.Row]
+	if cols := m.tableColumns(); len(cols) > 0 {
+		if cell := t.Table.Cell(item, cols[t.Col]); cell != nil {
+			return cell
+		}
+	}
+	return item
+}
+
+// cycleTableSort sorts by the selected column, ascending then descending,
+// then goes back to the list order
+func (m *Model) cycleTableSort() {
+	t := m.Table
+	cols := m.tableColumns()
+	if len(cols) == 0 {
+		return
+	}
+	key := t.Table.Columns[cols[t.Col]]
+
+	switch {
+	case t.SortKey != key:
+		t.SortKey, t.SortDesc = key, false
+	case !t.SortDesc:
+		t.SortDesc = true
+	default:
+		t.SortKey, t.SortDesc = "", false
+	}
+
+	// Keep the selected item selected
+	item := t.Table.Rows[t.Row]
+	m.sortTable()
+	for i, r := range t.Table.Rows {
+		if r == item {
+			t.Row = i
+		}
+	}
+}
+
+// sortTable applies the sort column to the rows
+func (m *Model) sortTable() {
+	t := m.Table
+	col := -1
+	for i, key := range t.Table.Columns {
+		if key == t.SortKey {
+			col = i
+		}
+	}
+	t.Table.SortBy(col, t.SortDesc)
+}
+
+// hideTableColumn hides the selected column; the last one stays
+func (m *Model) hideTableColumn() {
+	t := m.Table
+	cols := m.tableColumns()
+	if len(cols) <= 1 {
+		m.SetNotice("cannot hide the last column")
+		return
+	}
+	key := t.Table.Columns[cols[t.Col]]
+	t.Hidden[key] = true
+	t.Col = min(t.Col, len(cols)-2)
+	m.SetNotice("hid " + key + " (+ shows all columns)")
+}
+
+// tableColumns returns the indices of the columns that are not hidden
+func (m *Model) tableColumns() []int {
+	var cols []int
+	for i, key := range m.Table.Table.Columns {
+		if !m.Table.Hidden[key] {
+			cols = append(cols, i)
+		}
+	}
+	return cols
+}
+
+// tableHeight returns the number of table rows on screen
+func (m *Model) tableHeight() int {
+	return max(m.contentHeight()-tableHeaderHeight, 1)
+}
+
+// tableWidths returns the width of the index column and of each shown column
+func (m *Model) tableWidths() (int, []int) {
+	t := m.Table
+	indexWidth := 0
+	for _, row := range t.Table.Rows {
+		indexWidth = max(indexWidth, len(tableIndex(row)))
+	}
+
+	cols := m.tableColumns()
+	widths := make([]int, len(cols))
+	for i, col := range cols {
+		width := runeLen(t.Table.Columns[col]) + 2 // Room for the sort marker
+		for _, row := range t.Table.Rows {
+			width = max(width, runeLen(cellText(t.Table.Cell(row, col))))
+		}
+		widths[i] = min(width, maxColumnWidth)
+	}
+	return indexWidth, widths
+}
+
+// ensureTableCellVisible scrolls the table to the selected cell
+func (m *Model) ensureTableCellVisible() {
+	t := m.Table
+	height := m.tableHeight()
+	if t.Row < t.Scroll {
+		t.Scroll = t.Row
+	}
+	if t.Row >= t.Scroll+height {
+		t.Scroll = t.Row - height + 1
+	}
+
+	// Scroll right until the selected column fits, left if it is before
+	indexWidth, widths := m.tableWidths()
+	if t.Col < t.ColScroll {
+		t.ColScroll = t.Col
+	}
+	for t.ColScroll < t.Col {
+		used := indexWidth
+		for i := t.ColScroll; i <= t.Col; i++ {
+			used += columnGap + widths[i]
+		}
+		if used <= m.Width {
+			break
+		}
+		t.ColScroll++
+	}
+}
+
+// renderTable renders the column header and the visible rows
+func (m *Model) renderTable(height int) string {
+	t := m.Table
+	cols := m.tableColumns()
+	indexWidth, widths := m.tableWidths()
+
+	// Header, with the sort direction
+	header := padRunes("", indexWidth)
+	for i := t.ColScroll; i < len(cols); i++ {
+		name := t.Table.Columns[cols[i]]
+		if name == t.SortKey {
+			if t.SortDesc {
+				name += " ▼"
+			} else {
+				name += " ▲"
+			}
+		}
+		header += strings.Repeat(" ", columnGap) + m.Styles.Key.Render(padRunes(name, widths[i]))
+	}
+	lines := []string{truncateOrPad(header, m.Width)}
+
+	for r := t.Scroll; r < len(t.Table.Rows) && len(lines) < height; r++ {
+		if r == t.Row {
+			lines = append(lines, m.renderSelectedTableRow(cols, indexWidth, widths))
+			continue
+		}
+
+		row := t.Table.Rows[r]
+		var b strings.Builder
+		b.WriteString(m.Styles.ChildCount.Render(padRunes(tableIndex(row), indexWidth)))
+		for i := t.ColScroll; i < len(cols); i++ {
+			cell := t.Table.Cell(row, cols[i])
+			text := padRunes(cellText(cell), widths[i])
+			b.WriteString(strings.Repeat(" ", columnGap))
+			switch {
+			case cell == nil:
+				b.WriteString(text)
+			case cell.Kind != model.KindScalar:
+				b.WriteString(m.Styles.ChildCount.Render(text))
+			default:
+				b.WriteString(m.Styles.GetValueStyle(int(cell.ScalarType)).Render(text))
+			}
+		}
+		lines = append(lines, truncateOrPad(b.String(), m.Width))
+	}
+
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", m.Width))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderSelectedTableRow renders the selected row, with the selected cell
+// set apart
+func (m *Model) renderSelectedTableRow(cols []int, indexWidth int, widths []int) string {
+	t := m.Table
+	row := t.Table.Rows[t.Row]
+	gap := strings.Repeat(" ", columnGap)
+
+	var before, cell, after strings.Builder
+	before.WriteString(padRunes(tableIndex(row), indexWidth))
+	for i := t.ColScroll; i < len(cols); i++ {
+		text := padRunes(cellText(t.Table.Cell(row, cols[i])), widths[i])
+		switch {
+		case i < t.Col:
+			before.WriteString(gap + text)
+		case i == t.Col:
+			before.WriteString(gap)
+			cell.WriteString(text)
+		default:
+			after.WriteString(gap + text)
+		}
+	}
+
+	// Cut to the screen width before styling the parts
+	width := m.Width
+	left := truncateOrPad(before.String(), min(runeLen(before.String()), width))
+	width -= runeLen(left)
+	middle := truncateOrPad(cell.String(), min(runeLen(cell.String()), width))
+	width -= runeLen(middle)
+	right := truncateOrPad(after.String(), width)
+
+	return m.Styles.SelectedRow.Render(left) +
+		m.Styles.SelectionAccent.Bold(true).Render(middle) +
+		m.Styles.SelectedRow.Render(right)
+}
+
+// tableIndex returns the index label of a table row
+func tableIndex(item *model.Node) string {
+	return "[" + intToString(item.Index) + "]"
+}
+
+// cellText returns the text of a table cell: scalars as on tree rows, maps
+// and lists as their size ("" for a missing key)
+func cellText(cell *model.Node) string {
+	switch {
+	case cell == nil:
+		return ""
+	case cell.Kind == model.KindMap:
+		return "{" + intToString(len(cell.Children)) + "}"
+	case cell.Kind == model.KindList:
+		return "[" + intToString(len(cell.Children)) + "]"
+	case cell.ScalarType == model.ScalarNull:
+		return "null"
+	case strings.Contains(cell.ScalarValue, "\n"):
+		return "[" + intToString(strings.Count(cell.ScalarValue, "\n")+1) + " lines]"
+	}
+	return strings.ReplaceAll(cell.ScalarValue, "\t", "\\t")
+}
+
+// padRunes cuts or pads s to width runes, marking a cut with "…"
+func padRunes(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) > width {
+		if width < 1 {
+			return ""
+		}
+		return string(runes[:width-1]) + "…"
+	}
+	return s + strings.Repeat(" ", width-len(runes))
+}
+
+// runeLen returns the number of runes in s
+func runeLen(s string) int {
+	return len([]rune(s))
+}
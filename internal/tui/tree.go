@@ -6,12 +6,18 @@ import (
 
 // notifyLineChange sends a cursor position update to Neovim if connected
 func (m *Model) notifyLineChange() {
-	if m.NvimClient == nil {
+	if m.NvimClient == nil || m.isDecoded() {
 		return
 	}
 	row := m.TreeState.GetSelectedRow()
-	if row != nil && row.Node.LineNumber > 0 {
-		m.NvimClient.SendCursor(row.Node.LineNumber)
+	if row == nil {
+		return
+	}
+	// Land on the key for map entries, otherwise on the value
+	if pos := row.Node.StartPos(); pos.IsValid() {
+		m.NvimClient.SendCursor(pos.Line, m.Document.ByteColumn(pos))
+	} else if row.Node.LineNumber > 0 {
+		m.NvimClient.SendCursor(row.Node.LineNumber, 0)
 	}
 }
 
@@ -48,6 +54,7 @@ func (m *Model) computeFlatRows() {
 		if entry.Node != nil && entry.Node.Path != nil && entry.Node.Path.Depth() > 0 {
 			row := model.NewVisibleRow(entry.Node, false, len(m.TreeState.VisibleRows))
 			row.Depth = 0 // No indentation in flat mode
+			m.markSearchHit(row)
 			m.TreeState.VisibleRows = append(m.TreeState.VisibleRows, row)
 		}
 	}
@@ -71,9 +78,10 @@ func (m *Model) computeVisibleRowsRecursive(node *model.Node, depth int) {
 	}
 
 	// Add this node as a visible row
-	isExpanded := m.TreeState.IsExpanded(node.Path)
+	isExpanded := m.TreeState.IsExpanded(node)
 	rowIndex := len(m.TreeState.VisibleRows)
 	row := model.NewVisibleRow(node, isExpanded, rowIndex)
+	m.markSearchHit(row)
 	m.TreeState.VisibleRows = append(m.TreeState.VisibleRows, row)
 
 	// If expanded, add children
@@ -115,7 +123,7 @@ func (m *Model) expandSelected() bool {
 	}
 
 	// Expand
-	m.TreeState.SetExpanded(row.Node.Path, true)
+	m.TreeState.SetExpanded(row.Node, true)
 	m.computeVisibleRows()
 	return true
 }
@@ -129,7 +137,7 @@ func (m *Model) collapseSelected() bool {
 
 	if row.IsExpandable && row.IsExpanded {
 		// Collapse this node
-		m.TreeState.SetExpanded(row.Node.Path, false)
+		m.TreeState.SetExpanded(row.Node, false)
 		m.computeVisibleRows()
 		return true
 	}
@@ -213,7 +221,7 @@ func (m *Model) toggleExpand() bool {
 		return false
 	}
 
-	m.TreeState.ToggleExpanded(row.Node.Path)
+	m.TreeState.ToggleExpanded(row.Node)
 	m.computeVisibleRows()
 	return true
 }
@@ -224,14 +232,7 @@ func (m *Model) ensureSelectedVisible() {
 		return
 	}
 
-	// Calculate visible height (approximate)
-	visibleHeight := m.Height - StatusBarHeight
-	if m.Mode == SearchMode || m.SearchActive {
-		visibleHeight -= SearchBarHeight
-	}
-	if visibleHeight < 1 {
-		visibleHeight = 1
-	}
+	visibleHeight := m.treeHeight()
 
 	// Adjust scroll offset
 	if m.TreeState.SelectedIndex < m.TreeState.ScrollOffset {
@@ -248,14 +249,7 @@ func (m *Model) centerSelected() {
 		return
 	}
 
-	// Calculate visible height
-	visibleHeight := m.Height - StatusBarHeight
-	if m.Mode == SearchMode || m.SearchActive {
-		visibleHeight -= SearchBarHeight
-	}
-	if visibleHeight < 1 {
-		visibleHeight = 1
-	}
+	visibleHeight := m.treeHeight()
 
 	// Center the selection
 	m.TreeState.ScrollOffset = m.TreeState.SelectedIndex - visibleHeight/2
@@ -297,25 +291,13 @@ func (m *Model) jumpToNode(node *model.Node) bool {
 
 // pageUp moves up by a page
 func (m *Model) pageUp() {
-	pageSize := m.Height - StatusBarHeight - 2
-	if m.Mode == SearchMode {
-		pageSize -= SearchBarHeight
-	}
-	if pageSize < 1 {
-		pageSize = 1
-	}
+	pageSize := max(m.treeHeight()-1, 1)
 	m.moveUp(pageSize)
 }
 
 // pageDown moves down by a page
 func (m *Model) pageDown() {
-	pageSize := m.Height - StatusBarHeight - 2
-	if m.Mode == SearchMode {
-		pageSize -= SearchBarHeight
-	}
-	if pageSize < 1 {
-		pageSize = 1
-	}
+	pageSize := max(m.treeHeight()-1, 1)
 	m.moveDown(pageSize)
 }
 
@@ -339,3 +321,36 @@ func (m *Model) goToBottom() {
 	m.ensureSelectedVisible()
 	m.notifyLineChange()
 }
+
+// jumpToAnchor jumps to the anchor definition of the selected alias or
+// inherited key
+func (m *Model) jumpToAnchor() bool {
+	row := m.TreeState.GetSelectedRow()
+	if row == nil {
+		return false
+	}
+
+	target := row.Node.AnchorDefinition()
+	if target == nil {
+		m.SetError("not an alias or inherited key")
+		return false
+	}
+
+	m.ClearError()
+	return m.jumpToNode(target)
+}
+
+// jumpToParseError jumps to the pseudo-node marking the parse error
+func (m *Model) jumpToParseError() bool {
+	if m.Document.ParseError == nil {
+		m.SetError("no parse error")
+		return false
+	}
+
+	for _, child := range m.Document.Root.Children {
+		if child.IsError {
+			return m.jumpToNode(child)
+		}
+	}
+	return false
+}
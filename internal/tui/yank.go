@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"os"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/uznog/yamlist/internal/model"
+	"github.com/uznog/yamlist/internal/yamlparse"
+)
+
+// YankHelp lists the keys that can follow y
+const YankHelp = "p:path j:jsonpath q:yq h:helm v:value y:yaml J:json"
+
+// handleYankKey handles the key following y: what to copy of the selection
+func (m *Model) handleYankKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.YankPending = false
+
+	row := m.TreeState.GetSelectedRow()
+	if row == nil {
+		return m, nil
+	}
+	node := row.Node
+
+	var what, text string
+	switch msg.String() {
+	case "p":
+		what, text = "path", node.Path.String()
+	case "j":
+		what, text = "JSONPath", node.Path.JSONPath()
+	case "q":
+		what, text = "yq path", node.Path.YQ()
+	case "h":
+		what, text = "helm --set key", node.Path.HelmSet()
+	case "v":
+		if node.Kind != model.KindScalar {
+			m.SetError("only scalars have a value: use yy or yJ to copy a subtree")
+			return m, nil
+		}
+		what, text = "value", node.ScalarValue
+	case "y":
+		data, err := m.Document.NodeYAML(node)
+		if err != nil {
+			m.SetError(err.Error())
+			return m, nil
+		}
+		what, text = "YAML", string(data)
+	case "J":
+		what, text = "JSON", string(yamlparse.NodeJSON(node))
+	default:
+		// Any other key cancels
+		return m, nil
+	}
+
+	m.yank(what, text)
+	return m, nil
+}
+
+// yank copies text to the clipboard and, when connected, to Neovim's
+// registers
+func (m *Model) yank(what, text string) {
+	if err := copyToClipboard(text); err != nil {
+		m.SetError("could not copy: " + err.Error())
+		return
+	}
+	m.NvimClient.SendYank(text)
+
+	summary := text
+	if lines := strings.Count(strings.TrimSuffix(text, "\n"), "\n") + 1; lines > 1 {
+		summary = intToString(lines) + " lines"
+	}
+	m.SetNotice("copied " + what + ": " + summary)
+}
+
+// copyToClipboard sets the terminal's clipboard with an OSC 52 escape
+// sequence, which also works over SSH
+// Inside tmux and screen the sequence is wrapped to pass through them.
+func copyToClipboard(text string) error {
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+	_, err := seq.WriteTo(os.Stdout)
+	return err
+}
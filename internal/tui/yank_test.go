@@ -0,0 +1,45 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/uznog/yamlist/internal/yamlparse"
+)
+
+// press sends a key to the model and reports whether it asked to quit
+func press(m *Model, key string) bool {
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	if cmd == nil {
+		return false
+	}
+	_, quit := cmd().(tea.QuitMsg)
+	return quit
+}
+
+func TestYank_YQPathDoesNotQuit(t *testing.T) {
+	doc, err := yamlparse.ParseString("server:\n  port: 80\n")
+	if err != nil {
+		t.Fatalf("ParseString failed: %v", err)
+	}
+	m := NewModel(doc, nil, nil)
+	m.TreeState.SelectNode(doc.FindByPath("server.port"))
+
+	if press(m, "y") {
+		t.Fatal("y quit")
+	}
+	if press(m, "q") {
+		t.Fatal("q after y quit instead of copying the yq path")
+	}
+	if m.YankPending {
+		t.Error("YankPending still set after yq")
+	}
+	if !strings.Contains(m.Notice, "yq path") && m.Error == "" {
+		t.Errorf("Notice = %q, want the copied yq path", m.Notice)
+	}
+
+	if !press(m, "q") {
+		t.Error("q without a pending yank did not quit")
+	}
+}
@@ -0,0 +1,73 @@
+package yamlparse
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/uznog/yamlist/internal/model"
+)
+
+// ParseError is a syntax error with its location in the source
+type ParseError struct {
+	// Line is the 1-based line of the error (0 if unknown)
+	Line int
+
+	// Column is the 1-based column of the error (0 if unknown)
+	Column int
+
+	// Message describes the error
+	Message string
+}
+
+// Error implements the error interface
+func (e *ParseError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+	case e.Line > 0:
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	default:
+		return e.Message
+	}
+}
+
+// Position returns the error location as a source position
+func (e *ParseError) Position() model.Position {
+	return model.Position{Line: e.Line, Column: e.Column}
+}
+
+// errorAt creates a parse error at the given position
+func errorAt(pos model.Position, format string, args ...interface{}) *ParseError {
+	return &ParseError{Line: pos.Line, Column: pos.Column, Message: fmt.Sprintf(format, args...)}
+}
+
+// yamlErrorPattern matches the location prefix of yaml.v3 error messages
+var yamlErrorPattern = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// AsParseError returns the located parse error wrapped in err
+// yaml.v3 only reports locations inside its messages, so those are parsed.
+func AsParseError(err error) *ParseError {
+	if err == nil {
+		return nil
+	}
+
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr
+	}
+
+	// yaml.TypeError and other wrapped errors: use the innermost message
+	inner := err
+	for errors.Unwrap(inner) != nil {
+		inner = errors.Unwrap(inner)
+	}
+	message := inner.Error()
+	if m := yamlErrorPattern.FindStringSubmatch(message); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return &ParseError{Line: line, Message: m[2]}
+	}
+	return &ParseError{Message: strings.TrimPrefix(message, "yaml: ")}
+}
@@ -1,10 +1,20 @@
 package yamlparse
 
-import "github.com/uznog/yamlist/internal/model"
+import (
+	"unicode/utf8"
+
+	"github.com/uznog/yamlist/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// StdinPath is the source path of documents read from standard input
+const StdinPath = "<stdin>"
 
 // Document represents a parsed YAML document
 type Document struct {
 	// Root is the root node of the YAML tree
+	// For multi-document streams this is a synthetic node whose children
+	// are the document roots
 	Root *model.Node
 
 	// Index is the flattened path index for searching
@@ -12,14 +22,52 @@ type Document struct {
 
 	// FilePath is the path to the source file
 	FilePath string
+
+	// Format is the syntax the document was parsed from
+	Format Format
+
+	// ParseError is the syntax error found by a tolerant parse (nil if none)
+	ParseError *ParseError
+
+	// DecodedFrom is the path of the value the document was decoded from
+	// ("" unless it was decoded); decoded documents are read-only
+	DecodedFrom string
+
+	// source is the text the document was parsed from
+	source []byte
+
+	// yamlDocs holds the yaml.v3 trees of a YAML source, one per document
+	yamlDocs []*yaml.Node
+
+	// sources maps nodes to the yaml.v3 nodes they were converted from
+	sources map[*model.Node]source
+
+	// lines indexes the lines of source, to locate nodes for edits
+	lines *lineIndex
+
+	// indent is the indentation width used when writing the document back
+	indent int
+
+	// compactSeqs is set when the source writes the items of a sequence in
+	// a map at the indentation of their key ("key:\n- item")
+	compactSeqs bool
+
+	// patches are the changes of source made by edits (see Encode)
+	patches []patch
 }
 
-// NewDocument creates a new document with the given root
+// NewDocument creates a new YAML document with the given root
 func NewDocument(root *model.Node, filePath string) *Document {
+	return newDocumentWithFormat(root, filePath, FormatYAML)
+}
+
+// newDocumentWithFormat creates a new document parsed from the given format
+func newDocumentWithFormat(root *model.Node, filePath string, format Format) *Document {
 	doc := &Document{
 		Root:     root,
 		Index:    model.NewPathIndex(),
 		FilePath: filePath,
+		Format:   format,
 	}
 	doc.buildIndex(root)
 	return doc
@@ -41,15 +89,104 @@ func (d *Document) buildIndex(node *model.Node) {
 	}
 }
 
+// IsStdin returns true if the document was read from standard input
+func (d *Document) IsStdin() bool {
+	return d.FilePath == StdinPath
+}
+
+// Source returns the text the document was parsed from
+func (d *Document) Source() []byte {
+	return d.source
+}
+
+// IsMultiDocument returns true if the document holds more than one YAML document
+func (d *Document) IsMultiDocument() bool {
+	return len(d.Root.Children) > 0 && d.Root.Children[0].IsDocument
+}
+
+// Documents returns the root node of each YAML document in the stream
+func (d *Document) Documents() []*model.Node {
+	if d.IsMultiDocument() {
+		return d.Root.Children
+	}
+	return []*model.Node{d.Root}
+}
+
 // NodeCount returns the total number of nodes in the document
 func (d *Document) NodeCount() int {
 	return d.Index.Len()
 }
 
-// FindByPath finds a node by its path string
+// NodeAt returns the deepest node whose source span contains the given
+// 1-based line and column
+// Merged keys and the contents of aliases are skipped since their source
+// lives at the anchor. Returns the root if no node contains the line.
+func (d *Document) NodeAt(line, col int) *model.Node {
+	pos := model.Position{Line: line, Column: col}
+	node := d.Root
+	for !node.IsAlias() {
+		var next *model.Node
+		for _, child := range node.Children {
+			// Several nodes can share a line (flow collections): take the
+			// last one starting at or before the column
+			if child.Inherited || !child.ContainsLine(line) || pos.Before(child.StartPos()) {
+				continue
+			}
+			next = child
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return node
+}
+
+// ByteColumn returns the 1-based byte column of a position, as Neovim
+// counts columns
+// Positions outside the source are returned unchanged.
+func (d *Document) ByteColumn(pos model.Position) int {
+	li := d.lineIndex()
+	if pos.Line < 1 || pos.Line > li.lineCount() || pos.Column < 1 {
+		return pos.Column
+	}
+	return li.offset(pos) - li.lineStart(pos.Line) + 1
+}
+
+// CharColumn converts a 1-based byte column on a line to the character
+// column positions use
+// Columns outside the source are returned unchanged.
+func (d *Document) CharColumn(line, byteCol int) int {
+	li := d.lineIndex()
+	if line < 1 || line > li.lineCount() || byteCol < 1 {
+		return byteCol
+	}
+	start := li.lineStart(line)
+	end := min(start+byteCol-1, li.lineEnd(line))
+	return utf8.RuneCount(li.data[start:end]) + 1
+}
+
+// lineIndex returns the line index of the source
+func (d *Document) lineIndex() *lineIndex {
+	if d.lines == nil {
+		d.lines = newLineIndex(d.source)
+	}
+	return d.lines
+}
+
+// FindByPath finds a node by its path string (see model.ParsePath)
 func (d *Document) FindByPath(pathStr string) *model.Node {
+	path, err := model.ParsePath(pathStr)
+	if err != nil {
+		return nil
+	}
+	return d.NodeByPath(path)
+}
+
+// NodeByPath finds a node by its path
+func (d *Document) NodeByPath(path *model.Path) *model.Node {
 	for _, entry := range d.Index.Entries() {
-		if entry.DisplayString == pathStr {
+		if entry.Path.Equal(path) {
 			return entry.Node
 		}
 	}
@@ -0,0 +1,202 @@
+package yamlparse
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/uznog/yamlist/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// source links a converted node to the yaml.v3 nodes it came from
+type source struct {
+	key   *yaml.Node // nil unless the node is a map entry
+	value *yaml.Node
+}
+
+// CanEdit returns nil if the node's value can be changed and written back
+// to the source, or an error explaining why not
+func (d *Document) CanEdit(node *model.Node) error {
+	if err := d.canEditEntry(node); err != nil {
+		return err
+	}
+	if node.IsAlias() {
+		return fmt.Errorf("value comes from alias *%s: edit the anchor", node.Alias)
+	}
+	return nil
+}
+
+// canEditEntry is CanEdit for changes to the node as a whole (moving,
+// renaming, deleting), which are allowed for aliases
+func (d *Document) canEditEntry(node *model.Node) error {
+	switch {
+	case d.DecodedFrom != "":
+		return errors.New("cannot edit a decoded value")
+	case d.Format != FormatYAML:
+		return errors.New("editing is only supported for YAML")
+	case d.IsStdin():
+		return errors.New("cannot edit a document read from stdin")
+	case d.ParseError != nil:
+		return errors.New("fix the parse error before editing")
+	}
+
+	if _, ok := d.sources[node]; !ok {
+		if node.Inherited {
+			return errors.New("key is inherited through <<: edit the anchor")
+		}
+		return errors.New("value comes from an alias: edit the anchor")
+	}
+	return nil
+}
+
+// CanEditScalar is CanEdit restricted to single-line scalar values
+func (d *Document) CanEditScalar(node *model.Node) error {
+	if err := d.CanEdit(node); err != nil {
+		return err
+	}
+	if node.Kind != model.KindScalar {
+		return errors.New("only scalar values can be edited")
+	}
+	if strings.Contains(node.ScalarValue, "\n") {
+		return errors.New("multiline values cannot be edited inline")
+	}
+	return nil
+}
+
+// SetScalar changes the value of a scalar node
+// The new value must keep the node's type: strings accept anything (and
+// stay strings), nulls take any type, floats accept integers. Only the
+// value is rewritten in the source, so Encode keeps the rest of the file.
+func (d *Document) SetScalar(node *model.Node, value string) error {
+	if err := d.CanEditScalar(node); err != nil {
+		return err
+	}
+
+	newType, err := checkScalarType(node.ScalarType, value)
+	if err != nil {
+		return err
+	}
+
+	yn := d.sources[node].value
+	yn.Value = value
+	if isCoreTag(yn.Tag) {
+		if newType == model.ScalarString {
+			// The encoder quotes strings that would read as another type
+			yn.Tag = "!!str"
+		} else {
+			yn.Tag = ""
+			yn.Style = 0
+		}
+	}
+	if err := d.writeScalar(node); err != nil {
+		return err
+	}
+
+	node.ScalarValue = value
+	node.ScalarType = newType
+	return nil
+}
+
+// checkScalarType returns the type of a new value for a node of the given
+// type, or an error if the value does not fit
+func checkScalarType(current model.ScalarType, value string) (model.ScalarType, error) {
+	plain := &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+	plain.Tag = plain.ShortTag()
+	resolved := inferScalarType(plain)
+
+	switch current {
+	case model.ScalarString:
+		return model.ScalarString, nil
+	case model.ScalarNull:
+		return resolved, nil
+	case model.ScalarFloat:
+		if resolved == model.ScalarInt {
+			return resolved, nil
+		}
+	}
+	if resolved != current {
+		return current, fmt.Errorf("invalid %s value %q", current, value)
+	}
+	return resolved, nil
+}
+
+// isCoreTag returns true for standard YAML tags (and untagged nodes),
+// as opposed to application tags like !Ref
+func isCoreTag(tag string) bool {
+	return tag == "" || strings.HasPrefix(tag, "!!")
+}
+
+// Encode writes the document back as YAML: the source with the changes
+// made by edits spliced in (see splice.go)
+func (d *Document) Encode() ([]byte, error) {
+	if d.Format != FormatYAML || d.yamlDocs == nil {
+		return nil, errors.New("only YAML documents can be written back")
+	}
+
+	data, err := d.applyPatches()
+	if err != nil {
+		return nil, err
+	}
+	if err := validate(data); err != nil {
+		return nil, fmt.Errorf("edit would break the document: %w", err)
+	}
+	return data, nil
+}
+
+// validate checks that encoded YAML parses again, e.g. that no alias was
+// moved before its anchor
+func validate(data []byte) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var yn yaml.Node
+		if err := dec.Decode(&yn); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// Reverted returns the document as parsed from its source, without the
+// edits made since
+func (d *Document) Reverted() *Document {
+	return ParseBytesTolerant(d.source, d.FilePath, d.Format)
+}
+
+// clearMergeTags drops the tag of merge keys, which yaml.v3 would
+// otherwise write as "!!merge <<"
+func clearMergeTags(yn *yaml.Node) {
+	if yn.Kind == yaml.MappingNode {
+		for i := 0; i < len(yn.Content); i += 2 {
+			if isMergeKey(yn.Content[i]) {
+				yn.Content[i].Tag = ""
+			}
+		}
+	}
+	for _, child := range yn.Content {
+		clearMergeTags(child)
+	}
+}
+
+// detectIndent returns the indentation width of the source: the smallest
+// indentation of a nested line, or 2 if there is none
+func detectIndent(lines []string) int {
+	indent := 0
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if n := indentation(line); n > 0 && (indent == 0 || n < indent) {
+			indent = n
+		}
+	}
+	if indent < 2 || indent > 8 {
+		return 2
+	}
+	return indent
+}
@@ -0,0 +1,231 @@
+package yamlparse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/uznog/yamlist/internal/model"
+)
+
+// parseEditable parses YAML as if it came from a file
+func parseEditable(t *testing.T, data string) *Document {
+	t.Helper()
+	doc, err := ParseBytes([]byte(data), "test.yaml")
+	if err != nil {
+		t.Fatalf("ParseBytes failed: %v", err)
+	}
+	return doc
+}
+
+func TestSetScalar_Encode(t *testing.T) {
+	data := "# Service config\n" +
+		"name: app # the name\n" +
+		"\n" +
+		"spec:\n" +
+		"    replicas: 3\n" +
+		"    image: nginx\n" +
+		"\n" +
+		"    debug: false\n"
+
+	doc := parseEditable(t, data)
+	edits := map[string]string{
+		"spec.replicas": "5",
+		"spec.image":    "nginx:1.25",
+		"spec.debug":    "true",
+	}
+	for path, value := range edits {
+		if err := doc.SetScalar(doc.FindByPath(path), value); err != nil {
+			t.Fatalf("SetScalar(%q, %q) failed: %v", path, value, err)
+		}
+	}
+
+	out, err := doc.Encode()
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	want := "# Service config\n" +
+		"name: app # the name\n" +
+		"\n" +
+		"spec:\n" +
+		"    replicas: 5\n" +
+		"    image: nginx:1.25\n" +
+		"\n" +
+		"    debug: true\n"
+	if string(out) != want {
+		t.Errorf("Encode() =\n%s\nwant:\n%s", out, want)
+	}
+}
+
+// podSource is a Kubernetes manifest with lists at the indentation of their
+// key and comments spaced apart from their values
+const podSource = "apiVersion: v1\n" +
+	"kind: Pod\n" +
+	"metadata:\n" +
+	"  name: web   # the name\n" +
+	"  labels:\n" +
+	"    app: web\n" +
+	"spec:\n" +
+	"  containers:\n" +
+	"  - name: nginx\n" +
+	"    image: nginx:1.25\n" +
+	"    ports:\n" +
+	"    - containerPort: 80\n" +
+	"  - name: sidecar  # helper\n" +
+	"    image: busybox\n"
+
+func TestSetScalar_KeepsLayout(t *testing.T) {
+	tests := []struct {
+		path  string
+		value string
+		line  string // The edited line of podSource
+		want  string
+	}{
+		{"metadata.name", "api", "  name: web   # the name", "  name: api   # the name"},
+		{"spec.containers[0].image", "nginx:1.27", "    image: nginx:1.25", "    image: nginx:1.27"},
+		{"spec.containers[1].name", "true", "  - name: sidecar  # helper", "  - name: \"true\"  # helper"},
+		{"spec.containers[0].ports[0].containerPort", "8080", "    - containerPort: 80", "    - containerPort: 8080"},
+	}
+
+	for _, tt := range tests {
+		doc := parseEditable(t, podSource)
+		if err := doc.SetScalar(doc.FindByPath(tt.path), tt.value); err != nil {
+			t.Fatalf("SetScalar(%q, %q) failed: %v", tt.path, tt.value, err)
+		}
+		out, err := doc.Encode()
+		if err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		want := strings.Replace(podSource, tt.line+"\n", tt.want+"\n", 1)
+		if string(out) != want {
+			t.Errorf("SetScalar(%q) wrote\n%s\nwant:\n%s", tt.path, out, want)
+		}
+	}
+}
+
+func TestSetScalar_Flow(t *testing.T) {
+	tests := []struct {
+		source, path, value, want string
+	}{
+		{"a: [x, y]\n", "a[0]", "p, q", "a: [\"p, q\", y]\n"},
+		{"a: {x: s, y: t}\n", "a.x", "p, q", "a: {x: \"p, q\", y: t}\n"},
+		{"a: [x, y] # list\n", "a[1]", "{z}", "a: [x, \"{z}\"] # list\n"},
+		{"a: [x, y]\n", "a[1]", "z", "a: [x, z]\n"},
+		{"a: x\n", "a", "p, q", "a: p, q\n"},
+	}
+
+	for _, tt := range tests {
+		doc := parseEditable(t, tt.source)
+		if err := doc.SetScalar(doc.FindByPath(tt.path), tt.value); err != nil {
+			t.Fatalf("SetScalar(%q) failed: %v", tt.path, err)
+		}
+		out, err := doc.Encode()
+		if err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		if string(out) != tt.want {
+			t.Errorf("SetScalar(%q, %q) on %q wrote %q, want %q", tt.path, tt.value, tt.source, out, tt.want)
+		}
+	}
+}
+
+func TestSetScalar_KeepsStrings(t *testing.T) {
+	doc := parseEditable(t, "version: \"1.0\"\n")
+	node := doc.FindByPath("version")
+
+	if err := doc.SetScalar(node, "123"); err != nil {
+		t.Fatalf("SetScalar failed: %v", err)
+	}
+	if node.ScalarType != model.ScalarString {
+		t.Errorf("ScalarType = %v, want string", node.ScalarType)
+	}
+
+	out, err := doc.Encode()
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	reparsed := parseEditable(t, string(out))
+	if got := reparsed.FindByPath("version"); got.ScalarType != model.ScalarString || got.ScalarValue != "123" {
+		t.Errorf("re-parsed version = %q (%v), want string \"123\"", got.ScalarValue, got.ScalarType)
+	}
+}
+
+func TestSetScalar_TypeCheck(t *testing.T) {
+	tests := []struct {
+		value   string
+		edit    string
+		wantErr bool
+	}{
+		{"3", "5", false},
+		{"3", "five", true},
+		{"1.5", "2", false},
+		{"1.5", "x", true},
+		{"true", "false", false},
+		{"true", "yes please", true},
+		{"~", "anything", false},
+		{"text", "42", false},
+	}
+
+	for _, tt := range tests {
+		doc := parseEditable(t, "key: "+tt.value+"\n")
+		err := doc.SetScalar(doc.FindByPath("key"), tt.edit)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SetScalar(%s -> %s) error = %v, wantErr %v", tt.value, tt.edit, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCanEdit(t *testing.T) {
+	data := "base: &base\n" +
+		"  port: 80\n" +
+		"copy: *base\n" +
+		"web:\n" +
+		"  <<: *base\n" +
+		"  name: web\n" +
+		"text: |\n" +
+		"  one\n" +
+		"  two\n"
+	doc := parseEditable(t, data)
+
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{"base.port", ""},
+		{"web.name", ""},
+		{"copy", "alias"},
+		{"web.port", "inherited"},
+		{"base", "only scalar"},
+		{"text", "multiline"},
+	}
+	for _, tt := range tests {
+		node := doc.FindByPath(tt.path)
+		if node == nil {
+			t.Fatalf("FindByPath(%q) returned nil", tt.path)
+		}
+		err := doc.CanEditScalar(node)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("CanEditScalar(%q) = %v, want nil", tt.path, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("CanEditScalar(%q) = %v, want an error containing %q", tt.path, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCanEdit_JSON(t *testing.T) {
+	doc, err := ParseJSON([]byte(`{"name": "app"}`), "test.json")
+	if err != nil {
+		t.Fatalf("ParseJSON failed: %v", err)
+	}
+	if err := doc.CanEdit(doc.FindByPath("name")); err == nil {
+		t.Error("CanEdit on a JSON document = nil, want an error")
+	}
+}
+
+func TestCanEdit_Decoded(t *testing.T) {
+	doc := parseEditable(t, "name: app\n")
+	doc.DecodedFrom = "data.config"
+	if err := doc.CanEdit(doc.FindByPath("name")); err == nil || !strings.Contains(err.Error(), "decoded") {
+		t.Errorf("CanEdit on a decoded document = %v, want an error", err)
+	}
+}
@@ -0,0 +1,176 @@
+package yamlparse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/uznog/yamlist/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// NodeYAML serializes a subtree as YAML
+// Subtrees of a YAML source keep their comments and styles, unless they
+// use anchors defined outside of them; aliases and merge keys are expanded
+// otherwise.
+func (d *Document) NodeYAML(node *model.Node) ([]byte, error) {
+	yn := d.sources[node].value
+	if yn == nil || node.IsAlias() || hasOutsideAlias(yn) {
+		yn = toYAMLNode(node)
+	} else {
+		yn = copyNode(yn)
+		clearMergeTags(yn)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(yn); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// hasOutsideAlias returns true if the subtree yn has an alias to an anchor
+// defined outside of it
+func hasOutsideAlias(yn *yaml.Node) bool {
+	inside := make(map[*yaml.Node]bool)
+	walkNodes(yn, func(n *yaml.Node) bool {
+		inside[n] = true
+		return true
+	})
+
+	outside := false
+	walkNodes(yn, func(n *yaml.Node) bool {
+		if n.Kind == yaml.AliasNode && !inside[n.Alias] {
+			outside = true
+		}
+		return !outside
+	})
+	return outside
+}
+
+// toYAMLNode converts a subtree back to a yaml.v3 node
+func toYAMLNode(node *model.Node) *yaml.Node {
+	switch node.Kind {
+	case model.KindMap:
+		yn := &yaml.Node{Kind: yaml.MappingNode}
+		for _, child := range node.Children {
+			if child.IsError {
+				continue
+			}
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: child.Key}
+			yn.Content = append(yn.Content, key, toYAMLNode(child))
+		}
+		return yn
+
+	case model.KindList:
+		yn := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, child := range node.Children {
+			if !child.IsError {
+				yn.Content = append(yn.Content, toYAMLNode(child))
+			}
+		}
+		return yn
+	}
+
+	switch node.ScalarType {
+	case model.ScalarString:
+		// The encoder quotes strings that would read as another type
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: node.ScalarValue}
+	case model.ScalarNull:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: node.ScalarValue}
+	}
+}
+
+// NodeJSON serializes a subtree as indented JSON, keeping the key order
+// Timestamps and numbers JSON cannot represent (like .inf) become strings.
+func NodeJSON(node *model.Node) []byte {
+	var buf bytes.Buffer
+	writeJSON(&buf, node, "")
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
+
+// writeJSON writes the JSON of node at the given indentation
+func writeJSON(buf *bytes.Buffer, node *model.Node, indent string) {
+	var children []*model.Node
+	for _, child := range node.Children {
+		if !child.IsError {
+			children = append(children, child)
+		}
+	}
+
+	switch node.Kind {
+	case model.KindMap, model.KindList:
+		start, end := "[", "]"
+		if node.Kind == model.KindMap {
+			start, end = "{", "}"
+		}
+		if len(children) == 0 {
+			buf.WriteString(start + end)
+			return
+		}
+
+		buf.WriteString(start + "\n")
+		for i, child := range children {
+			buf.WriteString(indent + "  ")
+			if node.Kind == model.KindMap {
+				buf.Write(jsonString(child.Key))
+				buf.WriteString(": ")
+			}
+			writeJSON(buf, child, indent+"  ")
+			if i < len(children)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + end)
+
+	default:
+		buf.WriteString(jsonScalar(node))
+	}
+}
+
+// jsonScalar returns the JSON literal of a scalar node
+func jsonScalar(node *model.Node) string {
+	value := node.ScalarValue
+	digits := strings.ReplaceAll(value, "_", "")
+
+	switch node.ScalarType {
+	case model.ScalarNull:
+		return "null"
+	case model.ScalarBool:
+		return strconv.FormatBool(strings.ToLower(value) == "true")
+	case model.ScalarInt:
+		base := 10
+		if len(digits) > 2 && digits[0] == '0' && strings.ContainsAny(digits[1:2], "xXoObB") {
+			base = 0
+		}
+		if n, err := strconv.ParseInt(digits, base, 64); err == nil {
+			return strconv.FormatInt(n, 10)
+		}
+	case model.ScalarFloat:
+		if f, err := strconv.ParseFloat(digits, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+	}
+	return string(jsonString(value))
+}
+
+// jsonString returns s as a JSON string, without escaping HTML characters
+func jsonString(s string) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
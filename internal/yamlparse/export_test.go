@@ -0,0 +1,100 @@
+package yamlparse
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNodeYAML_KeepsComments(t *testing.T) {
+	doc := parseEditable(t, "spec:\n  # replicas\n  replicas: 3\n  image: \"nginx\"\n")
+
+	out, err := doc.NodeYAML(doc.FindByPath("spec"))
+	if err != nil {
+		t.Fatalf("NodeYAML failed: %v", err)
+	}
+	want := "# replicas\nreplicas: 3\nimage: \"nginx\"\n"
+	if string(out) != want {
+		t.Errorf("NodeYAML() =\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestNodeYAML_ExpandsOutsideAliases(t *testing.T) {
+	doc := parseEditable(t, "base: &base\n  port: 80\nweb:\n  <<: *base\n  name: web\n")
+
+	out, err := doc.NodeYAML(doc.FindByPath("web"))
+	if err != nil {
+		t.Fatalf("NodeYAML failed: %v", err)
+	}
+	want := "port: 80\nname: web\n"
+	if string(out) != want {
+		t.Errorf("NodeYAML() =\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestNodeYAML_KeepsSourceTags(t *testing.T) {
+	doc := parseEditable(t, "app:\n  base: &base\n    port: 80\n  web:\n    <<: *base\n")
+
+	out, err := doc.NodeYAML(doc.FindByPath("app"))
+	if err != nil {
+		t.Fatalf("NodeYAML failed: %v", err)
+	}
+	want := "base: &base\n  port: 80\nweb:\n  <<: *base\n"
+	if string(out) != want {
+		t.Errorf("NodeYAML() =\n%s\nwant:\n%s", out, want)
+	}
+
+	web := doc.sources[doc.FindByPath("app.web")].value
+	if tag := web.Content[0].Tag; tag != "!!merge" {
+		t.Errorf("merge key tag = %q after NodeYAML, want !!merge", tag)
+	}
+}
+
+func TestNodeYAML_JSONSource(t *testing.T) {
+	doc, err := ParseJSON([]byte(`{"a": {"version": "1.0", "n": 1, "x": null}}`), "test.json")
+	if err != nil {
+		t.Fatalf("ParseJSON failed: %v", err)
+	}
+
+	out, err := doc.NodeYAML(doc.FindByPath("a"))
+	if err != nil {
+		t.Fatalf("NodeYAML failed: %v", err)
+	}
+	want := "version: \"1.0\"\nn: 1\nx: null\n"
+	if string(out) != want {
+		t.Errorf("NodeYAML() =\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestNodeJSON(t *testing.T) {
+	data := "name: <app>\n" +
+		"replicas: 0x10\n" +
+		"ratio: 1.5\n" +
+		"limit: .inf\n" +
+		"debug: True\n" +
+		"empty: ~\n" +
+		"ports: [80, 443]\n" +
+		"labels: {}\n"
+	doc := parseEditable(t, data)
+
+	out := NodeJSON(doc.Root)
+	want := `{
+  "name": "<app>",
+  "replicas": 16,
+  "ratio": 1.5,
+  "limit": ".inf",
+  "debug": true,
+  "empty": null,
+  "ports": [
+    80,
+    443
+  ],
+  "labels": {}
+}
+`
+	if string(out) != want {
+		t.Errorf("NodeJSON() =\n%s\nwant:\n%s", out, want)
+	}
+	if !json.Valid(out) {
+		t.Error("NodeJSON() is not valid JSON")
+	}
+}
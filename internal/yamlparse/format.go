@@ -0,0 +1,113 @@
+package yamlparse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Format identifies the syntax of a source document
+type Format string
+
+const (
+	// FormatAuto detects the format from the file extension and content
+	FormatAuto Format = ""
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+	FormatTOML Format = "toml"
+)
+
+// tomlLinePattern matches a TOML table header or key/value line
+var tomlLinePattern = regexp.MustCompile(`^(\[\[?\s*[A-Za-z0-9_."' -]+\]\]?|[A-Za-z0-9_."-]+\s*=\s*\S.*)\s*(#.*)?$`)
+
+// ParseFormat converts a format name (as given on the command line) to a Format
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "", "auto":
+		return FormatAuto, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	case "json":
+		return FormatJSON, nil
+	case "toml":
+		return FormatTOML, nil
+	default:
+		return "", fmt.Errorf("unknown format %q", name)
+	}
+}
+
+// DetectFormat picks the format of a source from its file extension,
+// falling back to sniffing the content
+func DetectFormat(path string, data []byte) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".json", ".jsonl", ".ndjson", ".geojson":
+		return FormatJSON
+	case ".toml":
+		return FormatTOML
+	}
+
+	// JSON objects and arrays are also valid YAML, so only pick JSON when
+	// the whole content actually is JSON
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		if json.Valid(trimmed) || validJSONLines(trimmed) {
+			return FormatJSON
+		}
+	}
+
+	// "key = value" and "[table]" lines are not meaningful YAML
+	if firstContentLine := firstContentLine(trimmed); tomlLinePattern.MatchString(firstContentLine) {
+		return FormatTOML
+	}
+	return FormatYAML
+}
+
+// firstContentLine returns the first line that is neither blank nor a comment
+func firstContentLine(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line
+		}
+	}
+	return ""
+}
+
+// validJSONLines returns true if every non-empty line is a JSON value
+func validJSONLines(data []byte) bool {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 && !json.Valid(line) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseBytesAs parses data in the given format
+func ParseBytesAs(data []byte, sourcePath string, format Format) (*Document, error) {
+	if format == FormatAuto {
+		format = DetectFormat(sourcePath, data)
+	}
+
+	var doc *Document
+	var err error
+	switch format {
+	case FormatJSON:
+		doc, err = ParseJSON(data, sourcePath)
+	case FormatTOML:
+		doc, err = ParseTOML(data, sourcePath)
+	default:
+		doc, err = ParseBytes(data, sourcePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc.source = data
+	return doc, nil
+}
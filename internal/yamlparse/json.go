@@ -0,0 +1,314 @@
+package yamlparse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/uznog/yamlist/internal/model"
+)
+
+// ParseJSON parses JSON data from bytes
+// Several top-level values (JSON Lines, concatenated JSON) are treated like
+// a multi-document YAML stream.
+func ParseJSON(data []byte, sourcePath string) (*Document, error) {
+	root, err := parseJSONRoot(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return newDocumentWithFormat(root, sourcePath, FormatJSON), nil
+}
+
+// parseJSONRoot parses all top-level values into a root node
+// On a syntax error it returns the tree parsed so far along with the error.
+func parseJSONRoot(data []byte) (*model.Node, error) {
+	empty := &model.Node{
+		Kind:     model.KindMap,
+		Index:    -1,
+		Path:     model.NewPath(),
+		Depth:    0,
+		Children: make([]*model.Node, 0),
+	}
+
+	p := newJSONParser(data)
+	p.skipSpace()
+	if p.offset >= len(p.data) {
+		// Empty document - create empty root
+		return empty, nil
+	}
+
+	root, err := p.parseValue("", -1, 0, model.NewPath(), nil)
+	if err != nil {
+		if root == nil {
+			root = empty
+		}
+		return root, err
+	}
+
+	p.skipSpace()
+	if p.offset >= len(p.data) {
+		return root, nil
+	}
+
+	// More values follow: parse again as a stream with one child per value
+	p = newJSONParser(data)
+	root = &model.Node{
+		Kind:     model.KindList,
+		Index:    -1,
+		Path:     model.NewPath(),
+		Depth:    0,
+		Children: make([]*model.Node, 0),
+	}
+	for {
+		p.skipSpace()
+		if p.offset >= len(p.data) {
+			return root, nil
+		}
+		index := len(root.Children)
+		value, err := p.parseValue("", -1, 1, model.NewPath().AppendDocument(index), root)
+		if value != nil {
+			value.Index = index
+			value.IsDocument = true
+			root.Children = append(root.Children, value)
+		}
+		if err != nil {
+			return root, err
+		}
+	}
+}
+
+// jsonParser is a small recursive-descent JSON parser that records the
+// source position of every key and value
+type jsonParser struct {
+	*lineIndex
+	data   []byte
+	offset int
+}
+
+// newJSONParser creates a parser for the given data
+func newJSONParser(data []byte) *jsonParser {
+	return &jsonParser{lineIndex: newLineIndex(data), data: data}
+}
+
+// errorf returns an error annotated with the current source position
+func (p *jsonParser) errorf(format string, args ...interface{}) error {
+	return errorAt(p.position(p.offset), format, args...)
+}
+
+// skipSpace skips whitespace
+func (p *jsonParser) skipSpace() {
+	for p.offset < len(p.data) {
+		switch p.data[p.offset] {
+		case ' ', '\t', '\n', '\r':
+			p.offset++
+		default:
+			return
+		}
+	}
+}
+
+// parseValue parses the value at the current offset into a node with the given path
+func (p *jsonParser) parseValue(key string, index int, depth int, path *model.Path, parent *model.Node) (*model.Node, error) {
+	p.skipSpace()
+	if p.offset >= len(p.data) {
+		return nil, p.errorf("unexpected end of input")
+	}
+
+	start := p.position(p.offset)
+	node := &model.Node{
+		Key:        key,
+		Index:      index,
+		Depth:      depth,
+		Parent:     parent,
+		LineNumber: start.Line,
+		ValuePos:   start,
+		Path:       path,
+	}
+
+	var err error
+	switch c := p.data[p.offset]; {
+	case c == '{':
+		err = p.parseObject(node)
+	case c == '[':
+		err = p.parseArray(node)
+	case c == '"':
+		node.Kind = model.KindScalar
+		node.ScalarType = model.ScalarString
+		node.ScalarValue, err = p.parseString()
+	case c == 't' || c == 'f' || c == 'n':
+		err = p.parseLiteral(node)
+	case c == '-' || (c >= '0' && c <= '9'):
+		err = p.parseNumber(node)
+	default:
+		err = p.errorf("unexpected character %q", c)
+	}
+	node.EndPos = p.position(p.offset)
+	if err != nil {
+		// Keep partially parsed objects and arrays for tolerant parsing
+		if node.IsExpandable() {
+			return node, err
+		}
+		return nil, err
+	}
+	return node, nil
+}
+
+// parseObject parses an object into a map node
+func (p *jsonParser) parseObject(node *model.Node) error {
+	node.Kind = model.KindMap
+	node.Children = make([]*model.Node, 0)
+	p.offset++ // {
+
+	p.skipSpace()
+	if p.offset < len(p.data) && p.data[p.offset] == '}' {
+		p.offset++
+		return nil
+	}
+
+	for {
+		p.skipSpace()
+		if p.offset >= len(p.data) || p.data[p.offset] != '"' {
+			return p.errorf("expected object key")
+		}
+		keyPos := p.position(p.offset)
+		key, err := p.parseString()
+		if err != nil {
+			return err
+		}
+
+		p.skipSpace()
+		if p.offset >= len(p.data) || p.data[p.offset] != ':' {
+			return p.errorf("expected ':' after object key")
+		}
+		p.offset++
+
+		child, err := p.parseValue(key, -1, node.Depth+1, node.Path.AppendKey(key), node)
+		if child != nil {
+			child.KeyPos = keyPos
+			child.LineNumber = keyPos.Line
+			node.Children = append(node.Children, child)
+		}
+		if err != nil {
+			return err
+		}
+
+		p.skipSpace()
+		if p.offset >= len(p.data) {
+			return p.errorf("unexpected end of input in object")
+		}
+		switch p.data[p.offset] {
+		case ',':
+			p.offset++
+		case '}':
+			p.offset++
+			return nil
+		default:
+			return p.errorf("expected ',' or '}' in object")
+		}
+	}
+}
+
+// parseArray parses an array into a list node
+func (p *jsonParser) parseArray(node *model.Node) error {
+	node.Kind = model.KindList
+	node.Children = make([]*model.Node, 0)
+	p.offset++ // [
+
+	p.skipSpace()
+	if p.offset < len(p.data) && p.data[p.offset] == ']' {
+		p.offset++
+		return nil
+	}
+
+	for {
+		index := len(node.Children)
+		child, err := p.parseValue("", index, node.Depth+1, node.Path.AppendIndex(index), node)
+		if child != nil {
+			node.Children = append(node.Children, child)
+		}
+		if err != nil {
+			return err
+		}
+
+		p.skipSpace()
+		if p.offset >= len(p.data) {
+			return p.errorf("unexpected end of input in array")
+		}
+		switch p.data[p.offset] {
+		case ',':
+			p.offset++
+		case ']':
+			p.offset++
+			return nil
+		default:
+			return p.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+// parseString parses a quoted string, decoding escapes
+func (p *jsonParser) parseString() (string, error) {
+	start := p.offset
+	p.offset++ // opening quote
+	for p.offset < len(p.data) {
+		switch p.data[p.offset] {
+		case '\\':
+			p.offset += 2
+		case '"':
+			p.offset++
+			var value string
+			if err := json.Unmarshal(p.data[start:p.offset], &value); err != nil {
+				p.offset = start
+				return "", p.errorf("invalid string")
+			}
+			return value, nil
+		case '\n':
+			return "", p.errorf("unterminated string")
+		default:
+			p.offset++
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// parseLiteral parses true, false or null
+func (p *jsonParser) parseLiteral(node *model.Node) error {
+	node.Kind = model.KindScalar
+	for _, literal := range []string{"true", "false", "null"} {
+		if bytes.HasPrefix(p.data[p.offset:], []byte(literal)) {
+			p.offset += len(literal)
+			node.ScalarValue = literal
+			if literal == "null" {
+				node.ScalarType = model.ScalarNull
+			} else {
+				node.ScalarType = model.ScalarBool
+			}
+			return nil
+		}
+	}
+	return p.errorf("invalid literal")
+}
+
+// parseNumber parses a number as an int or float scalar
+func (p *jsonParser) parseNumber(node *model.Node) error {
+	start := p.offset
+	for p.offset < len(p.data) && strings.IndexByte("+-0123456789.eE", p.data[p.offset]) >= 0 {
+		p.offset++
+	}
+
+	value := string(p.data[start:p.offset])
+	if !json.Valid([]byte(value)) {
+		p.offset = start
+		return p.errorf("invalid number %q", value)
+	}
+
+	node.Kind = model.KindScalar
+	node.ScalarValue = value
+	if strings.ContainsAny(value, ".eE") {
+		node.ScalarType = model.ScalarFloat
+	} else {
+		node.ScalarType = model.ScalarInt
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package yamlparse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/uznog/yamlist/internal/model"
+)
+
+func TestParseFile_JSON(t *testing.T) {
+	doc, err := ParseFile("../../testdata/package.json")
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	if doc.Format != FormatJSON {
+		t.Errorf("Expected JSON format, got %q", doc.Format)
+	}
+
+	tests := []struct {
+		path       string
+		kind       model.NodeKind
+		scalarType model.ScalarType
+		value      string
+		line       int
+	}{
+		{"name", model.KindScalar, model.ScalarString, "web-app", 2},
+		{"private", model.KindScalar, model.ScalarBool, "true", 4},
+		{"scripts", model.KindMap, 0, "", 5},
+		{"scripts.test", model.KindScalar, model.ScalarString, "vitest", 7},
+		{"workspaces", model.KindList, 0, "", 9},
+		{"workspaces[1]", model.KindScalar, model.ScalarString, "apps/*", 9},
+		{"retries", model.KindScalar, model.ScalarInt, "3", 13},
+		{"ratio", model.KindScalar, model.ScalarFloat, "0.75", 14},
+		{"homepage", model.KindScalar, model.ScalarNull, "null", 15},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			node := doc.FindByPath(tt.path)
+			if node == nil {
+				t.Fatalf("FindByPath(%q) returned nil", tt.path)
+			}
+			if node.Kind != tt.kind {
+				t.Errorf("Kind = %v, want %v", node.Kind, tt.kind)
+			}
+			if tt.kind == model.KindScalar {
+				if node.ScalarType != tt.scalarType {
+					t.Errorf("ScalarType = %v, want %v", node.ScalarType, tt.scalarType)
+				}
+				if node.ScalarValue != tt.value {
+					t.Errorf("ScalarValue = %q, want %q", node.ScalarValue, tt.value)
+				}
+			}
+			if node.LineNumber != tt.line {
+				t.Errorf("LineNumber = %d, want %d", node.LineNumber, tt.line)
+			}
+		})
+	}
+
+	scripts := doc.FindByPath("scripts")
+	if scripts.KeyPos != (model.Position{Line: 5, Column: 3}) {
+		t.Errorf("Unexpected key position %+v", scripts.KeyPos)
+	}
+	if scripts.EndPos != (model.Position{Line: 8, Column: 4}) {
+		t.Errorf("Unexpected end position %+v", scripts.EndPos)
+	}
+}
+
+func TestParseJSON_Lines(t *testing.T) {
+	doc, err := ParseJSON([]byte("{\"id\": 1}\n{\"id\": 2}\n"), "<string>")
+	if err != nil {
+		t.Fatalf("ParseJSON failed: %v", err)
+	}
+
+	if !doc.IsMultiDocument() || len(doc.Documents()) != 2 {
+		t.Fatalf("Expected 2 documents")
+	}
+
+	id := doc.FindByPath("#1.id")
+	if id == nil || id.ScalarValue != "2" || id.LineNumber != 2 {
+		t.Errorf("Unexpected node for '#1.id': %+v", id)
+	}
+}
+
+func TestParseJSON_Positions(t *testing.T) {
+	doc, err := ParseJSON([]byte(`{"größe": "über", "n": [true, null],`+"\n"+`"ä": 1}`), "<string>")
+	if err != nil {
+		t.Fatalf("ParseJSON failed: %v", err)
+	}
+
+	tests := []struct {
+		path      string
+		key, want model.Position
+	}{
+		{"größe", model.Position{Line: 1, Column: 2}, model.Position{Line: 1, Column: 11}},
+		{"n", model.Position{Line: 1, Column: 19}, model.Position{Line: 1, Column: 24}},
+		{"n[1]", model.Position{}, model.Position{Line: 1, Column: 31}},
+		{"ä", model.Position{Line: 2, Column: 1}, model.Position{Line: 2, Column: 6}},
+	}
+
+	for _, tt := range tests {
+		node := doc.FindByPath(tt.path)
+		if node == nil {
+			t.Fatalf("FindByPath(%q) returned nil", tt.path)
+		}
+		if node.KeyPos != tt.key || node.ValuePos != tt.want {
+			t.Errorf("%s at key %v, value %v, want %v, %v", tt.path, node.KeyPos, node.ValuePos, tt.key, tt.want)
+		}
+	}
+}
+
+func TestParseJSON_Errors(t *testing.T) {
+	tests := []struct {
+		input    string
+		contains string
+	}{
+		{`{"a": }`, "line 1, column 7"},
+		{"{\n  \"a\": 1,\n  \"b\" 2\n}", "line 3, column 7"},
+		{`[1, 2`, "unexpected end of input"},
+		{`{"a": tru}`, "invalid literal"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseJSON([]byte(tt.input), "<string>")
+		if err == nil {
+			t.Errorf("ParseJSON(%q): expected error", tt.input)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.contains) {
+			t.Errorf("ParseJSON(%q): expected error containing %q, got %q", tt.input, tt.contains, err)
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		path     string
+		data     string
+		expected Format
+	}{
+		{"config.json", "", FormatJSON},
+		{"config.yaml", `{"a": 1}`, FormatYAML},
+		{"config.yml", "", FormatYAML},
+		{"<stdin>", `{"a": 1}`, FormatJSON},
+		{"<stdin>", "[1, 2]", FormatJSON},
+		{"<stdin>", "{\"a\": 1}\n{\"a\": 2}\n", FormatJSON},
+		{"<stdin>", "{a: 1}", FormatYAML},
+		{"<stdin>", "a: 1", FormatYAML},
+		{"Cargo.toml", "", FormatTOML},
+		{"<stdin>", "# settings\n[tool.black]\nline-length = 88\n", FormatTOML},
+		{"<stdin>", "name = \"demo\"\n", FormatTOML},
+	}
+
+	for _, tt := range tests {
+		if got := DetectFormat(tt.path, []byte(tt.data)); got != tt.expected {
+			t.Errorf("DetectFormat(%q, %q) = %q, want %q", tt.path, tt.data, got, tt.expected)
+		}
+	}
+}
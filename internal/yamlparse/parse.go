@@ -1,45 +1,132 @@
 package yamlparse
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/uznog/yamlist/internal/model"
 	"gopkg.in/yaml.v3"
 )
 
-// ParseFile parses a YAML file and returns a Document
+// ParseFile parses a YAML, JSON or TOML file and returns a Document
+// The format is detected from the file extension and content
 func ParseFile(path string) (*Document, error) {
+	return ParseFileAs(path, FormatAuto)
+}
+
+// ParseFileAs parses a file in the given format and returns a Document
+func ParseFileAs(path string, format Format) (*Document, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	return ParseBytes(data, path)
+	return ParseBytesAs(data, path, format)
 }
 
 // ParseBytes parses YAML data from bytes
+// Every document of a multi-document stream is parsed; when there is more
+// than one, the root is a synthetic node with one child per document.
 func ParseBytes(data []byte, sourcePath string) (*Document, error) {
-	var yamlNode yaml.Node
-	if err := yaml.Unmarshal(data, &yamlNode); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	var docs []*yaml.Node
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var yamlNode yaml.Node
+		if err := decoder.Decode(&yamlNode); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		docs = append(docs, &yamlNode)
+	}
+
+	lines := strings.Split(string(data), "\n")
+	sources := make(map[*model.Node]source)
+
+	if len(docs) <= 1 {
+		var yamlNode *yaml.Node
+		if len(docs) == 1 {
+			yamlNode = docs[0]
+		}
+		root := convertDocument(yamlNode, lines, sources, 0, model.NewPath(), nil)
+		return newYAMLDocument(root, sourcePath, data, docs, sources), nil
 	}
 
-	// yaml.Unmarshal wraps the content in a document node
-	if yamlNode.Kind != yaml.DocumentNode || len(yamlNode.Content) == 0 {
+	// Multi-document stream: synthetic root with one child per document
+	root := &model.Node{
+		Kind:     model.KindList,
+		Index:    -1,
+		Path:     model.NewPath(),
+		Depth:    0,
+		Children: make([]*model.Node, 0, len(docs)),
+	}
+	for i, yamlNode := range docs {
+		child := convertDocument(yamlNode, lines, sources, 1, model.NewPath().AppendDocument(i), root)
+		child.Index = i
+		child.IsDocument = true
+		root.Children = append(root.Children, child)
+	}
+	return newYAMLDocument(root, sourcePath, data, docs, sources), nil
+}
+
+// newYAMLDocument creates a document that keeps the yaml.v3 trees it was
+// converted from, so edits can be written back
+func newYAMLDocument(root *model.Node, sourcePath string, data []byte, docs []*yaml.Node, sources map[*model.Node]source) *Document {
+	doc := NewDocument(root, sourcePath)
+	doc.source = data
+	doc.yamlDocs = docs
+	doc.sources = sources
+	doc.lines = newLineIndex(data)
+	lines := strings.Split(string(data), "\n")
+	doc.indent = detectIndent(lines)
+	doc.compactSeqs = detectCompactSequences(lines)
+	return doc
+}
+
+// convertDocument converts the content of a yaml document node
+func convertDocument(yamlNode *yaml.Node, lines []string, sources map[*model.Node]source, depth int, path *model.Path, parent *model.Node) *model.Node {
+	// yaml.v3 wraps the content in a document node
+	if yamlNode == nil || yamlNode.Kind != yaml.DocumentNode || len(yamlNode.Content) == 0 {
 		// Empty or invalid document - create empty root
-		root := &model.Node{
-			Kind:  model.KindMap,
-			Path:  model.NewPath(),
-			Depth: 0,
+		node := &model.Node{
+			Kind:   model.KindMap,
+			Index:  -1,
+			Path:   path,
+			Depth:  depth,
+			Parent: parent,
 		}
-		return NewDocument(root, sourcePath), nil
+		if yamlNode != nil {
+			node.LineNumber = yamlNode.Line
+			node.ValuePos = nodePos(yamlNode)
+			node.EndPos = node.ValuePos
+			node.HeadComment = yamlNode.HeadComment
+			node.FootComment = yamlNode.FootComment
+		}
+		return node
 	}
 
-	root := convertNode(yamlNode.Content[0], "", -1, 0, model.NewPath(), nil)
-	return NewDocument(root, sourcePath), nil
+	node := newConverter(lines, sources).convert(yamlNode.Content[0], "", -1, depth, path, parent)
+	node.HeadComment = joinComments(yamlNode.HeadComment, node.HeadComment)
+	node.FootComment = joinComments(node.FootComment, yamlNode.FootComment)
+	return node
+}
+
+// joinComments joins two comment blocks, skipping empty ones
+func joinComments(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	return a + "\n" + b
 }
 
 // ParseString parses YAML from a string
@@ -47,14 +134,70 @@ func ParseString(data string) (*Document, error) {
 	return ParseBytes([]byte(data), "<string>")
 }
 
-// convertNode recursively converts a yaml.Node to our internal Node
-func convertNode(yn *yaml.Node, key string, index int, depth int, parentPath *model.Path, parent *model.Node) *model.Node {
+// MaxAliasExpansion limits the number of nodes created while expanding
+// aliases and merge keys, protecting against "billion laughs" documents.
+// Aliases beyond the limit are shown as unexpanded references.
+const MaxAliasExpansion = 100000
+
+// converter holds the state shared while converting one YAML document
+type converter struct {
+	// lines holds the source lines, used to find where values end
+	lines []string
+
+	// anchors maps anchored yaml nodes to the model node defining them
+	anchors map[*yaml.Node]*model.Node
+
+	// expanding tracks the anchors currently being expanded (cycle detection)
+	expanding map[*yaml.Node]bool
+
+	// aliasDepth is > 0 while converting the content of an alias
+	aliasDepth int
+
+	// expanded counts nodes created while expanding aliases
+	expanded int
+
+	// sources maps converted nodes to the yaml nodes they came from
+	// Copies made while expanding aliases are not recorded.
+	sources map[*model.Node]source
+}
+
+// newConverter creates a converter for a single document
+func newConverter(lines []string, sources map[*model.Node]source) *converter {
+	return &converter{
+		lines:     lines,
+		anchors:   make(map[*yaml.Node]*model.Node),
+		expanding: make(map[*yaml.Node]bool),
+		sources:   sources,
+	}
+}
+
+// convert recursively converts a yaml.Node to our internal Node
+func (c *converter) convert(yn *yaml.Node, key string, index int, depth int, parentPath *model.Path, parent *model.Node) *model.Node {
+	if yn.Kind == yaml.AliasNode {
+		return c.convertAlias(yn, key, index, depth, parentPath, parent)
+	}
+
 	node := &model.Node{
-		Key:        key,
-		Index:      index,
-		Depth:      depth,
-		Parent:     parent,
-		LineNumber: yn.Line,
+		Key:         key,
+		Index:       index,
+		Depth:       depth,
+		Parent:      parent,
+		LineNumber:  yn.Line,
+		ValuePos:    nodePos(yn),
+		HeadComment: yn.HeadComment,
+		LineComment: yn.LineComment,
+		FootComment: yn.FootComment,
+	}
+
+	if c.aliasDepth > 0 {
+		c.expanded++
+	} else {
+		c.sources[node] = source{value: yn}
+		if yn.Anchor != "" {
+			// Only the original definition owns the anchor, not expanded copies
+			node.Anchor = yn.Anchor
+			c.anchors[yn] = node
+		}
 	}
 
 	// Build the path
@@ -76,11 +219,33 @@ func convertNode(yn *yaml.Node, key string, index int, depth int, parentPath *mo
 		node.Kind = model.KindMap
 		node.Children = make([]*model.Node, 0, len(yn.Content)/2)
 
+		// Explicit keys take precedence over merged ones
+		explicit := make(map[string]bool)
+		for i := 0; i < len(yn.Content); i += 2 {
+			if !isMergeKey(yn.Content[i]) {
+				explicit[yn.Content[i].Value] = true
+			}
+		}
+
 		// Mapping nodes have alternating key/value pairs
 		for i := 0; i < len(yn.Content); i += 2 {
 			keyNode := yn.Content[i]
 			valueNode := yn.Content[i+1]
-			child := convertNode(valueNode, keyNode.Value, -1, depth+1, node.Path, node)
+			if isMergeKey(keyNode) {
+				c.mergeInto(node, valueNode, explicit)
+				continue
+			}
+			child := c.convert(valueNode, keyNode.Value, -1, depth+1, node.Path, node)
+			if src, ok := c.sources[child]; ok {
+				src.key = keyNode
+				c.sources[child] = src
+			}
+			child.KeyPos = nodePos(keyNode)
+			child.LineNumber = keyNode.Line
+			// Comments of a map entry may be attached to the key or the value
+			child.HeadComment = joinComments(keyNode.HeadComment, child.HeadComment)
+			child.LineComment = joinComments(keyNode.LineComment, child.LineComment)
+			child.FootComment = joinComments(keyNode.FootComment, child.FootComment)
 			node.Children = append(node.Children, child)
 		}
 
@@ -89,24 +254,263 @@ func convertNode(yn *yaml.Node, key string, index int, depth int, parentPath *mo
 		node.Children = make([]*model.Node, 0, len(yn.Content))
 
 		for i, item := range yn.Content {
-			child := convertNode(item, "", i, depth+1, node.Path, node)
+			child := c.convert(item, "", i, depth+1, node.Path, node)
 			node.Children = append(node.Children, child)
 		}
+	}
+
+	node.EndPos = c.endPos(node, yn)
+	return node
+}
 
-	case yaml.AliasNode:
-		// Resolve aliases by following the reference
-		if yn.Alias != nil {
-			return convertNode(yn.Alias, key, index, depth, parentPath, parent)
+// convertAlias converts an alias by expanding the anchored node it refers to.
+// Cyclic aliases and aliases beyond MaxAliasExpansion are not expanded.
+func (c *converter) convertAlias(yn *yaml.Node, key string, index int, depth int, parentPath *model.Path, parent *model.Node) *model.Node {
+	target := yn.Alias
+	var node *model.Node
+	if target == nil || c.expanding[target] || c.expanded >= MaxAliasExpansion {
+		node = &model.Node{
+			Key:         key,
+			Index:       index,
+			Depth:       depth,
+			Parent:      parent,
+			LineNumber:  yn.Line,
+			ValuePos:    nodePos(yn),
+			EndPos:      aliasEnd(yn),
+			Kind:        model.KindScalar,
+			ScalarType:  model.ScalarString,
+			Alias:       yn.Value,
+			AliasTarget: c.anchors[target],
 		}
-		// Fallback for unresolved alias
-		node.Kind = model.KindScalar
-		node.ScalarValue = "(alias)"
-		node.ScalarType = model.ScalarString
+		switch {
+		case target == nil:
+			node.ScalarValue = "(unresolved alias)"
+		case c.expanding[target]:
+			node.ScalarValue = "(cyclic alias)"
+		default:
+			node.ScalarValue = "(alias not expanded)"
+		}
+		if key != "" {
+			node.Path = parentPath.AppendKey(key)
+		} else if index >= 0 {
+			node.Path = parentPath.AppendIndex(index)
+		} else {
+			node.Path = parentPath
+		}
+	} else {
+		c.expanding[target] = true
+		c.aliasDepth++
+		node = c.convert(target, key, index, depth, parentPath, parent)
+		c.aliasDepth--
+		delete(c.expanding, target)
+
+		node.Alias = yn.Value
+		node.AliasTarget = c.anchors[target]
+		node.LineNumber = yn.Line
+		node.ValuePos = nodePos(yn)
+		node.EndPos = aliasEnd(yn)
 	}
 
+	// The alias itself can be moved or deleted, unlike its expanded value
+	if c.aliasDepth == 0 {
+		c.sources[node] = source{value: yn}
+	}
 	return node
 }
 
+// mergeInto adds the keys of a merge (<<) value to node as inherited children.
+// The value is a map, an alias to a map, or a list of those; earlier sources
+// win over later ones and explicit keys win over all of them.
+func (c *converter) mergeInto(node *model.Node, value *yaml.Node, explicit map[string]bool) {
+	sources := []*yaml.Node{value}
+	if value.Kind == yaml.SequenceNode {
+		sources = value.Content
+	}
+
+	for _, source := range sources {
+		// Convert the source as a map at this node's position so the merged
+		// children get this node's paths
+		merged := c.convert(source, "", -1, node.Depth, node.Path, node)
+		if merged.Kind != model.KindMap {
+			continue
+		}
+		for _, child := range merged.Children {
+			if explicit[child.Key] {
+				continue
+			}
+			explicit[child.Key] = true
+			child.Parent = node
+			child.Inherited = true
+			if child.MergedFrom == nil {
+				child.MergedFrom = findChild(merged.AliasTarget, child.Key)
+			}
+			node.Children = append(node.Children, child)
+		}
+	}
+}
+
+// nodePos returns the source position of a yaml node
+func nodePos(yn *yaml.Node) model.Position {
+	return model.Position{Line: yn.Line, Column: yn.Column}
+}
+
+// aliasEnd returns the position just past an alias (*name)
+func aliasEnd(yn *yaml.Node) model.Position {
+	return model.Position{Line: yn.Line, Column: yn.Column + 1 + len(yn.Value)}
+}
+
+// endPos returns the position just past the end of a converted value.
+// yaml.v3 only records start positions, so the end is derived from the
+// last child for collections and from the source text for scalars.
+func (c *converter) endPos(node *model.Node, yn *yaml.Node) model.Position {
+	start := nodePos(yn)
+
+	switch yn.Kind {
+	case yaml.ScalarNode:
+		return c.scalarEnd(yn)
+
+	case yaml.MappingNode, yaml.SequenceNode:
+		end := start
+		for _, child := range node.Children {
+			// Inherited keys live elsewhere in the source
+			if !child.Inherited && end.Before(child.EndPos) {
+				end = child.EndPos
+			}
+		}
+		if yn.Style&yaml.FlowStyle != 0 {
+			closing := "]"
+			if yn.Kind == yaml.MappingNode {
+				closing = "}"
+			}
+			if pos, ok := c.find(closing, end); ok {
+				return pos
+			}
+		}
+		return end
+	}
+
+	return start
+}
+
+// scalarEnd returns the position just past the end of a scalar value
+func (c *converter) scalarEnd(yn *yaml.Node) model.Position {
+	start := nodePos(yn)
+
+	switch {
+	case yn.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+		// Block scalars span the following lines that are indented deeper
+		// than the line holding the indicator
+		if start.Line < 1 || start.Line > len(c.lines) {
+			break
+		}
+		indent := indentation(c.lines[start.Line-1])
+		end := model.Position{Line: start.Line, Column: utf8.RuneCountInString(c.lines[start.Line-1]) + 1}
+		for i := start.Line; i < len(c.lines); i++ {
+			line := c.lines[i]
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if indentation(line) <= indent {
+				break
+			}
+			end = model.Position{Line: i + 1, Column: utf8.RuneCountInString(strings.TrimRight(line, " \t\r")) + 1}
+		}
+		return end
+
+	case yn.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0:
+		if pos, ok := c.closingQuote(start, yn.Style&yaml.DoubleQuotedStyle != 0); ok {
+			return pos
+		}
+	}
+
+	value, _, _ := strings.Cut(yn.Value, "\n")
+	return model.Position{Line: start.Line, Column: start.Column + utf8.RuneCountInString(value)}
+}
+
+// closingQuote returns the position just past the quote closing the
+// quoted scalar that starts at the given position
+func (c *converter) closingQuote(start model.Position, double bool) (model.Position, bool) {
+	quote := byte('\'')
+	if double {
+		quote = '"'
+	}
+	column := start.Column + 1 // skip the opening quote
+	for line := start.Line; line >= 1 && line <= len(c.lines); line++ {
+		text := c.lines[line-1]
+		for i := byteIndex(text, column); i < len(text); i++ {
+			switch {
+			case double && text[i] == '\\':
+				i++ // skip the escaped character
+			case text[i] == quote && !double && i+1 < len(text) && text[i+1] == quote:
+				i++ // '' is an escaped single quote
+			case text[i] == quote:
+				return model.Position{Line: line, Column: charColumn(text, i+1)}, true
+			}
+		}
+		column = 1
+	}
+	return model.Position{}, false
+}
+
+// find returns the position just past the first occurrence of s at or
+// after the given position
+func (c *converter) find(s string, from model.Position) (model.Position, bool) {
+	for line := from.Line; line >= 1 && line <= len(c.lines); line++ {
+		text := c.lines[line-1]
+		offset := 0
+		if line == from.Line {
+			if from.Column < 1 {
+				continue
+			}
+			offset = byteIndex(text, from.Column)
+		}
+		if i := strings.Index(text[offset:], s); i >= 0 {
+			return model.Position{Line: line, Column: charColumn(text, offset+i+len(s))}, true
+		}
+	}
+	return model.Position{}, false
+}
+
+// byteIndex returns the byte index in text of a 1-based character column
+// Columns past the end of text map to its length.
+func byteIndex(text string, column int) int {
+	i := 0
+	for n := 1; n < column && i < len(text); n++ {
+		_, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+	}
+	return i
+}
+
+// charColumn returns the 1-based character column of a byte index in text
+func charColumn(text string, i int) int {
+	return utf8.RuneCountInString(text[:i]) + 1
+}
+
+// indentation returns the number of leading spaces of a line
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// isMergeKey returns true if a mapping key is the YAML merge key (<<)
+func isMergeKey(keyNode *yaml.Node) bool {
+	return keyNode.Kind == yaml.ScalarNode && keyNode.Value == "<<" &&
+		(keyNode.Tag == "!!merge" || keyNode.Tag == "")
+}
+
+// findChild returns the child of a map node with the given key
+func findChild(node *model.Node, key string) *model.Node {
+	if node == nil {
+		return nil
+	}
+	for _, child := range node.Children {
+		if child.Key == key {
+			return child
+		}
+	}
+	return nil
+}
+
 // inferScalarType infers the scalar type from the yaml.Node tag
 func inferScalarType(yn *yaml.Node) model.ScalarType {
 	// Check explicit tag first
@@ -1,6 +1,8 @@
 package yamlparse
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/uznog/yamlist/internal/model"
@@ -211,3 +213,406 @@ func TestInferScalarType(t *testing.T) {
 		}
 	}
 }
+
+func TestParseFile_MultiDocument(t *testing.T) {
+	doc, err := ParseFile("../../testdata/multi-doc.yaml")
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	if !doc.IsMultiDocument() {
+		t.Fatal("Expected a multi-document stream")
+	}
+
+	docs := doc.Documents()
+	if len(docs) != 3 {
+		t.Fatalf("Expected 3 documents, got %d", len(docs))
+	}
+
+	for i, d := range docs {
+		if !d.IsDocument || d.Index != i {
+			t.Errorf("Document %d: expected document node with index %d, got IsDocument=%v Index=%d", i, i, d.IsDocument, d.Index)
+		}
+		if d.Parent != doc.Root {
+			t.Errorf("Document %d: expected parent to be the stream root", i)
+		}
+	}
+
+	// Paths are prefixed with the document index
+	kind := docs[1].Children[1]
+	if kind.Path.String() != "#1.kind" {
+		t.Errorf("Expected path '#1.kind', got '%s'", kind.Path.String())
+	}
+	if kind.ScalarValue != "Deployment" {
+		t.Errorf("Expected value 'Deployment', got '%s'", kind.ScalarValue)
+	}
+
+	// Line numbers are relative to the whole stream
+	if kind.LineNumber != 9 {
+		t.Errorf("Expected line 9, got %d", kind.LineNumber)
+	}
+
+	port := doc.FindByPath("#2.spec.ports[0].port")
+	if port == nil {
+		t.Fatal("FindByPath('#2.spec.ports[0].port') returned nil")
+	}
+	if port.LineNumber != 21 {
+		t.Errorf("Expected line 21, got %d", port.LineNumber)
+	}
+}
+
+func TestParseString_SingleDocumentHasNoPrefix(t *testing.T) {
+	doc, err := ParseString("---\nname: test\n")
+	if err != nil {
+		t.Fatalf("ParseString failed: %v", err)
+	}
+
+	if doc.IsMultiDocument() {
+		t.Error("Expected a single document")
+	}
+	if doc.Root.Children[0].Path.String() != "name" {
+		t.Errorf("Expected path 'name', got '%s'", doc.Root.Children[0].Path.String())
+	}
+}
+
+func TestParseFile_AnchorsAndMergeKeys(t *testing.T) {
+	doc, err := ParseFile("../../testdata/anchors.yaml")
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	defaults := doc.FindByPath("defaults")
+	if defaults == nil || defaults.Anchor != "defaults" {
+		t.Fatalf("Expected 'defaults' to define anchor 'defaults', got %+v", defaults)
+	}
+
+	// Merge keys are replaced by inherited children
+	test := doc.FindByPath("test")
+	if findChild(test, "<<") != nil {
+		t.Error("Merge key '<<' should not be a child")
+	}
+	keys := make([]string, 0, len(test.Children))
+	for _, child := range test.Children {
+		keys = append(keys, child.Key)
+	}
+	if got := strings.Join(keys, ","); got != "adapter,host,pool,database" {
+		t.Errorf("Expected keys 'adapter,host,pool,database', got '%s'", got)
+	}
+
+	// Explicit keys override merged ones
+	pool := doc.FindByPath("test.pool")
+	if pool.Inherited || pool.ScalarValue != "1" {
+		t.Errorf("Expected explicit pool=1, got %q (inherited=%v)", pool.ScalarValue, pool.Inherited)
+	}
+
+	host := doc.FindByPath("test.host")
+	if !host.Inherited {
+		t.Error("Expected 'test.host' to be inherited")
+	}
+	if host.AnchorDefinition() != doc.FindByPath("defaults.host") {
+		t.Error("Expected 'test.host' to point at 'defaults.host'")
+	}
+	if host.Parent != test {
+		t.Error("Expected inherited key's parent to be the merging map")
+	}
+
+	// Aliases are expanded and point to their anchor
+	tags := doc.FindByPath("service.tags")
+	if tags.Alias != "tags" || tags.Kind != model.KindList || len(tags.Children) != 2 {
+		t.Errorf("Expected expanded alias *tags with 2 items, got %+v", tags)
+	}
+	if tags.AnchorDefinition() != doc.FindByPath("tags") {
+		t.Error("Expected 'service.tags' to point at 'tags'")
+	}
+	if tags.LineNumber != 19 {
+		t.Errorf("Expected alias line 19, got %d", tags.LineNumber)
+	}
+	if tags.Children[0].Anchor != "" {
+		t.Error("Expanded copies should not own anchors")
+	}
+}
+
+func TestParseString_CyclicAlias(t *testing.T) {
+	doc, err := ParseString("a: &a\n  b: *a\n")
+	if err != nil {
+		t.Fatalf("ParseString failed: %v", err)
+	}
+
+	b := doc.FindByPath("a.b")
+	if b == nil {
+		t.Fatal("FindByPath('a.b') returned nil")
+	}
+	// One level is expanded, the nested self-reference is not
+	inner := findChild(b, "b")
+	if inner == nil || inner.Kind != model.KindScalar || inner.Alias != "a" {
+		t.Errorf("Expected cyclic alias to stop expanding, got %+v", inner)
+	}
+}
+
+func TestParseString_AliasExpansionLimit(t *testing.T) {
+	// Each level multiplies the node count by 10 ("billion laughs")
+	var b strings.Builder
+	b.WriteString("l0: &l0 [x, x, x, x, x, x, x, x, x, x]\n")
+	for i := 1; i <= 8; i++ {
+		fmt.Fprintf(&b, "l%d: &l%d [*l%d, *l%d, *l%d, *l%d, *l%d, *l%d, *l%d, *l%d, *l%d, *l%d]\n",
+			i, i, i-1, i-1, i-1, i-1, i-1, i-1, i-1, i-1, i-1, i-1)
+	}
+
+	doc, err := ParseString(b.String())
+	if err != nil {
+		t.Fatalf("ParseString failed: %v", err)
+	}
+
+	if doc.NodeCount() > 2*MaxAliasExpansion {
+		t.Errorf("Expected alias expansion to be limited, got %d nodes", doc.NodeCount())
+	}
+}
+
+func TestParseString_Comments(t *testing.T) {
+	yaml := `# Database settings
+database:
+  # Hostname of the primary
+  host: localhost # overridden in prod
+  port: 5432
+items:
+  # first item
+  - one # inline
+`
+	doc, err := ParseString(yaml)
+	if err != nil {
+		t.Fatalf("ParseString failed: %v", err)
+	}
+
+	database := doc.FindByPath("database")
+	if database.CommentText() != "Database settings" {
+		t.Errorf("Expected 'Database settings', got %q", database.CommentText())
+	}
+
+	host := doc.FindByPath("database.host")
+	if host.CommentText() != "Hostname of the primary\noverridden in prod" {
+		t.Errorf("Unexpected host comments %q", host.CommentText())
+	}
+	if host.InlineComment() != "overridden in prod" {
+		t.Errorf("Expected inline comment 'overridden in prod', got %q", host.InlineComment())
+	}
+
+	if port := doc.FindByPath("database.port"); port.HasComments() {
+		t.Errorf("Expected no comments on port, got %q", port.CommentText())
+	}
+
+	item := doc.FindByPath("items[0]")
+	if item.CommentText() != "first item\ninline" {
+		t.Errorf("Unexpected item comments %q", item.CommentText())
+	}
+}
+
+func TestParseString_Positions(t *testing.T) {
+	yaml := `spec:
+  replicas: 3
+  template:
+    name: "web"
+  script: |
+    echo one
+    echo two
+
+  ports: [80, 443]
+items:
+  - a
+  - b
+`
+	doc, err := ParseString(yaml)
+	if err != nil {
+		t.Fatalf("ParseString failed: %v", err)
+	}
+
+	pos := func(line, col int) model.Position {
+		return model.Position{Line: line, Column: col}
+	}
+
+	tests := []struct {
+		path  string
+		key   model.Position
+		value model.Position
+		end   model.Position
+	}{
+		{"spec", pos(1, 1), pos(2, 3), pos(9, 19)},
+		{"spec.replicas", pos(2, 3), pos(2, 13), pos(2, 14)},
+		{"spec.template", pos(3, 3), pos(4, 5), pos(4, 16)},
+		{"spec.template.name", pos(4, 5), pos(4, 11), pos(4, 16)},
+		{"spec.script", pos(5, 3), pos(5, 11), pos(7, 13)},
+		{"spec.ports", pos(9, 3), pos(9, 10), pos(9, 19)},
+		{"items[1]", model.Position{}, pos(12, 5), pos(12, 6)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			node := doc.FindByPath(tt.path)
+			if node == nil {
+				t.Fatalf("FindByPath(%q) returned nil", tt.path)
+			}
+			if node.KeyPos != tt.key {
+				t.Errorf("KeyPos = %+v, want %+v", node.KeyPos, tt.key)
+			}
+			if node.ValuePos != tt.value {
+				t.Errorf("ValuePos = %+v, want %+v", node.ValuePos, tt.value)
+			}
+			if node.EndPos != tt.end {
+				t.Errorf("EndPos = %+v, want %+v", node.EndPos, tt.end)
+			}
+		})
+	}
+
+	// Map entries report the key line, not the value line
+	template := doc.FindByPath("spec.template")
+	if template.LineNumber != 3 {
+		t.Errorf("Expected line 3 for 'spec.template', got %d", template.LineNumber)
+	}
+	if !template.ContainsLine(4) || template.ContainsLine(5) {
+		t.Error("Expected 'spec.template' to span lines 3-4")
+	}
+}
+
+func TestParseString_MultibyteEndPositions(t *testing.T) {
+	doc, err := ParseString("plain: größe\nquoted: \"über\" # c\nsingle: 'ä''b'\nflow: [ä, ö]\nblock: |\n  äöü\n")
+	if err != nil {
+		t.Fatalf("ParseString failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		end  model.Position
+	}{
+		{"plain", model.Position{Line: 1, Column: 13}},
+		{"quoted", model.Position{Line: 2, Column: 15}},
+		{"single", model.Position{Line: 3, Column: 15}},
+		{"flow", model.Position{Line: 4, Column: 13}},
+		{"flow[1]", model.Position{Line: 4, Column: 12}},
+		{"block", model.Position{Line: 6, Column: 6}},
+	}
+
+	for _, tt := range tests {
+		node := doc.FindByPath(tt.path)
+		if node == nil {
+			t.Fatalf("FindByPath(%q) returned nil", tt.path)
+		}
+		if node.EndPos != tt.end {
+			t.Errorf("EndPos of %s = %+v, want %+v", tt.path, node.EndPos, tt.end)
+		}
+	}
+}
+
+func TestDocument_NodeAt(t *testing.T) {
+	data := `base: &base
+  image: nginx
+app:
+  <<: *base
+  ports: [80, 443]
+  env:
+    - name: MODE
+      value: prod
+copy: *base
+`
+	doc, err := ParseBytes([]byte(data), "test.yaml")
+	if err != nil {
+		t.Fatalf("ParseBytes failed: %v", err)
+	}
+
+	tests := []struct {
+		line, col int
+		want      string
+	}{
+		{1, 1, "base"},
+		{2, 5, "base.image"},
+		{3, 1, "app"},
+		{4, 3, "app"}, // merged keys live at the anchor
+		{5, 3, "app.ports"},
+		{5, 11, "app.ports[0]"},
+		{5, 15, "app.ports[1]"},
+		{8, 7, "app.env[0].value"},
+		{9, 7, "copy"}, // alias contents live at the anchor
+		{20, 1, "(root)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d:%d", tt.line, tt.col), func(t *testing.T) {
+			node := doc.NodeAt(tt.line, tt.col)
+			if got := node.Path.String(); got != tt.want {
+				t.Errorf("NodeAt(%d, %d) = %q, want %q", tt.line, tt.col, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_ByteColumn(t *testing.T) {
+	doc, err := ParseBytes([]byte("größe: {breite: 1, höhe: 2}\n"), "test.yaml")
+	if err != nil {
+		t.Fatalf("ParseBytes failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"größe", 1},
+		{"größe.breite", 11},
+		{"größe.höhe", 22},
+	}
+
+	for _, tt := range tests {
+		node := doc.FindByPath(tt.path)
+		if node == nil {
+			t.Fatalf("FindByPath(%q) = nil", tt.path)
+		}
+		if got := doc.ByteColumn(node.StartPos()); got != tt.want {
+			t.Errorf("ByteColumn of %s at %v = %d, want %d", tt.path, node.StartPos(), got, tt.want)
+		}
+	}
+}
+
+func TestDocument_CharColumn(t *testing.T) {
+	doc, err := ParseBytes([]byte("größe: {breite: 1, höhe: 2}\n"), "test.yaml")
+	if err != nil {
+		t.Fatalf("ParseBytes failed: %v", err)
+	}
+
+	tests := []struct {
+		byteCol int
+		want    string
+	}{
+		{1, "größe"},
+		{11, "größe.breite"},
+		{21, "größe.breite"},
+		{22, "größe.höhe"},
+	}
+
+	for _, tt := range tests {
+		col := doc.CharColumn(1, tt.byteCol)
+		if got := doc.NodeAt(1, col).Path.String(); got != tt.want {
+			t.Errorf("NodeAt byte column %d (column %d) = %q, want %q", tt.byteCol, col, got, tt.want)
+		}
+	}
+}
+
+func TestParseFile_DottedKeys(t *testing.T) {
+	doc, err := ParseFile("../../testdata/dotted-keys.yaml")
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	const path = `metadata.annotations."nginx.ingress.kubernetes.io/rewrite-target"`
+	node := doc.FindByPath(path)
+	if node == nil {
+		t.Fatalf("FindByPath(%q) returned nil", path)
+	}
+	if node.ScalarValue != "/" {
+		t.Errorf("ScalarValue = %q, want %q", node.ScalarValue, "/")
+	}
+	if got := node.Path.String(); got != path {
+		t.Errorf("Path.String() = %q, want %q", got, path)
+	}
+
+	// The unquoted form is a (missing) nested path, not the annotation
+	if doc.FindByPath("metadata.annotations.nginx.ingress.kubernetes.io/rewrite-target") != nil {
+		t.Error("FindByPath of the unquoted path found a node")
+	}
+}
@@ -0,0 +1,106 @@
+package yamlparse
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"github.com/uznog/yamlist/internal/model"
+)
+
+// lineIndex converts byte offsets in a source to line/column positions
+type lineIndex struct {
+	data []byte
+
+	// lineStarts holds the byte offset at which each line starts
+	lineStarts []int
+
+	// last is the last position converted and lastOffset its offset;
+	// parsers convert offsets in order, so counting runes can resume there
+	last       model.Position
+	lastOffset int
+}
+
+// newLineIndex creates a line index for the given data
+func newLineIndex(data []byte) *lineIndex {
+	lineStarts := []int{0}
+	for i, b := range data {
+		if b == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	return &lineIndex{data: data, lineStarts: lineStarts}
+}
+
+// position converts a byte offset to a 1-based line/column position
+// Columns count characters, not bytes
+func (li *lineIndex) position(offset int) model.Position {
+	line := li.line(offset)
+	from, column := li.lineStarts[line-1], 1
+	if li.last.Line == line && li.lastOffset <= offset {
+		from, column = li.lastOffset, li.last.Column
+	}
+	column += utf8.RuneCount(li.data[from:offset])
+	li.last, li.lastOffset = model.Position{Line: line, Column: column}, offset
+	return li.last
+}
+
+// offset converts a 1-based line/column position back to a byte offset
+// Columns past the end of the line map to the end of the line.
+func (li *lineIndex) offset(pos model.Position) int {
+	if pos.Line < 1 {
+		return 0
+	}
+	if pos.Line > len(li.lineStarts) {
+		return len(li.data)
+	}
+	offset, end := li.lineStarts[pos.Line-1], li.lineEnd(pos.Line)
+	for column := 1; column < pos.Column && offset < end; column++ {
+		_, size := utf8.DecodeRune(li.data[offset:end])
+		offset += size
+	}
+	return offset
+}
+
+// line returns the 1-based line holding a byte offset
+func (li *lineIndex) line(offset int) int {
+	return sort.Search(len(li.lineStarts), func(i int) bool {
+		return li.lineStarts[i] > offset
+	})
+}
+
+// lineCount returns the number of lines
+func (li *lineIndex) lineCount() int {
+	return len(li.lineStarts)
+}
+
+// lineStart returns the byte offset at which a 1-based line starts
+func (li *lineIndex) lineStart(line int) int {
+	return li.lineStarts[line-1]
+}
+
+// lineEnd returns the byte offset at which the text of a 1-based line ends:
+// its line break (\n or \r\n), or the end of the data for the last line
+func (li *lineIndex) lineEnd(line int) int {
+	if line >= len(li.lineStarts) {
+		return len(li.data)
+	}
+	end := li.lineStarts[line] - 1
+	if end > li.lineStarts[line-1] && li.data[end-1] == '\r' {
+		end--
+	}
+	return end
+}
+
+// newline returns the line break of the data: \r\n if its first line
+// ends with one, otherwise \n
+func (li *lineIndex) newline() string {
+	if len(li.lineStarts) > 1 && li.lineEnd(1) < li.lineStarts[1]-1 {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// text returns a 1-based line without its line break
+func (li *lineIndex) text(line int) string {
+	return string(li.data[li.lineStart(line):li.lineEnd(line)])
+}
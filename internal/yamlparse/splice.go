@@ -0,0 +1,589 @@
+package yamlparse
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/uznog/yamlist/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// Edits are written back by splicing the source: each edit replaces the
+// bytes of the nodes it changes and leaves the rest of the file as it was.
+// Only the changed nodes are re-encoded through yaml.v3, at the indentation
+// they had.
+
+// patch replaces the source bytes [start, end) with text
+type patch struct {
+	start, end int
+	text       string
+}
+
+// addPatch records a change of the source
+// Line breaks in text are written as the source writes them. A later change
+// of the same bytes replaces the earlier one.
+func (d *Document) addPatch(start, end int, text string) {
+	if newline := d.lines.newline(); newline != "\n" {
+		text = strings.ReplaceAll(strings.ReplaceAll(text, newline, "\n"), "\n", newline)
+	}
+	for i, p := range d.patches {
+		if p.start == start && p.end == end && start < end {
+			d.patches[i].text = text
+			return
+		}
+	}
+	d.patches = append(d.patches, patch{start: start, end: end, text: text})
+}
+
+// applyPatches returns the source with the recorded changes applied
+func (d *Document) applyPatches() ([]byte, error) {
+	patches := append([]patch(nil), d.patches...)
+	sort.SliceStable(patches, func(i, j int) bool {
+		return patches[i].start < patches[j].start
+	})
+
+	var buf bytes.Buffer
+	pos := 0
+	for _, p := range patches {
+		if p.start < pos {
+			return nil, errors.New("overlapping edits: save before editing again")
+		}
+		buf.Write(d.source[pos:p.start])
+		buf.WriteString(p.text)
+		pos = p.end
+	}
+	buf.Write(d.source[pos:])
+	return buf.Bytes(), nil
+}
+
+// writeScalar writes the new value of a scalar node over the old one, or
+// its whole entry when the value cannot be found in the source
+// The node still holds the old value.
+func (d *Document) writeScalar(node *model.Node) error {
+	yn := d.sources[node].value
+	start, end, ok := d.scalarSpan(yn, node.ValuePos, node.ScalarValue)
+	if !ok {
+		return d.rewrite(node)
+	}
+	text, err := d.encodeScalar(node, yn)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(text, "\n") {
+		return d.rewrite(node)
+	}
+	d.addPatch(start, end, text)
+	return nil
+}
+
+// writeKey writes the new key of a map entry over the old one
+// The node still holds the old key.
+func (d *Document) writeKey(node *model.Node) error {
+	keyNode := d.sources[node].key
+	start, end, ok := d.scalarSpan(keyNode, node.KeyPos, node.Key)
+	if !ok {
+		return d.rewrite(node)
+	}
+	text, err := d.encodeScalar(node, keyNode)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(text, "\n") {
+		return d.rewrite(node)
+	}
+	d.addPatch(start, end, text)
+	return nil
+}
+
+// encodeScalar encodes the scalar yn (the key or value of node) for its
+// place in the source
+// yaml.v3 writes scalars for a block context: in a flow collection, text
+// holding flow indicators or # is double-quoted so it stays one scalar.
+func (d *Document) encodeScalar(node *model.Node, yn *yaml.Node) (string, error) {
+	c := withoutComments(yn)
+	text, err := d.encode(c)
+	if err != nil || !strings.ContainsAny(text, ",[]{}#") {
+		return text, err
+	}
+	if parent, ok := d.sources[node.Parent]; !ok || parent.value == nil || !isFlow(parent.value) {
+		return text, nil
+	}
+	c.Style = yaml.DoubleQuotedStyle
+	return d.encode(c)
+}
+
+// writeInsert writes nodes added to parent after the entry of after, at
+// its indentation
+// Flow collections and parents without an entry to follow are rewritten.
+func (d *Document) writeInsert(parent, after *model.Node, nodes []*yaml.Node) error {
+	yn := d.sources[parent].value
+	if after == nil || isFlow(yn) {
+		return d.rewrite(parent)
+	}
+
+	block := &yaml.Node{Kind: yn.Kind, Content: nodes}
+	text, err := d.encode(block)
+	if err != nil {
+		return err
+	}
+	col := d.column(d.entryStart(after, false))
+	end := d.entryEnd(after)
+	d.addPatch(end, end, "\n"+strings.Repeat(" ", col)+indentText(text, col))
+	return nil
+}
+
+// writeDelete removes the entry of node from the source, along with its
+// head comment; next is the sibling that follows it (nil if none)
+func (d *Document) writeDelete(parent, node, next *model.Node) error {
+	yn := d.sources[parent].value
+	if isFlow(yn) || len(yn.Content) == 0 {
+		// An emptied collection is written as {} or []
+		return d.rewrite(parent)
+	}
+
+	start, end := d.entryStart(node, true), d.entryEnd(node)
+	line := d.lines.line(start)
+	lineStart := d.lines.lineStart(line)
+	if strings.TrimSpace(string(d.source[lineStart:start])) != "" {
+		// The entry shares its first line with its list item ("- name: x"):
+		// the next entry moves up in its place
+		if next == nil {
+			return d.rewrite(parent)
+		}
+		d.addPatch(start, d.entryStart(next, false), "")
+		return nil
+	}
+
+	if end < len(d.source) {
+		d.addPatch(lineStart, d.lines.lineStart(d.lines.line(end)+1), "")
+	} else if line > 1 {
+		d.addPatch(d.lines.lineEnd(line-1), end, "")
+	} else {
+		d.addPatch(0, end, "")
+	}
+	return nil
+}
+
+// writeDuplicate writes a copy of the list item node after it
+// The source of the item is copied as is unless it defines anchors, which
+// the copy drops.
+func (d *Document) writeDuplicate(parent, node *model.Node, copied *yaml.Node) error {
+	if isFlow(d.sources[parent].value) {
+		return d.rewrite(parent)
+	}
+
+	start, end := d.entryStart(node, false), d.entryEnd(node)
+	col := d.column(start)
+	text := string(d.source[start:end])
+	if hasAnchor(d.sources[node].value) {
+		encoded, err := d.encode(&yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{copied}})
+		if err != nil {
+			return err
+		}
+		text = indentText(encoded, col)
+	}
+	d.addPatch(end, end, "\n"+strings.Repeat(" ", col)+text)
+	return nil
+}
+
+// writeMove writes the entries of parent between positions from and to in
+// their new order; entries holds the node of each entry in the original
+// order (nil for merge keys)
+// The text between entries (blank lines, indentation) stays in place.
+func (d *Document) writeMove(parent *model.Node, entries []*model.Node, from, to int) error {
+	lo, hi := min(from, to), max(from, to)
+	if isFlow(d.sources[parent].value) {
+		return d.rewrite(parent)
+	}
+	for _, entry := range entries[lo : hi+1] {
+		if entry == nil {
+			return d.rewrite(parent)
+		}
+	}
+
+	starts := make([]int, hi-lo+1)
+	ends := make([]int, hi-lo+1)
+	for i, entry := range entries[lo : hi+1] {
+		starts[i], ends[i] = d.entryStart(entry, true), d.entryEnd(entry)
+	}
+
+	order := make([]int, 0, hi-lo+1)
+	for i := lo; i <= hi; i++ {
+		if i != from {
+			order = append(order, i-lo)
+		}
+	}
+	order = append(order[:to-lo], append([]int{from - lo}, order[to-lo:]...)...)
+
+	var b strings.Builder
+	for i, entry := range order {
+		b.Write(d.source[starts[entry]:ends[entry]])
+		if i+1 < len(order) {
+			b.Write(d.source[ends[i]:starts[i+1]])
+		}
+	}
+	d.addPatch(starts[0], ends[len(ends)-1], b.String())
+	return nil
+}
+
+// rewrite re-encodes the entry of node in place of its source
+// Nodes inside a flow collection rewrite the outermost collection.
+func (d *Document) rewrite(node *model.Node) error {
+	for !isRoot(node) && isFlow(d.sources[node.Parent].value) {
+		node = node.Parent
+	}
+	src := d.sources[node]
+
+	var start, end int
+	var content *yaml.Node
+	switch {
+	case isFlow(src.value):
+		start = d.lines.offset(node.ValuePos)
+		end = d.flowEnd(start)
+		content = withoutComments(src.value)
+	case isRoot(node):
+		start, end = d.lines.offset(node.ValuePos), d.entryEnd(node)
+		content = withoutOuterComments(src.value)
+	case src.key != nil:
+		start, end = d.entryStart(node, false), d.entryEnd(node)
+		key := *src.key
+		key.HeadComment, key.FootComment = "", ""
+		value := withoutOuterComments(src.value)
+		if value.Kind != yaml.ScalarNode && !isFlow(value) && key.LineComment == "" {
+			// yaml.v3 drops the line comment of a block collection
+			key.LineComment, value.LineComment = value.LineComment, ""
+		}
+		content = &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{&key, value}}
+	default:
+		start, end = d.entryStart(node, false), d.entryEnd(node)
+		content = &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{withoutOuterComments(src.value)}}
+	}
+
+	text, err := d.encode(content)
+	if err != nil {
+		return err
+	}
+	d.addPatch(start, end, indentText(text, d.column(start)))
+	return nil
+}
+
+// encode encodes a yaml.v3 node with the indentation of the source,
+// without the final newline
+func (d *Document) encode(yn *yaml.Node) (string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(d.indent)
+	clearMergeTags(yn)
+	if err := enc.Encode(yn); err != nil {
+		return "", fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	text := strings.TrimSuffix(buf.String(), "\n")
+	if d.compactSeqs {
+		text = compactSequences(text)
+	}
+	return text, nil
+}
+
+// scalarSpan returns the bytes of the scalar yn at pos, from its tag or
+// anchor to the end of its value, which was parsed as value
+// Returns false for values that cannot be found as written, like plain
+// scalars folded over several lines or empty values.
+func (d *Document) scalarSpan(yn *yaml.Node, pos model.Position, value string) (int, int, bool) {
+	start := d.lines.offset(pos)
+	i := start
+	for i < len(d.source) && (d.source[i] == '&' || d.source[i] == '!') {
+		for i < len(d.source) && d.source[i] != ' ' && d.source[i] != '\n' {
+			i++
+		}
+		for i < len(d.source) && d.source[i] == ' ' {
+			i++
+		}
+	}
+
+	switch {
+	case yn.Style&yaml.DoubleQuotedStyle != 0:
+		end, ok := closingQuoteAt(d.source, i, '"')
+		return start, end, ok
+	case yn.Style&yaml.SingleQuotedStyle != 0:
+		end, ok := closingQuoteAt(d.source, i, '\'')
+		return start, end, ok
+	case yn.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 || value == "":
+		return 0, 0, false
+	}
+	if !bytes.HasPrefix(d.source[i:], []byte(value)) {
+		return 0, 0, false
+	}
+	return start, i + len(value), true
+}
+
+// closingQuoteAt returns the offset just past the quote closing the quoted
+// scalar that starts at offset
+func closingQuoteAt(data []byte, offset int, quote byte) (int, bool) {
+	if offset >= len(data) || data[offset] != quote {
+		return 0, false
+	}
+	for i := offset + 1; i < len(data); i++ {
+		switch {
+		case quote == '"' && data[i] == '\\':
+			i++ // skip the escaped character
+		case quote == '\'' && data[i] == '\'' && i+1 < len(data) && data[i+1] == '\'':
+			i++ // '' is an escaped single quote
+		case data[i] == quote:
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
+
+// flowEnd returns the offset just past the flow collection that starts at
+// offset (after any tag or anchor)
+func (d *Document) flowEnd(offset int) int {
+	depth := 0
+	for i := offset; i < len(d.source); i++ {
+		switch c := d.source[i]; c {
+		case '"', '\'':
+			if end, ok := closingQuoteAt(d.source, i, c); ok {
+				i = end - 1
+			}
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(d.source)
+}
+
+// entryStart returns the offset at which the entry of node starts: its key
+// for map entries, its dash for list items
+// With comments set, the comment lines right above the entry at the same
+// indentation are included, unless the entry starts a list item.
+func (d *Document) entryStart(node *model.Node, comments bool) int {
+	var start int
+	if node.KeyPos.IsValid() {
+		start = d.lines.offset(node.KeyPos)
+	} else {
+		start = d.lines.offset(node.ValuePos)
+		if isRoot(node) {
+			return start
+		}
+		i := start - 1
+		for i >= 0 && strings.IndexByte(" \t\r\n", d.source[i]) >= 0 {
+			i--
+		}
+		if i >= 0 && d.source[i] == '-' {
+			start = i
+		}
+	}
+	if !comments {
+		return start
+	}
+
+	line := d.lines.line(start)
+	col := d.column(start)
+	if strings.TrimSpace(string(d.source[d.lines.lineStart(line):start])) != "" {
+		return start
+	}
+	for ; line > 1; line-- {
+		above := d.lines.text(line - 1)
+		if !strings.HasPrefix(strings.TrimSpace(above), "#") || indentation(above) != col {
+			break
+		}
+		start = d.lines.lineStart(line-1) + col
+	}
+	return start
+}
+
+// entryEnd returns the offset at which the entry of node ends: the end of
+// its last line, not counting the newline
+// Lines after it that are indented deeper than the entry belong to it
+// (comments, continued plain scalars).
+func (d *Document) entryEnd(node *model.Node) int {
+	last := max(node.EndPos.Line, lastLine(d.sources[node].value))
+	if node.KeyPos.IsValid() {
+		last = max(last, node.KeyPos.Line)
+	}
+
+	col := d.column(d.entryStart(node, false))
+	for line := last + 1; line <= d.lines.lineCount(); line++ {
+		text := d.lines.text(line)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if indentation(text) <= col {
+			break
+		}
+		last = line
+	}
+	return d.lines.lineEnd(last)
+}
+
+// column returns the byte column of an offset in its line, counted from 0
+func (d *Document) column(offset int) int {
+	return offset - d.lines.lineStart(d.lines.line(offset))
+}
+
+// lastLine returns the last line holding yn or one of its descendants
+func lastLine(yn *yaml.Node) int {
+	line := 0
+	walkNodes(yn, func(n *yaml.Node) bool {
+		line = max(line, n.Line)
+		return true
+	})
+	return line
+}
+
+// isRoot returns true for the root of a (possibly multi-document) YAML file
+func isRoot(node *model.Node) bool {
+	return node.Parent == nil || node.IsDocument
+}
+
+// isFlow returns true for collections written in flow style ([a, b], {a: 1})
+func isFlow(yn *yaml.Node) bool {
+	return yn.Style&yaml.FlowStyle != 0
+}
+
+// hasAnchor returns true if yn or one of its descendants defines an anchor
+func hasAnchor(yn *yaml.Node) bool {
+	found := false
+	walkNodes(yn, func(n *yaml.Node) bool {
+		found = found || n.Anchor != ""
+		return !found
+	})
+	return found
+}
+
+// withoutComments returns a copy of yn without its own comments
+func withoutComments(yn *yaml.Node) *yaml.Node {
+	c := *yn
+	c.HeadComment, c.LineComment, c.FootComment = "", "", ""
+	return &c
+}
+
+// withoutOuterComments returns a copy of yn without the comments yaml.v3
+// writes outside of its source: its head comment and the foot comments of
+// its last descendants
+func withoutOuterComments(yn *yaml.Node) *yaml.Node {
+	c := *yn
+	c.HeadComment = ""
+	return withoutFootComments(&c)
+}
+
+// withoutFootComments returns a copy of yn without the foot comments of yn
+// and its last descendants
+func withoutFootComments(yn *yaml.Node) *yaml.Node {
+	c := *yn
+	c.FootComment = ""
+	if n := len(c.Content); n > 0 {
+		c.Content = append([]*yaml.Node(nil), c.Content...)
+		c.Content[n-1] = withoutFootComments(c.Content[n-1])
+		if c.Kind == yaml.MappingNode && n >= 2 {
+			key := *c.Content[n-2]
+			key.FootComment = ""
+			c.Content[n-2] = &key
+		}
+	}
+	return &c
+}
+
+// indentText indents the lines of text after the first by col spaces
+func indentText(text string, col int) string {
+	prefix := strings.Repeat(" ", col)
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = prefix + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// compactSequences moves block sequences that are map values to the
+// indentation of their key, as in "key:\n- item"
+// yaml.v3 always indents them.
+func compactSequences(text string) string {
+	type scope struct{ indent, shift int }
+	var scopes []scope
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := indentation(line)
+		for len(scopes) > 0 && indent < scopes[len(scopes)-1].indent {
+			scopes = scopes[:len(scopes)-1]
+		}
+		shift := 0
+		for _, s := range scopes {
+			shift += s.shift
+		}
+
+		if key, ok := blockKeyIndent(line); ok {
+			if next, ok := nextItemIndent(lines[i+1:]); ok && next > key {
+				scopes = append(scopes, scope{indent: next, shift: next - key})
+			}
+		}
+		lines[i] = line[shift:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// detectCompactSequences returns true if the first block sequence in a
+// map of the source is written at the indentation of its key
+func detectCompactSequences(lines []string) bool {
+	for i, line := range lines {
+		if key, ok := blockKeyIndent(line); ok {
+			if next, ok := nextItemIndent(lines[i+1:]); ok {
+				return next == key
+			}
+		}
+	}
+	return false
+}
+
+// blockKeyIndent returns the indentation of the key of a line holding a
+// map key without a value ("key:", "- key: # comment")
+func blockKeyIndent(line string) (int, bool) {
+	text := strings.TrimSpace(line)
+	if i := strings.Index(text, " #"); i >= 0 {
+		text = strings.TrimSpace(text[:i])
+	}
+	if !strings.HasSuffix(text, ":") || strings.HasPrefix(text, "#") {
+		return 0, false
+	}
+
+	indent := indentation(line)
+	rest := line[indent:]
+	for strings.HasPrefix(rest, "- ") {
+		trimmed := strings.TrimLeft(rest[1:], " ")
+		indent += len(rest) - len(trimmed)
+		rest = trimmed
+	}
+	return indent, true
+}
+
+// nextItemIndent returns the indentation of the first line of lines that
+// is not blank or a comment, if it is a sequence item
+func nextItemIndent(lines []string) (int, bool) {
+	for _, line := range lines {
+		text := strings.TrimSpace(line)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if text == "-" || strings.HasPrefix(text, "- ") {
+			return indentation(line), true
+		}
+		return 0, false
+	}
+	return 0, false
+}
@@ -0,0 +1,345 @@
+package yamlparse
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/uznog/yamlist/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// Structural edits change the yaml.v3 tree of the document and splice the
+// changed entries into its source; the converted tree and its PathIndex are
+// stale afterwards, so callers write the document back with Encode and
+// re-parse it. Each edit returns the path of the node to select in the
+// re-parsed document.
+
+// AddChild parses entry as YAML and appends it to a map or list
+// For maps the entry must be a mapping ("key: value") of new keys; for
+// lists it becomes the last item.
+func (d *Document) AddChild(parent *model.Node, entry string) (*model.Path, error) {
+	if err := d.CanEdit(parent); err != nil {
+		return nil, err
+	}
+	if !parent.IsExpandable() {
+		return nil, errors.New("can only add to maps and lists")
+	}
+
+	yn := d.sources[parent].value
+	if len(yn.Content) == 0 {
+		// Write a formerly empty {} or [] in block style
+		yn.Style &^= yaml.FlowStyle
+	}
+	return d.insert(parent, yn, len(yn.Content), d.lastEntry(parent), entry)
+}
+
+// AddSibling parses entry as YAML and inserts it after node in its parent
+func (d *Document) AddSibling(node *model.Node, entry string) (*model.Path, error) {
+	parent, yn, pos, err := d.locate(node)
+	if err != nil {
+		return nil, err
+	}
+	step := 1
+	if yn.Kind == yaml.MappingNode {
+		step = 2
+	}
+	return d.insert(parent, yn, pos+step, node, entry)
+}
+
+// insert parses entry and inserts its nodes at pos of the parent's content,
+// writing them after the entry of after in the source
+func (d *Document) insert(parent *model.Node, yn *yaml.Node, pos int, after *model.Node, entry string) (*model.Path, error) {
+	var parsed yaml.Node
+	if err := yaml.Unmarshal([]byte(entry), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+	if len(parsed.Content) == 0 {
+		return nil, errors.New("nothing to add")
+	}
+	value := parsed.Content[0]
+
+	if yn.Kind == yaml.SequenceNode {
+		yn.Content = insertNodes(yn.Content, pos, value)
+		if err := d.writeInsert(parent, after, []*yaml.Node{value}); err != nil {
+			return nil, err
+		}
+		return parent.Path.AppendIndex(pos), nil
+	}
+
+	if value.Kind != yaml.MappingNode || len(value.Content) == 0 {
+		return nil, errors.New(`expected "key: value"`)
+	}
+	for i := 0; i < len(value.Content); i += 2 {
+		key := value.Content[i].Value
+		if findKey(yn, key) >= 0 {
+			return nil, fmt.Errorf("key %q already exists", key)
+		}
+	}
+	yn.Content = insertNodes(yn.Content, pos, value.Content...)
+	if err := d.writeInsert(parent, after, value.Content); err != nil {
+		return nil, err
+	}
+	return parent.Path.AppendKey(value.Content[0].Value), nil
+}
+
+// lastEntry returns the last child of parent written in its source, or nil
+func (d *Document) lastEntry(parent *model.Node) *model.Node {
+	for i := len(parent.Children) - 1; i >= 0; i-- {
+		if _, ok := d.sources[parent.Children[i]]; ok && !parent.Children[i].Inherited {
+			return parent.Children[i]
+		}
+	}
+	return nil
+}
+
+// Delete removes a node and its subtree from its parent
+// Returns the path of the node that takes its place: the next sibling,
+// else the previous one, else the parent.
+func (d *Document) Delete(node *model.Node) (*model.Path, error) {
+	parent, yn, pos, err := d.locate(node)
+	if err != nil {
+		return nil, err
+	}
+
+	step := 1
+	if yn.Kind == yaml.MappingNode {
+		step = 2
+	}
+	removed := yn.Content[pos+step-1]
+	if anchor := d.usedAnchor(removed); anchor != "" {
+		return nil, fmt.Errorf("anchor &%s is still used by an alias", anchor)
+	}
+
+	var next *model.Node
+	if entries := d.entries(parent, yn); pos/step+1 < len(entries) {
+		next = entries[pos/step+1]
+	}
+	yn.Content = append(yn.Content[:pos:pos], yn.Content[pos+step:]...)
+	if err := d.writeDelete(parent, node, next); err != nil {
+		return nil, err
+	}
+
+	if pos == len(yn.Content) {
+		pos -= step
+	}
+	switch {
+	case pos < 0:
+		return parent.Path, nil
+	case yn.Kind == yaml.SequenceNode:
+		return parent.Path.AppendIndex(pos), nil
+	default:
+		return parent.Path.AppendKey(yn.Content[pos].Value), nil
+	}
+}
+
+// CanRename returns nil if the node is a map entry whose key can be changed
+func (d *Document) CanRename(node *model.Node) error {
+	if err := d.canEditEntry(node); err != nil {
+		return err
+	}
+	if d.sources[node].key == nil {
+		return errors.New("only map keys can be renamed")
+	}
+	return nil
+}
+
+// Rename changes the key of a map entry
+func (d *Document) Rename(node *model.Node, key string) (*model.Path, error) {
+	if err := d.CanRename(node); err != nil {
+		return nil, err
+	}
+	keyNode := d.sources[node].key
+	if key == "" {
+		return nil, errors.New("key cannot be empty")
+	}
+	if key == keyNode.Value {
+		return node.Path, nil
+	}
+	if findKey(d.sources[node.Parent].value, key) >= 0 {
+		return nil, fmt.Errorf("key %q already exists", key)
+	}
+
+	keyNode.Value = key
+	if isCoreTag(keyNode.Tag) {
+		// Quote keys that would read as another type, like "true" or "80"
+		keyNode.Tag = "!!str"
+	}
+	if err := d.writeKey(node); err != nil {
+		return nil, err
+	}
+	return node.Parent.Path.AppendKey(key), nil
+}
+
+// Duplicate inserts a copy of a list item after it
+// Anchors are not copied, so aliases keep referring to the original.
+func (d *Document) Duplicate(node *model.Node) (*model.Path, error) {
+	parent, yn, pos, err := d.locate(node)
+	if err != nil {
+		return nil, err
+	}
+	if yn.Kind != yaml.SequenceNode {
+		return nil, errors.New("only list items can be duplicated")
+	}
+
+	copied := copyNode(yn.Content[pos])
+	walkNodes(copied, func(n *yaml.Node) bool {
+		n.Anchor = ""
+		return true
+	})
+	yn.Content = insertNodes(yn.Content, pos+1, copied)
+	if err := d.writeDuplicate(parent, node, copied); err != nil {
+		return nil, err
+	}
+	return parent.Path.AppendIndex(pos + 1), nil
+}
+
+// Move moves a list item or map entry by delta positions among its siblings
+func (d *Document) Move(node *model.Node, delta int) (*model.Path, error) {
+	parent, yn, pos, err := d.locate(node)
+	if err != nil {
+		return nil, err
+	}
+
+	step := 1
+	if yn.Kind == yaml.MappingNode {
+		step = 2
+	}
+	target := pos + delta*step
+	if target < 0 || target >= len(yn.Content) {
+		return nil, errors.New("cannot move any further")
+	}
+
+	entries := d.entries(parent, yn)
+	entry := append([]*yaml.Node(nil), yn.Content[pos:pos+step]...)
+	yn.Content = append(yn.Content[:pos:pos], yn.Content[pos+step:]...)
+	yn.Content = insertNodes(yn.Content, target, entry...)
+	if err := d.writeMove(parent, entries, pos/step, target/step); err != nil {
+		return nil, err
+	}
+
+	if yn.Kind == yaml.SequenceNode {
+		return parent.Path.AppendIndex(target), nil
+	}
+	return node.Path, nil
+}
+
+// locate returns the parent of an editable node, the parent's yaml.v3 node
+// and the position of the node (its key for maps) in the parent's content
+func (d *Document) locate(node *model.Node) (*model.Node, *yaml.Node, int, error) {
+	if err := d.canEditEntry(node); err != nil {
+		return nil, nil, 0, err
+	}
+	parent := node.Parent
+	if parent == nil || node.IsDocument {
+		return nil, nil, 0, errors.New("cannot change the document root")
+	}
+	if _, ok := d.sources[parent]; !ok {
+		return nil, nil, 0, errors.New("cannot change the document root")
+	}
+
+	src := d.sources[node]
+	yn := d.sources[parent].value
+	target := src.value
+	if src.key != nil {
+		target = src.key
+	}
+	for i, child := range yn.Content {
+		if child == target {
+			return parent, yn, i, nil
+		}
+	}
+	return nil, nil, 0, errors.New("node not found in its parent")
+}
+
+// entries returns the node of each entry of a parent's content, in order
+// (nil for merge keys)
+func (d *Document) entries(parent *model.Node, yn *yaml.Node) []*model.Node {
+	step := 1
+	if yn.Kind == yaml.MappingNode {
+		step = 2
+	}
+	entries := make([]*model.Node, 0, len(yn.Content)/step)
+	for i := 0; i < len(yn.Content); i += step {
+		var entry *model.Node
+		for _, child := range parent.Children {
+			src, ok := d.sources[child]
+			if ok && (src.key == yn.Content[i] || src.key == nil && src.value == yn.Content[i]) {
+				entry = child
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// usedAnchor returns the name of an anchor defined in the subtree yn that an
+// alias outside of it refers to, or ""
+func (d *Document) usedAnchor(yn *yaml.Node) string {
+	anchors := make(map[*yaml.Node]bool)
+	walkNodes(yn, func(n *yaml.Node) bool {
+		if n.Anchor != "" {
+			anchors[n] = true
+		}
+		return true
+	})
+	if len(anchors) == 0 {
+		return ""
+	}
+
+	used := ""
+	for _, doc := range d.yamlDocs {
+		walkNodes(doc, func(n *yaml.Node) bool {
+			if n == yn || used != "" {
+				return false
+			}
+			if n.Kind == yaml.AliasNode && anchors[n.Alias] {
+				used = n.Alias.Anchor
+			}
+			return true
+		})
+	}
+	return used
+}
+
+// walkNodes calls fn for yn and its descendants, skipping the children of
+// nodes for which fn returns false
+func walkNodes(yn *yaml.Node, fn func(*yaml.Node) bool) {
+	if !fn(yn) {
+		return
+	}
+	for _, child := range yn.Content {
+		walkNodes(child, fn)
+	}
+}
+
+// findKey returns the content index of key in a mapping, or -1
+func findKey(yn *yaml.Node, key string) int {
+	for i := 0; i+1 < len(yn.Content); i += 2 {
+		if !isMergeKey(yn.Content[i]) && yn.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// insertNodes inserts nodes at pos of content
+func insertNodes(content []*yaml.Node, pos int, nodes ...*yaml.Node) []*yaml.Node {
+	result := make([]*yaml.Node, 0, len(content)+len(nodes))
+	result = append(result, content[:pos]...)
+	result = append(result, nodes...)
+	return append(result, content[pos:]...)
+}
+
+// copyNode returns a deep copy of yn
+// Aliases in the copy still point at the original anchored nodes.
+func copyNode(yn *yaml.Node) *yaml.Node {
+	c := *yn
+	if yn.Kind == yaml.AliasNode {
+		return &c
+	}
+	c.Content = make([]*yaml.Node, len(yn.Content))
+	for i, child := range yn.Content {
+		c.Content[i] = copyNode(child)
+	}
+	return &c
+}
@@ -0,0 +1,408 @@
+package yamlparse
+
+import (
+	"strings"
+	"testing"
+)
+
+// structureSource is the document the structural edit tests start from
+const structureSource = "# Deployment\n" +
+	"name: app\n" +
+	"\n" +
+	"ports:\n" +
+	"  - 80\n" +
+	"  - 443 # tls\n" +
+	"env:\n" +
+	"  DEBUG: \"false\"\n" +
+	"  LEVEL: info\n"
+
+// encodeEdit applies an edit to structureSource and returns the encoded
+// result and the path to select
+func encodeEdit(t *testing.T, edit func(doc *Document) (string, error)) (string, string) {
+	t.Helper()
+	doc := parseEditable(t, structureSource)
+	path, err := edit(doc)
+	if err != nil {
+		t.Fatalf("edit failed: %v", err)
+	}
+	out, err := doc.Encode()
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	return string(out), path
+}
+
+func TestStructuralEdits(t *testing.T) {
+	tests := []struct {
+		name     string
+		edit     func(doc *Document) (string, error)
+		want     string
+		wantPath string
+	}{
+		{
+			name: "add key",
+			edit: func(doc *Document) (string, error) {
+				path, err := doc.AddChild(doc.FindByPath("env"), "PORT: 8080")
+				return path.String(), err
+			},
+			want:     structureSource + "  PORT: 8080\n",
+			wantPath: "env.PORT",
+		},
+		{
+			name: "add item after",
+			edit: func(doc *Document) (string, error) {
+				path, err := doc.AddSibling(doc.FindByPath("ports[0]"), "8080")
+				return path.String(), err
+			},
+			want: "# Deployment\nname: app\n\nports:\n" +
+				"  - 80\n  - 8080\n  - 443 # tls\n" +
+				"env:\n  DEBUG: \"false\"\n  LEVEL: info\n",
+			wantPath: "ports[1]",
+		},
+		{
+			name: "delete key",
+			edit: func(doc *Document) (string, error) {
+				path, err := doc.Delete(doc.FindByPath("env.DEBUG"))
+				return path.String(), err
+			},
+			want:     "# Deployment\nname: app\n\nports:\n  - 80\n  - 443 # tls\nenv:\n  LEVEL: info\n",
+			wantPath: "env.LEVEL",
+		},
+		{
+			name: "delete last item",
+			edit: func(doc *Document) (string, error) {
+				path, err := doc.Delete(doc.FindByPath("ports[1]"))
+				return path.String(), err
+			},
+			want:     "# Deployment\nname: app\n\nports:\n  - 80\nenv:\n  DEBUG: \"false\"\n  LEVEL: info\n",
+			wantPath: "ports[0]",
+		},
+		{
+			name: "rename",
+			edit: func(doc *Document) (string, error) {
+				path, err := doc.Rename(doc.FindByPath("env.LEVEL"), "LOG_LEVEL")
+				return path.String(), err
+			},
+			want:     "# Deployment\nname: app\n\nports:\n  - 80\n  - 443 # tls\nenv:\n  DEBUG: \"false\"\n  LOG_LEVEL: info\n",
+			wantPath: "env.LOG_LEVEL",
+		},
+		{
+			name: "duplicate",
+			edit: func(doc *Document) (string, error) {
+				path, err := doc.Duplicate(doc.FindByPath("ports[1]"))
+				return path.String(), err
+			},
+			want:     "# Deployment\nname: app\n\nports:\n  - 80\n  - 443 # tls\n  - 443 # tls\nenv:\n  DEBUG: \"false\"\n  LEVEL: info\n",
+			wantPath: "ports[2]",
+		},
+		{
+			name: "move item up",
+			edit: func(doc *Document) (string, error) {
+				path, err := doc.Move(doc.FindByPath("ports[1]"), -1)
+				return path.String(), err
+			},
+			want:     "# Deployment\nname: app\n\nports:\n  - 443 # tls\n  - 80\nenv:\n  DEBUG: \"false\"\n  LEVEL: info\n",
+			wantPath: "ports[0]",
+		},
+		{
+			name: "move key down",
+			edit: func(doc *Document) (string, error) {
+				path, err := doc.Move(doc.FindByPath("env.DEBUG"), 1)
+				return path.String(), err
+			},
+			want:     "# Deployment\nname: app\n\nports:\n  - 80\n  - 443 # tls\nenv:\n  LEVEL: info\n  DEBUG: \"false\"\n",
+			wantPath: "env.DEBUG",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, path := encodeEdit(t, tt.edit)
+			if got != tt.want {
+				t.Errorf("Encode() =\n%s\nwant:\n%s", got, tt.want)
+			}
+			if path != tt.wantPath {
+				t.Errorf("path = %q, want %q", path, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestStructuralEdits_UnindentedLists(t *testing.T) {
+	// Each edit only touches the lines of the entries it changes
+	tests := []struct {
+		name string
+		edit func(doc *Document) error
+		want string
+	}{
+		{
+			name: "add item",
+			edit: func(doc *Document) error {
+				_, err := doc.AddChild(doc.FindByPath("spec.containers"), "name: proxy\nports:\n- containerPort: 443")
+				return err
+			},
+			want: podSource + "  - name: proxy\n    ports:\n    - containerPort: 443\n",
+		},
+		{
+			name: "add nested item",
+			edit: func(doc *Document) error {
+				_, err := doc.AddChild(doc.FindByPath("spec.containers[0].ports"), "containerPort: 443")
+				return err
+			},
+			want: strings.Replace(podSource, "    - containerPort: 80\n", "    - containerPort: 80\n    - containerPort: 443\n", 1),
+		},
+		{
+			name: "delete item",
+			edit: func(doc *Document) error {
+				_, err := doc.Delete(doc.FindByPath("spec.containers[0]"))
+				return err
+			},
+			want: strings.Replace(podSource, "  - name: nginx\n    image: nginx:1.25\n    ports:\n    - containerPort: 80\n", "", 1),
+		},
+		{
+			name: "delete first key of item",
+			edit: func(doc *Document) error {
+				_, err := doc.Delete(doc.FindByPath("spec.containers[0].name"))
+				return err
+			},
+			want: strings.Replace(podSource, "  - name: nginx\n    image", "  - image", 1),
+		},
+		{
+			name: "delete only item",
+			edit: func(doc *Document) error {
+				_, err := doc.Delete(doc.FindByPath("spec.containers[0].ports[0]"))
+				return err
+			},
+			want: strings.Replace(podSource, "    ports:\n    - containerPort: 80\n", "    ports: []\n", 1),
+		},
+		{
+			name: "move item",
+			edit: func(doc *Document) error {
+				_, err := doc.Move(doc.FindByPath("spec.containers[1]"), -1)
+				return err
+			},
+			want: strings.Replace(podSource,
+				"  - name: nginx\n    image: nginx:1.25\n    ports:\n    - containerPort: 80\n  - name: sidecar  # helper\n    image: busybox\n",
+				"  - name: sidecar  # helper\n    image: busybox\n  - name: nginx\n    image: nginx:1.25\n    ports:\n    - containerPort: 80\n", 1),
+		},
+		{
+			name: "duplicate",
+			edit: func(doc *Document) error {
+				_, err := doc.Duplicate(doc.FindByPath("spec.containers[1]"))
+				return err
+			},
+			want: podSource + "  - name: sidecar  # helper\n    image: busybox\n",
+		},
+		{
+			name: "rename",
+			edit: func(doc *Document) error {
+				_, err := doc.Rename(doc.FindByPath("metadata.name"), "generateName")
+				return err
+			},
+			want: strings.Replace(podSource, "  name: web", "  generateName: web", 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseEditable(t, podSource)
+			if err := tt.edit(doc); err != nil {
+				t.Fatalf("edit failed: %v", err)
+			}
+			out, err := doc.Encode()
+			if err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("Encode() =\n%s\nwant:\n%s", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructuralEdits_Flow(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(doc *Document) error
+		want string
+	}{
+		{
+			name: "add to flow list",
+			edit: func(doc *Document) error {
+				_, err := doc.AddChild(doc.FindByPath("ports"), "8080")
+				return err
+			},
+			want: "ports: [80, 443, 8080]   # open\nenv: {}\n",
+		},
+		{
+			name: "delete from flow list",
+			edit: func(doc *Document) error {
+				_, err := doc.Delete(doc.FindByPath("ports[0]"))
+				return err
+			},
+			want: "ports: [443]   # open\nenv: {}\n",
+		},
+		{
+			name: "add to empty map",
+			edit: func(doc *Document) error {
+				_, err := doc.AddChild(doc.FindByPath("env"), "DEBUG: true")
+				return err
+			},
+			want: "ports: [80, 443]   # open\nenv:\n  DEBUG: true\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseEditable(t, "ports: [80, 443]   # open\nenv: {}\n")
+			if err := tt.edit(doc); err != nil {
+				t.Fatalf("edit failed: %v", err)
+			}
+			out, err := doc.Encode()
+			if err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("Encode() =\n%s\nwant:\n%s", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructuralEdits_CRLF(t *testing.T) {
+	const source = "a: 1\r\nb: 2\r\nl:\r\n  - x\r\n  - y\r\ne: {}\r\n"
+	tests := []struct {
+		name string
+		edit func(doc *Document) error
+		want string
+	}{
+		{
+			name: "add",
+			edit: func(doc *Document) error {
+				_, err := doc.AddChild(doc.Root, "c: 3")
+				return err
+			},
+			want: "a: 1\r\nb: 2\r\nl:\r\n  - x\r\n  - y\r\ne: {}\r\nc: 3\r\n",
+		},
+		{
+			name: "add to empty map",
+			edit: func(doc *Document) error {
+				_, err := doc.AddChild(doc.FindByPath("e"), "k: v")
+				return err
+			},
+			want: "a: 1\r\nb: 2\r\nl:\r\n  - x\r\n  - y\r\ne:\r\n  k: v\r\n",
+		},
+		{
+			name: "delete",
+			edit: func(doc *Document) error {
+				_, err := doc.Delete(doc.FindByPath("b"))
+				return err
+			},
+			want: "a: 1\r\nl:\r\n  - x\r\n  - y\r\ne: {}\r\n",
+		},
+		{
+			name: "duplicate",
+			edit: func(doc *Document) error {
+				_, err := doc.Duplicate(doc.FindByPath("l[0]"))
+				return err
+			},
+			want: "a: 1\r\nb: 2\r\nl:\r\n  - x\r\n  - x\r\n  - y\r\ne: {}\r\n",
+		},
+		{
+			name: "move",
+			edit: func(doc *Document) error {
+				_, err := doc.Move(doc.FindByPath("e"), -1)
+				return err
+			},
+			want: "a: 1\r\nb: 2\r\ne: {}\r\nl:\r\n  - x\r\n  - y\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseEditable(t, source)
+			if err := tt.edit(doc); err != nil {
+				t.Fatalf("edit failed: %v", err)
+			}
+			out, err := doc.Encode()
+			if err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("Encode() = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestRename_Flow(t *testing.T) {
+	tests := []struct {
+		key, want string
+	}{
+		{"p, q", "a: {\"p, q\": 1, y: 2}\n"},
+		{"[z]", "a: {\"[z]\": 1, y: 2}\n"},
+		{"z", "a: {z: 1, y: 2}\n"},
+	}
+
+	for _, tt := range tests {
+		doc := parseEditable(t, "a: {x: 1, y: 2}\n")
+		if _, err := doc.Rename(doc.FindByPath("a.x"), tt.key); err != nil {
+			t.Fatalf("Rename(%q) failed: %v", tt.key, err)
+		}
+		out, err := doc.Encode()
+		if err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		if string(out) != tt.want {
+			t.Errorf("Rename(%q) wrote %q, want %q", tt.key, out, tt.want)
+		}
+	}
+}
+
+func TestStructuralEdits_Errors(t *testing.T) {
+	doc := parseEditable(t, structureSource)
+
+	if _, err := doc.AddChild(doc.FindByPath("env"), "LEVEL: debug"); err == nil {
+		t.Error("AddChild with an existing key = nil, want an error")
+	}
+	if _, err := doc.AddChild(doc.FindByPath("env"), "just a value"); err == nil {
+		t.Error("AddChild of a scalar to a map = nil, want an error")
+	}
+	if _, err := doc.Rename(doc.FindByPath("env.DEBUG"), "LEVEL"); err == nil {
+		t.Error("Rename to an existing key = nil, want an error")
+	}
+	if _, err := doc.Rename(doc.FindByPath("ports[0]"), "port"); err == nil {
+		t.Error("Rename of a list item = nil, want an error")
+	}
+	if _, err := doc.Duplicate(doc.FindByPath("name")); err == nil {
+		t.Error("Duplicate of a map entry = nil, want an error")
+	}
+	if _, err := doc.Move(doc.FindByPath("ports[0]"), -1); err == nil {
+		t.Error("Move past the first item = nil, want an error")
+	}
+}
+
+func TestDelete_UsedAnchor(t *testing.T) {
+	doc := parseEditable(t, "base: &base\n  port: 80\nweb: *base\n")
+
+	if _, err := doc.Delete(doc.FindByPath("base")); err == nil {
+		t.Error("Delete of a used anchor = nil, want an error")
+	}
+	if _, err := doc.Delete(doc.FindByPath("web")); err != nil {
+		t.Errorf("Delete of an alias = %v, want nil", err)
+	}
+}
+
+func TestMove_AliasBeforeAnchor(t *testing.T) {
+	doc := parseEditable(t, "base: &base 1\nweb: *base\n")
+
+	if _, err := doc.Move(doc.FindByPath("web"), -1); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	if _, err := doc.Encode(); err == nil {
+		t.Error("Encode with an alias before its anchor = nil, want an error")
+	}
+	if reverted := doc.Reverted(); reverted.FindByPath("base").ScalarValue != "1" {
+		t.Error("Reverted() lost the original document")
+	}
+}
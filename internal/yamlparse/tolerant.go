@@ -0,0 +1,167 @@
+package yamlparse
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/uznog/yamlist/internal/model"
+)
+
+// ErrorNodeKey is the key of the pseudo-node marking a parse error
+const ErrorNodeKey = "(parse error)"
+
+// ParseFileTolerant parses a file like ParseFileAs, but recovers from
+// syntax errors. Only failures to read the file are returned as errors.
+func ParseFileTolerant(path string, format Format) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return ParseBytesTolerant(data, path, format), nil
+}
+
+// ParseBytesTolerant parses data like ParseBytesAs, but never fails:
+// on a syntax error the document holds everything parseable before the
+// error, Document.ParseError describes it, and a pseudo-node marks it.
+func ParseBytesTolerant(data []byte, sourcePath string, format Format) *Document {
+	if format == FormatAuto {
+		format = DetectFormat(sourcePath, data)
+	}
+
+	doc, err := ParseBytesAs(data, sourcePath, format)
+	if err == nil {
+		return doc
+	}
+	parseErr := AsParseError(err)
+
+	var root *model.Node
+	switch format {
+	case FormatJSON:
+		root, _ = parseJSONRoot(data)
+	case FormatTOML:
+		root, _ = parseTOMLRoot(data)
+	default:
+		root = parseYAMLPrefix(data, parseErr.Line)
+	}
+
+	addErrorNode(root, parseErr)
+	doc = newDocumentWithFormat(root, sourcePath, format)
+	doc.ParseError = parseErr
+	doc.source = data
+	return doc
+}
+
+// parseYAMLPrefix returns the tree of the largest parseable prefix of data
+// yaml.v3 sometimes reports the line where the failing construct started
+// rather than the offending line, so the search starts at errorLine and
+// moves forward while prefixes still parse, or backward until one does, in
+// doubling steps; the cut is then bisected. This keeps the number of
+// re-parses logarithmic, as it runs on every reload of a file being typed
+// into.
+func parseYAMLPrefix(data []byte, errorLine int) *model.Node {
+	// ends[n] is the length of the first n lines
+	ends := []int{0}
+	for i, b := range data {
+		if b == '\n' {
+			ends = append(ends, i+1)
+		}
+	}
+	if ends[len(ends)-1] < len(data) {
+		ends = append(ends, len(data))
+	}
+	parsePrefix := func(n int) *model.Node {
+		doc, err := ParseBytes(data[:ends[n]], "")
+		if err != nil {
+			return nil
+		}
+		return doc.Root
+	}
+
+	// good is a number of lines that parses and bad one that doesn't; no
+	// lines at all parse and the whole document doesn't
+	good, bad := 0, len(ends)-1
+	var best *model.Node
+	if errorLine > 0 && errorLine < bad {
+		if best = parsePrefix(errorLine); best != nil {
+			good = errorLine
+			for step := 1; good+step < bad; step *= 2 {
+				root := parsePrefix(good + step)
+				if root == nil {
+					bad = good + step
+					break
+				}
+				good, best = good+step, root
+			}
+		} else {
+			bad = errorLine
+			for step := 1; bad-step > 0; step *= 2 {
+				if best = parsePrefix(bad - step); best != nil {
+					good = bad - step
+					break
+				}
+				bad -= step
+			}
+		}
+	}
+	for bad-good > 1 {
+		mid := good + (bad-good)/2
+		if root := parsePrefix(mid); root != nil {
+			good, best = mid, root
+		} else {
+			bad = mid
+		}
+	}
+
+	if best == nil {
+		best = &model.Node{
+			Kind:     model.KindMap,
+			Index:    -1,
+			Path:     model.NewPath(),
+			Children: make([]*model.Node, 0),
+		}
+	}
+	return best
+}
+
+// addErrorNode appends a pseudo-node marking the parse error to the root
+// A scalar root is wrapped in a map so the error node has a place to live.
+func addErrorNode(root *model.Node, parseErr *ParseError) *model.Node {
+	if root.Kind == model.KindScalar {
+		value := *root
+		root.Kind = model.KindMap
+		root.ScalarValue = ""
+		root.Children = nil
+		value.Key = "(value)"
+		value.Parent = root
+		value.Depth = 1
+		value.Path = root.Path.AppendKey(value.Key)
+		root.Children = []*model.Node{&value}
+	}
+
+	pos := parseErr.Position()
+	if pos.Line > 0 && pos.Column == 0 {
+		pos.Column = 1
+	}
+	node := &model.Node{
+		Index:       -1,
+		Kind:        model.KindScalar,
+		ScalarValue: parseErr.Message,
+		ScalarType:  model.ScalarString,
+		Depth:       root.Depth + 1,
+		Parent:      root,
+		LineNumber:  pos.Line,
+		ValuePos:    pos,
+		EndPos:      pos,
+		IsError:     true,
+	}
+	if root.Kind == model.KindList {
+		node.Index = len(root.Children)
+		node.Path = root.Path.AppendIndex(node.Index)
+	} else {
+		node.Key = ErrorNodeKey
+		node.Path = root.Path.AppendKey(node.Key)
+	}
+	root.Children = append(root.Children, node)
+	return node
+}
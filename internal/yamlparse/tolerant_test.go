@@ -0,0 +1,158 @@
+package yamlparse
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/uznog/yamlist/internal/model"
+)
+
+// errorNode returns the parse error pseudo-node of a document
+func errorNode(t *testing.T, doc *Document) *model.Node {
+	t.Helper()
+	for _, child := range doc.Root.Children {
+		if child.IsError {
+			return child
+		}
+	}
+	t.Fatalf("document has no parse error node")
+	return nil
+}
+
+func TestParseBytesTolerant_Valid(t *testing.T) {
+	doc := ParseBytesTolerant([]byte("name: app\nreplicas: 3\n"), "valid.yaml", FormatAuto)
+	if doc.ParseError != nil {
+		t.Fatalf("ParseError = %v, want nil", doc.ParseError)
+	}
+	if len(doc.Root.Children) != 2 {
+		t.Errorf("Root has %d children, want 2", len(doc.Root.Children))
+	}
+}
+
+func TestParseBytesTolerant_YAML(t *testing.T) {
+	data := "name: app\n" +
+		"spec:\n" +
+		"  replicas: 3\n" +
+		"  image: \"nginx\n" +
+		"labels:\n" +
+		"  tier: web\n"
+
+	doc := ParseBytesTolerant([]byte(data), "broken.yaml", FormatAuto)
+	if doc.ParseError == nil {
+		t.Fatal("ParseError = nil, want an error")
+	}
+
+	for _, path := range []string{"name", "spec.replicas"} {
+		if doc.FindByPath(path) == nil {
+			t.Errorf("FindByPath(%q) returned nil, want the node parsed before the error", path)
+		}
+	}
+
+	node := errorNode(t, doc)
+	if node.Key != ErrorNodeKey {
+		t.Errorf("Key = %q, want %q", node.Key, ErrorNodeKey)
+	}
+	if node.ScalarValue != doc.ParseError.Message {
+		t.Errorf("ScalarValue = %q, want %q", node.ScalarValue, doc.ParseError.Message)
+	}
+	if node.LineNumber != doc.ParseError.Line {
+		t.Errorf("LineNumber = %d, want %d", node.LineNumber, doc.ParseError.Line)
+	}
+}
+
+func TestParseBytesTolerant_LargeYAML(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&b, "key%d:\n  value: %d\n", i, i)
+	}
+	b.WriteString("broken: [1, 2\n")
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&b, "after%d: %d\n", i, i)
+	}
+
+	doc := ParseBytesTolerant([]byte(b.String()), "broken.yaml", FormatAuto)
+	if doc.ParseError == nil {
+		t.Fatal("ParseError = nil, want an error")
+	}
+	if doc.FindByPath("key4999.value") == nil {
+		t.Error("FindByPath(\"key4999.value\") returned nil, want the last node before the error")
+	}
+	if doc.FindByPath("after0") != nil {
+		t.Error("FindByPath(\"after0\") found a node after the error")
+	}
+}
+
+func TestParseBytesTolerant_JSON(t *testing.T) {
+	data := "{\n  \"name\": \"app\",\n  \"tags\": [\"a\", \"b\",\n  \"port\": 80\n}\n"
+
+	doc := ParseBytesTolerant([]byte(data), "broken.json", FormatAuto)
+	if doc.ParseError == nil {
+		t.Fatal("ParseError = nil, want an error")
+	}
+	if doc.ParseError.Line != 4 || doc.ParseError.Column != 9 {
+		t.Errorf("ParseError at %d:%d, want 4:9", doc.ParseError.Line, doc.ParseError.Column)
+	}
+
+	if node := doc.FindByPath("name"); node == nil || node.ScalarValue != "app" {
+		t.Errorf("FindByPath(\"name\") = %v, want app", node)
+	}
+	if node := doc.FindByPath("tags[1]"); node == nil {
+		t.Error("FindByPath(\"tags[1]\") returned nil")
+	}
+	errorNode(t, doc)
+}
+
+func TestParseBytesTolerant_TOML(t *testing.T) {
+	data := "[package]\nname = \"app\"\nversion = \n"
+
+	doc := ParseBytesTolerant([]byte(data), "broken.toml", FormatAuto)
+	if doc.ParseError == nil {
+		t.Fatal("ParseError = nil, want an error")
+	}
+	if doc.ParseError.Line != 3 {
+		t.Errorf("ParseError.Line = %d, want 3", doc.ParseError.Line)
+	}
+	if doc.FindByPath("package.name") == nil {
+		t.Error("FindByPath(\"package.name\") returned nil")
+	}
+	errorNode(t, doc)
+}
+
+func TestParseBytesTolerant_TOMLSealedTable(t *testing.T) {
+	doc := ParseBytesTolerant([]byte("a = {b = 1}\n[a.c]\n"), "broken.toml", FormatAuto)
+	if doc.ParseError == nil {
+		t.Fatal("ParseError = nil, want an error")
+	}
+	if got := doc.ParseError.Position(); got != (model.Position{Line: 2, Column: 2}) {
+		t.Errorf("ParseError at %v, want 2:2", got)
+	}
+	if doc.FindByPath("a.b") == nil {
+		t.Error("FindByPath(\"a.b\") returned nil")
+	}
+	errorNode(t, doc)
+}
+
+func TestParseBytesTolerant_ListRoot(t *testing.T) {
+	doc := ParseBytesTolerant([]byte("[1, 2"), "broken.json", FormatJSON)
+	if doc.ParseError == nil {
+		t.Fatal("ParseError = nil, want an error")
+	}
+
+	node := errorNode(t, doc)
+	if doc.Root.Kind != model.KindList || node.Index != 2 {
+		t.Errorf("error node index = %d in %v root, want 2 in a list", node.Index, doc.Root.Kind)
+	}
+}
+
+func TestAsParseError_YAML(t *testing.T) {
+	_, err := ParseBytes([]byte("a: 1\nb: [\n"), "")
+	if err == nil {
+		t.Fatal("ParseBytes succeeded, want an error")
+	}
+
+	parseErr := AsParseError(err)
+	if parseErr.Line == 0 {
+		t.Errorf("ParseError.Line = 0, want the line reported by the YAML parser (%v)", err)
+	}
+}
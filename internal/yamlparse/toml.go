@@ -0,0 +1,768 @@
+package yamlparse
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/uznog/yamlist/internal/model"
+)
+
+// ParseTOML parses TOML data from bytes
+// Tables become maps, arrays of tables become lists of maps and
+// date/time values become timestamp scalars.
+func ParseTOML(data []byte, sourcePath string) (*Document, error) {
+	root, err := parseTOMLRoot(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TOML: %w", err)
+	}
+	return newDocumentWithFormat(root, sourcePath, FormatTOML), nil
+}
+
+// parseTOMLRoot parses a TOML document into a root node
+// On a syntax error it returns the tree parsed so far along with the error.
+func parseTOMLRoot(data []byte) (*model.Node, error) {
+	p := newTOMLParser(data)
+	err := p.parse()
+	setTOMLEnd(p.root)
+	return p.root, err
+}
+
+var (
+	tomlDatePattern     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	tomlDateTimePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[Tt ]\d{2}:\d{2}(:\d{2}(\.\d+)?)?([Zz]|[+-]\d{2}:\d{2})?$`)
+	tomlTimePattern     = regexp.MustCompile(`^\d{2}:\d{2}(:\d{2}(\.\d+)?)?$`)
+)
+
+// tomlKey is one part of a dotted key with its source position
+type tomlKey struct {
+	name string
+	pos  model.Position
+}
+
+// tomlSeal is how a table (or array) restricts later definitions
+type tomlSeal int
+
+const (
+	// sealedByDottedKey tables were created by dotted keys: no header may
+	// define them, though headers may define tables below them
+	sealedByDottedKey tomlSeal = iota + 1
+
+	// sealedInline values are inline tables and arrays: nothing may add
+	// to them
+	sealedInline
+)
+
+// tomlParser is a recursive-descent TOML parser that records the
+// source position of every key and value
+type tomlParser struct {
+	*lineIndex
+	data   []byte
+	offset int
+
+	// root is the top-level table
+	root *model.Node
+
+	// table is the table receiving key/value pairs
+	table *model.Node
+
+	// defined tracks tables and values that may not be redefined
+	defined map[*model.Node]bool
+
+	// sealed tracks tables and arrays that may not be extended or defined
+	// again by a later header or dotted key
+	sealed map[*model.Node]tomlSeal
+
+	// comments holds comment lines waiting to be attached to the next key
+	comments []string
+}
+
+// newTOMLParser creates a parser for the given data
+func newTOMLParser(data []byte) *tomlParser {
+	root := &model.Node{
+		Kind:     model.KindMap,
+		Index:    -1,
+		Path:     model.NewPath(),
+		Depth:    0,
+		Children: make([]*model.Node, 0),
+	}
+	return &tomlParser{
+		lineIndex: newLineIndex(data),
+		data:      data,
+		root:      root,
+		table:     root,
+		defined:   make(map[*model.Node]bool),
+		sealed:    make(map[*model.Node]tomlSeal),
+	}
+}
+
+// errorf returns an error annotated with the current source position
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	return errorAt(p.position(p.offset), format, args...)
+}
+
+// hasPrefix returns true if the data at the current offset starts with prefix
+func (p *tomlParser) hasPrefix(prefix string) bool {
+	return bytes.HasPrefix(p.data[p.offset:], []byte(prefix))
+}
+
+// parse parses the whole document line by line
+func (p *tomlParser) parse() error {
+	for {
+		p.skipBlank()
+		if p.offset >= len(p.data) {
+			return nil
+		}
+
+		switch c := p.data[p.offset]; {
+		case c == '\n' || c == '\r':
+			p.offset++
+			if len(p.comments) > 0 && p.blankLineFollows() {
+				// A blank line detaches comments from the next key
+				p.comments = nil
+			}
+		case c == '#':
+			p.comments = append(p.comments, p.readComment())
+		case c == '[':
+			if err := p.parseTableHeader(); err != nil {
+				return err
+			}
+		default:
+			if err := p.parseKeyValue(p.table, true); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// blankLineFollows returns true if the line at the current offset is empty
+func (p *tomlParser) blankLineFollows() bool {
+	for i := p.offset; i < len(p.data); i++ {
+		switch p.data[i] {
+		case ' ', '\t', '\r':
+			continue
+		case '\n':
+			return true
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// skipBlank skips spaces and tabs
+func (p *tomlParser) skipBlank() {
+	for p.offset < len(p.data) && (p.data[p.offset] == ' ' || p.data[p.offset] == '\t') {
+		p.offset++
+	}
+}
+
+// skipSpace skips whitespace, newlines and comments (inside arrays)
+func (p *tomlParser) skipSpace() {
+	for p.offset < len(p.data) {
+		switch p.data[p.offset] {
+		case ' ', '\t', '\n', '\r':
+			p.offset++
+		case '#':
+			p.readComment()
+		default:
+			return
+		}
+	}
+}
+
+// readComment reads a comment up to the end of the line
+func (p *tomlParser) readComment() string {
+	start := p.offset
+	for p.offset < len(p.data) && p.data[p.offset] != '\n' {
+		p.offset++
+	}
+	return strings.TrimRight(string(p.data[start:p.offset]), "\r")
+}
+
+// endOfLine expects only whitespace and an optional comment until the end of the line
+// It returns the comment, if any
+func (p *tomlParser) endOfLine() (string, error) {
+	p.skipBlank()
+	comment := ""
+	if p.offset < len(p.data) && p.data[p.offset] == '#' {
+		comment = p.readComment()
+	}
+	if p.offset < len(p.data) && p.data[p.offset] == '\r' {
+		p.offset++
+	}
+	if p.offset < len(p.data) && p.data[p.offset] != '\n' {
+		return "", p.errorf("expected end of line, found %q", p.data[p.offset])
+	}
+	return comment, nil
+}
+
+// takeComments returns the pending comment lines as a head comment
+func (p *tomlParser) takeComments() string {
+	comment := strings.Join(p.comments, "\n")
+	p.comments = nil
+	return comment
+}
+
+// parseTableHeader parses a [table] or [[array.of.tables]] header
+func (p *tomlParser) parseTableHeader() error {
+	start := p.position(p.offset)
+	isArray := p.hasPrefix("[[")
+	if isArray {
+		p.offset += 2
+	} else {
+		p.offset++
+	}
+
+	p.skipBlank()
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipBlank()
+
+	closing := "]"
+	if isArray {
+		closing = "]]"
+	}
+	if !p.hasPrefix(closing) {
+		return p.errorf("expected %q to close table header", closing)
+	}
+	p.offset += len(closing)
+
+	headComment := p.takeComments()
+	lineComment, err := p.endOfLine()
+	if err != nil {
+		return err
+	}
+
+	// Walk to the parent of the last key, creating implicit tables
+	parent := p.root
+	for _, key := range keys[:len(keys)-1] {
+		if parent, err = p.descend(parent, key, start, false); err != nil {
+			return err
+		}
+	}
+
+	last := keys[len(keys)-1]
+	existing := findChild(parent, last.name)
+
+	var table *model.Node
+	if isArray {
+		list := existing
+		if list == nil {
+			list = p.newChild(parent, last, start)
+			list.Kind = model.KindList
+			p.defined[list] = true
+		} else if list.Kind != model.KindList || !p.defined[list] || p.sealed[list] != 0 {
+			return errorAt(last.pos, "%q is not an array of tables", last.name)
+		}
+		table = p.newItem(list, start)
+	} else {
+		if existing == nil {
+			table = p.newChild(parent, last, start)
+		} else if p.sealed[existing] == sealedByDottedKey {
+			return errorAt(last.pos, "table %q is already defined by dotted keys", last.name)
+		} else if existing.Kind == model.KindMap && !p.defined[existing] {
+			// Implicitly created by a previous header; now defined explicitly
+			table = existing
+		} else {
+			return errorAt(last.pos, "table %q is already defined", last.name)
+		}
+	}
+
+	p.defined[table] = true
+	table.HeadComment = joinComments(table.HeadComment, headComment)
+	table.LineComment = joinComments(table.LineComment, lineComment)
+	table.EndPos = p.position(p.offset)
+	p.table = table
+	return nil
+}
+
+// descend returns the table for key below parent, creating an implicit
+// table if needed. Arrays of tables descend into their last item.
+// Dotted keys seal the tables they create and may not reopen a table
+// defined by a header.
+func (p *tomlParser) descend(parent *model.Node, key tomlKey, pos model.Position, dotted bool) (*model.Node, error) {
+	child := findChild(parent, key.name)
+	if child == nil {
+		child = p.newChild(parent, key, pos)
+		if dotted {
+			p.sealed[child] = sealedByDottedKey
+		}
+		return child, nil
+	}
+	switch {
+	case p.sealed[child] == sealedInline:
+		return nil, errorAt(key.pos, "%q is defined inline and can't be extended", key.name)
+	case dotted && child.Kind == model.KindMap && p.defined[child]:
+		return nil, errorAt(key.pos, "table %q is already defined", key.name)
+	case child.Kind == model.KindMap:
+		return child, nil
+	case child.Kind == model.KindList && p.defined[child] && len(child.Children) > 0:
+		return child.Children[len(child.Children)-1], nil
+	default:
+		return nil, errorAt(key.pos, "key %q is not a table", key.name)
+	}
+}
+
+// newChild creates an empty table under parent
+func (p *tomlParser) newChild(parent *model.Node, key tomlKey, pos model.Position) *model.Node {
+	child := &model.Node{
+		Key:        key.name,
+		Index:      -1,
+		Kind:       model.KindMap,
+		Depth:      parent.Depth + 1,
+		Parent:     parent,
+		Path:       parent.Path.AppendKey(key.name),
+		LineNumber: key.pos.Line,
+		KeyPos:     key.pos,
+		ValuePos:   pos,
+		EndPos:     pos,
+		Children:   make([]*model.Node, 0),
+	}
+	parent.Children = append(parent.Children, child)
+	return child
+}
+
+// newItem appends an empty table to an array of tables
+func (p *tomlParser) newItem(list *model.Node, pos model.Position) *model.Node {
+	index := len(list.Children)
+	item := &model.Node{
+		Index:      index,
+		Kind:       model.KindMap,
+		Depth:      list.Depth + 1,
+		Parent:     list,
+		Path:       list.Path.AppendIndex(index),
+		LineNumber: pos.Line,
+		ValuePos:   pos,
+		EndPos:     pos,
+		Children:   make([]*model.Node, 0),
+	}
+	list.Children = append(list.Children, item)
+	return item
+}
+
+// parseKey parses a possibly dotted key
+func (p *tomlParser) parseKey() ([]tomlKey, error) {
+	var keys []tomlKey
+	for {
+		p.skipBlank()
+		pos := p.position(p.offset)
+		if p.offset >= len(p.data) {
+			return nil, p.errorf("expected key")
+		}
+
+		var name string
+		var err error
+		switch p.data[p.offset] {
+		case '"':
+			name, err = p.parseBasicString()
+		case '\'':
+			name, err = p.parseLiteralString()
+		default:
+			start := p.offset
+			for p.offset < len(p.data) && isBareKeyChar(p.data[p.offset]) {
+				p.offset++
+			}
+			if p.offset == start {
+				return nil, p.errorf("invalid key character %q", p.data[p.offset])
+			}
+			name = string(p.data[start:p.offset])
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, tomlKey{name: name, pos: pos})
+
+		p.skipBlank()
+		if p.offset < len(p.data) && p.data[p.offset] == '.' {
+			p.offset++
+			continue
+		}
+		return keys, nil
+	}
+}
+
+// isBareKeyChar returns true for characters allowed in bare keys
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// parseKeyValue parses "key = value" into table
+// Top-level pairs must end the line; pairs in inline tables must not.
+func (p *tomlParser) parseKeyValue(table *model.Node, topLevel bool) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+
+	p.skipBlank()
+	if p.offset >= len(p.data) || p.data[p.offset] != '=' {
+		return p.errorf("expected '=' after key")
+	}
+	p.offset++
+	p.skipBlank()
+
+	// Dotted keys create implicit tables
+	parent := table
+	for _, key := range keys[:len(keys)-1] {
+		if parent, err = p.descend(parent, key, key.pos, true); err != nil {
+			return err
+		}
+	}
+
+	last := keys[len(keys)-1]
+	if findChild(parent, last.name) != nil {
+		return errorAt(last.pos, "key %q is already defined", last.name)
+	}
+
+	value, err := p.parseValue(last.name, -1, parent)
+	if err != nil {
+		return err
+	}
+	value.KeyPos = last.pos
+	value.LineNumber = last.pos.Line
+	parent.Children = append(parent.Children, value)
+
+	if topLevel {
+		value.HeadComment = p.takeComments()
+		if value.LineComment, err = p.endOfLine(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseValue parses a value as a child of parent
+func (p *tomlParser) parseValue(key string, index int, parent *model.Node) (*model.Node, error) {
+	if p.offset >= len(p.data) {
+		return nil, p.errorf("expected value")
+	}
+
+	start := p.position(p.offset)
+	node := &model.Node{
+		Key:        key,
+		Index:      index,
+		Depth:      parent.Depth + 1,
+		Parent:     parent,
+		LineNumber: start.Line,
+		ValuePos:   start,
+	}
+	if index >= 0 {
+		node.Path = parent.Path.AppendIndex(index)
+	} else {
+		node.Path = parent.Path.AppendKey(key)
+	}
+
+	var err error
+	switch c := p.data[p.offset]; c {
+	case '[':
+		err = p.parseArray(node)
+	case '{':
+		err = p.parseInlineTable(node)
+	case '"':
+		node.Kind = model.KindScalar
+		node.ScalarType = model.ScalarString
+		node.ScalarValue, err = p.parseBasicString()
+	case '\'':
+		node.Kind = model.KindScalar
+		node.ScalarType = model.ScalarString
+		node.ScalarValue, err = p.parseLiteralString()
+	default:
+		err = p.parseBareValue(node)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	node.EndPos = p.position(p.offset)
+	p.defined[node] = true
+	if node.Kind != model.KindScalar {
+		p.sealed[node] = sealedInline
+	}
+	return node, nil
+}
+
+// parseArray parses an array into a list node
+func (p *tomlParser) parseArray(node *model.Node) error {
+	node.Kind = model.KindList
+	node.Children = make([]*model.Node, 0)
+	p.offset++ // [
+
+	for {
+		p.skipSpace()
+		if p.offset >= len(p.data) {
+			return p.errorf("unterminated array")
+		}
+		if p.data[p.offset] == ']' {
+			p.offset++
+			return nil
+		}
+
+		child, err := p.parseValue("", len(node.Children), node)
+		if err != nil {
+			return err
+		}
+		node.Children = append(node.Children, child)
+
+		p.skipSpace()
+		if p.offset < len(p.data) && p.data[p.offset] == ',' {
+			p.offset++
+		} else if p.offset < len(p.data) && p.data[p.offset] != ']' {
+			return p.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+// parseInlineTable parses an inline table into a map node
+func (p *tomlParser) parseInlineTable(node *model.Node) error {
+	node.Kind = model.KindMap
+	node.Children = make([]*model.Node, 0)
+	p.offset++ // {
+
+	p.skipBlank()
+	if p.offset < len(p.data) && p.data[p.offset] == '}' {
+		p.offset++
+		return nil
+	}
+
+	for {
+		if err := p.parseKeyValue(node, false); err != nil {
+			return err
+		}
+		p.skipBlank()
+		if p.offset >= len(p.data) {
+			return p.errorf("unterminated inline table")
+		}
+		switch p.data[p.offset] {
+		case ',':
+			p.offset++
+		case '}':
+			p.offset++
+			return nil
+		default:
+			return p.errorf("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+// parseBasicString parses a "basic" or """multi-line basic""" string
+func (p *tomlParser) parseBasicString() (string, error) {
+	multiline := p.hasPrefix(`"""`)
+	if multiline {
+		p.offset += 3
+		p.skipNewline()
+	} else {
+		p.offset++
+	}
+
+	var b strings.Builder
+	for p.offset < len(p.data) {
+		c := p.data[p.offset]
+		switch {
+		case multiline && p.hasPrefix(`"""`):
+			p.offset += 3
+			// Up to two quotes may directly precede the closing delimiter
+			for i := 0; i < 2 && p.offset < len(p.data) && p.data[p.offset] == '"'; i++ {
+				b.WriteByte('"')
+				p.offset++
+			}
+			return b.String(), nil
+		case !multiline && c == '"':
+			p.offset++
+			return b.String(), nil
+		case !multiline && c == '\n':
+			return "", p.errorf("unterminated string")
+		case c == '\\':
+			if err := p.parseEscape(&b, multiline); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+			p.offset++
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// parseEscape decodes an escape sequence in a basic string
+func (p *tomlParser) parseEscape(b *strings.Builder, multiline bool) error {
+	p.offset++ // backslash
+	if p.offset >= len(p.data) {
+		return p.errorf("unterminated escape")
+	}
+
+	c := p.data[p.offset]
+	p.offset++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case 'e':
+		b.WriteByte('\x1b')
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.offset+size > len(p.data) {
+			return p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(string(p.data[p.offset:p.offset+size]), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorf("invalid unicode escape")
+		}
+		b.WriteRune(rune(code))
+		p.offset += size
+	case ' ', '\t', '\r', '\n':
+		if !multiline {
+			return p.errorf("invalid escape %q", c)
+		}
+		// Line-ending backslash trims all whitespace up to the next content
+		p.offset--
+		for p.offset < len(p.data) && strings.IndexByte(" \t\r\n", p.data[p.offset]) >= 0 {
+			p.offset++
+		}
+	default:
+		return p.errorf("invalid escape %q", c)
+	}
+	return nil
+}
+
+// parseLiteralString parses a literal or multi-line literal string
+func (p *tomlParser) parseLiteralString() (string, error) {
+	if p.hasPrefix("'''") {
+		p.offset += 3
+		p.skipNewline()
+		end := bytes.Index(p.data[p.offset:], []byte("'''"))
+		if end < 0 {
+			return "", p.errorf("unterminated string")
+		}
+		end += p.offset
+		// Up to two quotes may directly precede the closing delimiter
+		for i := 0; i < 2 && end+3 < len(p.data) && p.data[end+3] == '\''; i++ {
+			end++
+		}
+		value := string(p.data[p.offset:end])
+		p.offset = end + 3
+		return value, nil
+	}
+
+	p.offset++ // opening quote
+	start := p.offset
+	for p.offset < len(p.data) && p.data[p.offset] != '\'' {
+		if p.data[p.offset] == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		p.offset++
+	}
+	if p.offset >= len(p.data) {
+		return "", p.errorf("unterminated string")
+	}
+	value := string(p.data[start:p.offset])
+	p.offset++
+	return value, nil
+}
+
+// skipNewline skips a newline directly following a multi-line string delimiter
+func (p *tomlParser) skipNewline() {
+	if p.hasPrefix("\r\n") {
+		p.offset += 2
+	} else if p.offset < len(p.data) && p.data[p.offset] == '\n' {
+		p.offset++
+	}
+}
+
+// parseBareValue parses a boolean, number or date/time
+func (p *tomlParser) parseBareValue(node *model.Node) error {
+	start := p.offset
+	for p.offset < len(p.data) && strings.IndexByte(" \t\r\n,]}#", p.data[p.offset]) < 0 {
+		p.offset++
+	}
+	value := string(p.data[start:p.offset])
+
+	// A date may be followed by a space and a time
+	if tomlDatePattern.MatchString(value) && p.offset+3 < len(p.data) && p.data[p.offset] == ' ' &&
+		isDigit(p.data[p.offset+1]) && isDigit(p.data[p.offset+2]) && p.data[p.offset+3] == ':' {
+		p.offset++
+		for p.offset < len(p.data) && strings.IndexByte(" \t\r\n,]}#", p.data[p.offset]) < 0 {
+			p.offset++
+		}
+		value = string(p.data[start:p.offset])
+	}
+
+	node.Kind = model.KindScalar
+	node.ScalarValue = value
+
+	scalarType, ok := tomlScalarType(value)
+	if !ok {
+		p.offset = start
+		return p.errorf("invalid value %q", value)
+	}
+	node.ScalarType = scalarType
+	return nil
+}
+
+// tomlScalarType returns the type of a bare TOML value
+func tomlScalarType(value string) (model.ScalarType, bool) {
+	switch value {
+	case "true", "false":
+		return model.ScalarBool, true
+	case "inf", "+inf", "-inf", "nan", "+nan", "-nan":
+		return model.ScalarFloat, true
+	}
+
+	if tomlDatePattern.MatchString(value) || tomlDateTimePattern.MatchString(value) || tomlTimePattern.MatchString(value) {
+		return model.ScalarTimestamp, true
+	}
+
+	digits := strings.ReplaceAll(value, "_", "")
+	if strings.HasPrefix(value, "_") || strings.HasSuffix(value, "_") || strings.Contains(value, "__") {
+		return 0, false
+	}
+	for prefix, base := range map[string]int{"0x": 16, "0o": 8, "0b": 2} {
+		if strings.HasPrefix(digits, prefix) {
+			_, err := strconv.ParseUint(digits[2:], base, 64)
+			return model.ScalarInt, err == nil
+		}
+	}
+	if _, err := strconv.ParseInt(digits, 10, 64); err == nil {
+		return model.ScalarInt, true
+	}
+	if _, err := strconv.ParseFloat(digits, 64); err == nil && strings.ContainsAny(digits, ".eE") {
+		return model.ScalarFloat, true
+	}
+	return 0, false
+}
+
+// isDigit returns true for ASCII digits
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// setTOMLEnd extends the end position of tables to cover their children
+// Tables are filled in after their header is parsed, so their end is only
+// known once the whole document has been read.
+func setTOMLEnd(node *model.Node) {
+	for _, child := range node.Children {
+		setTOMLEnd(child)
+		if node.EndPos.Before(child.EndPos) {
+			node.EndPos = child.EndPos
+		}
+	}
+}
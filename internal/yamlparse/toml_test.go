@@ -0,0 +1,128 @@
+package yamlparse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/uznog/yamlist/internal/model"
+)
+
+func TestParseFile_TOML(t *testing.T) {
+	doc, err := ParseFile("../../testdata/Cargo.toml")
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	if doc.Format != FormatTOML {
+		t.Errorf("Expected TOML format, got %q", doc.Format)
+	}
+
+	tests := []struct {
+		path       string
+		kind       model.NodeKind
+		scalarType model.ScalarType
+		value      string
+		line       int
+	}{
+		{"package", model.KindMap, 0, "", 2},
+		{"package.name", model.KindScalar, model.ScalarString, "yamlist-demo", 3},
+		{"package.authors", model.KindList, 0, "", 6},
+		{"package.authors[1]", model.KindScalar, model.ScalarString, "Bob <bob@example.com>", 8},
+		{"dependencies.serde.features[0]", model.KindScalar, model.ScalarString, "derive", 12},
+		{"dependencies.tokio", model.KindMap, 0, "", 13},
+		{"dependencies.tokio.features[0]", model.KindScalar, model.ScalarString, "full", 14},
+		{"profile.release.lto", model.KindScalar, model.ScalarBool, "true", 17},
+		{"profile.release.opt-level", model.KindScalar, model.ScalarInt, "3", 18},
+		{"bin", model.KindList, 0, "", 20},
+		{"bin[1].name", model.KindScalar, model.ScalarString, "worker", 25},
+		{"bin[1].path", model.KindScalar, model.ScalarString, `src\worker.rs`, 26},
+		{"metadata.released", model.KindScalar, model.ScalarTimestamp, "2024-03-01T12:30:00Z", 29},
+		{"metadata.build-date", model.KindScalar, model.ScalarTimestamp, "2024-03-01", 30},
+		{"metadata.ratio", model.KindScalar, model.ScalarFloat, "1_000.5", 31},
+		{"metadata.mask", model.KindScalar, model.ScalarInt, "0xff", 32},
+		{"metadata.description", model.KindScalar, model.ScalarString, "Multi-line description", 33},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			node := doc.FindByPath(tt.path)
+			if node == nil {
+				t.Fatalf("FindByPath(%q) returned nil", tt.path)
+			}
+			if node.Kind != tt.kind {
+				t.Errorf("Kind = %v, want %v", node.Kind, tt.kind)
+			}
+			if tt.kind == model.KindScalar {
+				if node.ScalarType != tt.scalarType {
+					t.Errorf("ScalarType = %v, want %v", node.ScalarType, tt.scalarType)
+				}
+				if node.ScalarValue != tt.value {
+					t.Errorf("ScalarValue = %q, want %q", node.ScalarValue, tt.value)
+				}
+			}
+			if node.LineNumber != tt.line {
+				t.Errorf("LineNumber = %d, want %d", node.LineNumber, tt.line)
+			}
+		})
+	}
+
+	if bins := doc.FindByPath("bin"); len(bins.Children) != 2 {
+		t.Errorf("Expected 2 [[bin]] tables, got %d", len(bins.Children))
+	}
+
+	pkg := doc.FindByPath("package")
+	if pkg.CommentText() != "Package metadata" {
+		t.Errorf("Expected head comment 'Package metadata', got %q", pkg.CommentText())
+	}
+	if lto := doc.FindByPath("profile.release.lto"); lto.InlineComment() != "smaller binaries" {
+		t.Errorf("Expected line comment 'smaller binaries', got %q", lto.InlineComment())
+	}
+	if !pkg.ContainsLine(9) || pkg.ContainsLine(11) {
+		t.Errorf("Expected [package] to span lines 2-9, got end %+v", pkg.EndPos)
+	}
+}
+
+func TestParseTOML_TablesBelowDottedKeys(t *testing.T) {
+	data := "[fruit]\napple.color = \"red\"\napple.taste.sweet = true\n\n[fruit.apple.texture]\nsmooth = true\n"
+	doc, err := ParseTOML([]byte(data), "<string>")
+	if err != nil {
+		t.Fatalf("ParseTOML failed: %v", err)
+	}
+	for _, path := range []string{"fruit.apple.color", "fruit.apple.taste.sweet", "fruit.apple.texture.smooth"} {
+		if doc.FindByPath(path) == nil {
+			t.Errorf("FindByPath(%q) returned nil", path)
+		}
+	}
+}
+
+func TestParseTOML_Errors(t *testing.T) {
+	tests := []struct {
+		input    string
+		contains string
+	}{
+		{"a = 1\na = 2\n", "already defined"},
+		{"[a]\n[a]\n", "already defined"},
+		{"a.b = 1\n[a]\n", "already defined by dotted keys"},
+		{"a.b.c = 1\n[a.b]\n", "already defined by dotted keys"},
+		{"[a.b]\n[a]\nb.c = 1\n", "already defined"},
+		{"a = {b = 1}\n[a.c]\n", "defined inline"},
+		{"a = {b = 1}\na.c = 2\n", "defined inline"},
+		{"a = [1]\n[[a]]\n", "not an array of tables"},
+		{"a = \n", "line 1"},
+		{"a = \"unterminated\n", "unterminated string"},
+		{"a = 1 2\n", "expected end of line"},
+		{"a = [1, 2\n", "unterminated array"},
+		{"a = 1__0\n", "invalid value"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseTOML([]byte(tt.input), "<string>")
+		if err == nil {
+			t.Errorf("ParseTOML(%q): expected error", tt.input)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.contains) {
+			t.Errorf("ParseTOML(%q): expected error containing %q, got %q", tt.input, tt.contains, err)
+		}
+	}
+}
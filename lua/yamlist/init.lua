@@ -34,16 +34,66 @@ local function create_float_win()
   return buf, win
 end
 
+-- File extensions yamlist can open
+local supported_exts = { yaml = true, yml = true, json = true, jsonl = true, toml = true }
+
 -- Write buffer content to a temporary file
-local function write_buffer_to_temp(bufnr)
+-- The extension is kept so yamlist detects the format
+local function write_buffer_to_temp(bufnr, ext)
   local lines = vim.api.nvim_buf_get_lines(bufnr, 0, -1, false)
-  local tmpfile = vim.fn.tempname() .. ".yaml"
+  local tmpfile = vim.fn.tempname() .. "." .. ext
   vim.fn.writefile(lines, tmpfile)
   return tmpfile
 end
 
+-- Namespace for parse error diagnostics reported by yamlist
+local diagnostic_ns = vim.api.nvim_create_namespace("yamlist")
+
+-- Show (or clear, when msg.line is absent) a parse error diagnostic
+local function set_diagnostic(edit_buf, msg)
+  if not vim.api.nvim_buf_is_valid(edit_buf) then
+    return
+  end
+  if not msg.line then
+    vim.diagnostic.reset(diagnostic_ns, edit_buf)
+    return
+  end
+  vim.diagnostic.set(diagnostic_ns, edit_buf, {
+    {
+      lnum = msg.line - 1,
+      col = math.max(0, (msg.col or 1) - 1),
+      message = msg.message or "syntax error",
+      severity = vim.diagnostic.severity.ERROR,
+      source = "yamlist",
+    },
+  })
+end
+
+-- Replace the buffer contents with text edited in yamlist
+local function replace_buffer(edit_buf, text)
+  if not vim.api.nvim_buf_is_valid(edit_buf) then
+    return
+  end
+  local lines = vim.split(text, "\n", { plain = true })
+  if lines[#lines] == "" then
+    table.remove(lines)
+  end
+  vim.api.nvim_buf_set_lines(edit_buf, 0, -1, false, lines)
+end
+
+-- Put text copied in yamlist into the unnamed register, and the clipboard
+-- register when a clipboard provider is available
+local function set_registers(text)
+  vim.fn.setreg('"', text)
+  if vim.fn.has("clipboard") == 1 then
+    pcall(vim.fn.setreg, "+", text)
+  end
+end
+
 -- Start a Unix socket server for cursor sync
-local function start_socket_server(edit_win)
+-- session.client is set once yamlist connects; session.applied holds the
+-- last cursor position set on behalf of yamlist
+local function start_socket_server(session, edit_win, edit_buf)
   local uv = vim.loop
   local socket_path = vim.fn.tempname() .. ".sock"
   local server = uv.new_pipe(false)
@@ -55,10 +105,16 @@ local function start_socket_server(edit_win)
     end
     local client = uv.new_pipe(false)
     server:accept(client)
+    session.client = client
+    if session.buffer_sync then
+      -- Edits made in yamlist should replace the buffer contents
+      client:write(vim.json.encode({ op = "attach" }) .. "\n")
+    end
 
     local buffer = ""
     client:read_start(function(read_err, data)
       if read_err or not data then
+        session.client = nil
         client:close()
         return
       end
@@ -74,9 +130,21 @@ local function start_socket_server(edit_win)
         local line = buffer:sub(1, newline_pos - 1)
         buffer = buffer:sub(newline_pos + 1)
 
-        -- Parse JSON and handle cursor message
+        -- Parse JSON and handle cursor and diagnostic messages
         local ok, msg = pcall(vim.json.decode, line)
-        if ok and msg.op == "cursor" and msg.line then
+        if ok and msg.op == "diagnostic" then
+          vim.schedule(function()
+            set_diagnostic(edit_buf, msg)
+          end)
+        elseif ok and msg.op == "replace" and session.buffer_sync and msg.text then
+          vim.schedule(function()
+            replace_buffer(edit_buf, msg.text)
+          end)
+        elseif ok and msg.op == "yank" and msg.text then
+          vim.schedule(function()
+            set_registers(msg.text)
+          end)
+        elseif ok and msg.op == "cursor" and msg.line then
           vim.schedule(function()
             if vim.api.nvim_win_is_valid(edit_win) then
               -- Clamp line number to valid range
@@ -85,7 +153,11 @@ local function start_socket_server(edit_win)
               )
               local target_line = math.min(msg.line, line_count)
               target_line = math.max(1, target_line)
-              pcall(vim.api.nvim_win_set_cursor, edit_win, { target_line, 0 })
+              -- yamlist sends 1-based byte columns, Neovim's are 0-based
+              local target_col = math.max(0, (msg.col or 1) - 1)
+              if pcall(vim.api.nvim_win_set_cursor, edit_win, { target_line, target_col }) then
+                session.applied = vim.api.nvim_win_get_cursor(edit_win)
+              end
             end
           end)
         end
@@ -96,6 +168,60 @@ local function start_socket_server(edit_win)
   return server, socket_path
 end
 
+-- Send the edit buffer cursor to yamlist when it moves
+-- Moves caused by yamlist itself are not echoed back
+local function start_cursor_sync(session, edit_buf)
+  local group = vim.api.nvim_create_augroup("YAMListSync" .. edit_buf, { clear = true })
+  vim.api.nvim_create_autocmd({ "CursorMoved", "CursorMovedI" }, {
+    group = group,
+    buffer = edit_buf,
+    callback = function()
+      local pos = vim.api.nvim_win_get_cursor(0)
+      local applied = session.applied
+      session.applied = nil
+      if applied and applied[1] == pos[1] and applied[2] == pos[2] then
+        return
+      end
+      if session.client and not session.client:is_closing() then
+        local msg = vim.json.encode({ op = "cursor", line = pos[1], col = pos[2] + 1 })
+        session.client:write(msg .. "\n")
+      end
+    end,
+  })
+  return group
+end
+
+-- Delay before buffer edits are sent to yamlist
+local buffer_sync_delay = 150
+
+-- Stream the edit buffer contents to yamlist as they change, so unsaved
+-- edits show up in the tree. Sends are debounced while typing.
+local function start_buffer_sync(session, edit_buf, group)
+  local timer = vim.loop.new_timer()
+  session.timer = timer
+  session.buffer_sync = true
+
+  local function send()
+    if not vim.api.nvim_buf_is_valid(edit_buf) then
+      return
+    end
+    if session.client and not session.client:is_closing() then
+      local lines = vim.api.nvim_buf_get_lines(edit_buf, 0, -1, false)
+      local text = table.concat(lines, "\n") .. "\n"
+      session.client:write(vim.json.encode({ op = "buffer", text = text }) .. "\n")
+    end
+  end
+
+  vim.api.nvim_create_autocmd({ "TextChanged", "TextChangedI" }, {
+    group = group,
+    buffer = edit_buf,
+    callback = function()
+      timer:stop()
+      timer:start(buffer_sync_delay, 0, vim.schedule_wrap(send))
+    end,
+  })
+end
+
 function M.open(file)
   local edit_win = vim.api.nvim_get_current_win()
   local edit_buf = vim.api.nvim_get_current_buf()
@@ -115,18 +241,28 @@ function M.open(file)
     -- Use current buffer content via temp file
     local bufname = vim.api.nvim_buf_get_name(edit_buf)
     local ext = vim.fn.fnamemodify(bufname, ":e"):lower()
-    if ext ~= "yaml" and ext ~= "yml" and bufname ~= "" then
-      vim.notify("YAMList: Not a YAML file: " .. bufname, vim.log.levels.ERROR)
+    if not supported_exts[ext] and bufname ~= "" then
+      vim.notify("YAMList: Not a YAML, JSON or TOML file: " .. bufname, vim.log.levels.ERROR)
       return
     end
+    if not supported_exts[ext] then
+      -- Unnamed buffer: let yamlist sniff the content
+      ext = "txt"
+    end
 
     -- Write buffer content to temp file
-    tmpfile = write_buffer_to_temp(edit_buf)
+    tmpfile = write_buffer_to_temp(edit_buf, ext)
     source_file = tmpfile
   end
 
-  -- Start socket server for cursor sync
-  local server, socket_path = start_socket_server(edit_win)
+  -- Start socket server for cursor and buffer sync
+  local session = {}
+  local server, socket_path = start_socket_server(session, edit_win, edit_buf)
+  local sync_group = start_cursor_sync(session, edit_buf)
+  local bufname = vim.api.nvim_buf_get_name(edit_buf)
+  if tmpfile or (bufname ~= "" and vim.fn.fnamemodify(file, ":p") == bufname) then
+    start_buffer_sync(session, edit_buf, sync_group)
+  end
 
   -- Create floating terminal window
   local term_buf, term_win = create_float_win()
@@ -143,6 +279,11 @@ function M.open(file)
   vim.fn.termopen(cmd, {
     on_exit = function()
       -- Cleanup
+      pcall(vim.api.nvim_del_augroup_by_id, sync_group)
+      if session.timer then
+        session.timer:stop()
+        session.timer:close()
+      end
       if server then
         server:close()
       end